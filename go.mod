@@ -12,6 +12,7 @@ require (
 	github.com/ttacon/libphonenumber v1.2.1
 	go.uber.org/config v1.4.0
 	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
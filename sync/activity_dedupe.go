@@ -0,0 +1,148 @@
+package sync
+
+import (
+	"context"
+	"log"
+	gosync "sync"
+)
+
+// ActivityDedupeStore remembers the [OrttoActivity.ContentHash] of the
+// last activity Ortto ingested for each person and activity type, so
+// identical activities produced by high-frequency webhooks (e.g.
+// profile.totalUpdated) are not sent again. Implementations typically
+// live downstream in a shared store; [MemoryActivityDedupeStore] is
+// provided for single instance deployments and tests.
+//
+// Keys are built with [ActivityDedupeKey]. Get returns ok=false when no
+// hash has been recorded.
+type ActivityDedupeStore interface {
+	Get(ctx context.Context, key string) (hash string, ok bool, err error)
+	Set(ctx context.Context, key string, hash string) error
+}
+
+// MemoryActivityDedupeStore is an in-process [ActivityDedupeStore]. Safe
+// for concurrent use.
+type MemoryActivityDedupeStore struct {
+	mu     gosync.Mutex
+	hashes map[string]string
+}
+
+// NewMemoryActivityDedupeStore returns an empty MemoryActivityDedupeStore.
+func NewMemoryActivityDedupeStore() *MemoryActivityDedupeStore {
+	return &MemoryActivityDedupeStore{hashes: make(map[string]string)}
+}
+
+// Get implements [ActivityDedupeStore].
+func (s *MemoryActivityDedupeStore) Get(ctx context.Context, key string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hash, ok := s.hashes[key]
+	return hash, ok, nil
+}
+
+// Set implements [ActivityDedupeStore].
+func (s *MemoryActivityDedupeStore) Set(ctx context.Context, key string, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hashes[key] = hash
+	return nil
+}
+
+// ActivityDedupeKey returns the [ActivityDedupeStore] key for an
+// activity type sent to the person identified by mergeValue (the value
+// of the first of the request's MergeBy fields that is set).
+func ActivityDedupeKey(mergeValue, activityID string) string {
+	return mergeValue + "|" + activityID
+}
+
+// orttoMergeValue returns the first of mergeBy's fields with a non-empty
+// string value in fields, which is the one Ortto matches the person on.
+func orttoMergeValue(mergeBy []string, fields map[string]interface{}) (fieldID, value string) {
+	for _, id := range mergeBy {
+		if v, ok := fields[id].(string); ok && v != "" {
+			return id, v
+		}
+	}
+	return "", ""
+}
+
+// dedupedActivity is an activity that passed dedupeActivities and whose
+// hash is recorded once Ortto confirms ingestion.
+type dedupedActivity struct {
+	key  string
+	hash string
+}
+
+// dedupeActivities drops the activities in req whose content hash matches
+// the last one ingested for the same person and activity type. It returns
+// the request to send, the activities kept (in request order) and how
+// many were dropped. Contacts requests, and every request when no store
+// is configured, are returned unchanged.
+//
+// A store that fails to answer never blocks a send: the activity is kept
+// and a warning logged.
+func (s *Service) dedupeActivities(req OrttoRequest, ctx context.Context) (OrttoRequest, []dedupedActivity, int) {
+	if s.dedupe == nil || req == nil {
+		return req, nil, 0
+	}
+	activities, ok := req.AsOrttoActivitiesRequest()
+	if !ok {
+		return req, nil, 0
+	}
+
+	kept := make([]OrttoActivity, 0, len(activities.Activities))
+	keys := make([]dedupedActivity, 0, len(activities.Activities))
+	for _, activity := range activities.Activities {
+		_, mergeValue := orttoMergeValue(activities.MergeBy, activity.Fields)
+		if mergeValue == "" {
+			kept = append(kept, activity)
+			keys = append(keys, dedupedActivity{})
+			continue
+		}
+		entry := dedupedActivity{
+			key:  ActivityDedupeKey(mergeValue, activity.ActivityID),
+			hash: activity.ContentHash(),
+		}
+		last, found, err := s.dedupe.Get(ctx, entry.key)
+		if err != nil {
+			log.Printf("Warning: activity dedupe lookup failed for %s (sending anyway): %v", activity.ActivityID, err)
+		} else if found && last == entry.hash {
+			if s.sc.Debug {
+				log.Printf("Debug: Skipping unchanged activity %s (hash %s)\n", activity.ActivityID, entry.hash)
+			}
+			continue
+		}
+		kept = append(kept, activity)
+		keys = append(keys, entry)
+	}
+
+	dropped := len(activities.Activities) - len(kept)
+	if dropped == 0 {
+		return req, keys, 0
+	}
+	activities.Activities = kept
+	return activities, keys, dropped
+}
+
+// recordActivities stores the hash of each kept activity that Ortto
+// reports as ingested. Nothing is recorded when the response doesn't
+// carry one result per activity (e.g. for an Async request), so those
+// activities are sent again next time rather than risk skipping one
+// Ortto never ingested.
+func (s *Service) recordActivities(kept []dedupedActivity, resp OrttoResponse, ctx context.Context) {
+	if s.dedupe == nil || len(kept) == 0 {
+		return
+	}
+	r, ok := resp.(OrttoActivitiesResponse)
+	if !ok || len(r.Activities) != len(kept) {
+		return
+	}
+	for i, entry := range kept {
+		if entry.key == "" || r.Activities[i].Status != "ingested" {
+			continue
+		}
+		if err := s.dedupe.Set(ctx, entry.key, entry.hash); err != nil {
+			log.Printf("Warning: failed to record activity dedupe hash for %s: %v", entry.key, err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package sync
+
+import (
+	"testing"
+)
+
+func testActivity(email, amount string) OrttoActivity {
+	return OrttoActivity{
+		ActivityID: "act:cm:total-updated",
+		Fields:     map[string]interface{}{"str::email": email},
+		Attributes: OrttoAttributes{
+			"str:cm:total":        amount,
+			"obj:cm:sync-context": map[string]interface{}{"trigger": email + amount},
+		},
+	}
+}
+
+func testActivitiesRequest(activities ...OrttoActivity) OrttoActivitiesRequest {
+	return OrttoActivitiesRequest{MergeBy: []string{"str::email"}, Activities: activities}
+}
+
+func newTestDedupeService(mapper OrttoMapper) *Service {
+	return &Service{sc: &SyncContext{}, mapper: mapper, dedupe: NewMemoryActivityDedupeStore()}
+}
+
+func TestSendRequest_SkipsUnchangedActivities(t *testing.T) {
+	t.Parallel()
+	mapper := &recordingOrttoMapper{}
+	svc := newTestDedupeService(mapper)
+
+	if _, err := svc.SendRequest(testActivitiesRequest(testActivity("a@example.com", "10")), t.Context()); err != nil {
+		t.Fatalf("SendRequest returned unexpected error: %v", err)
+	}
+
+	// Same content with different sync metadata, plus a second person.
+	repeat := testActivity("a@example.com", "10")
+	repeat.Attributes["obj:cm:sync-context"] = map[string]interface{}{"trigger": "another"}
+	resp, err := svc.SendRequest(testActivitiesRequest(repeat, testActivity("b@example.com", "10")), t.Context())
+	if err != nil {
+		t.Fatalf("SendRequest returned unexpected error: %v", err)
+	}
+	if len(mapper.sent) != 2 || mapper.sent[1].ItemCount() != 1 {
+		t.Fatalf("expected only b's activity in the second send, got %v", mapper.sent)
+	}
+	if r := resp.(OrttoActivitiesResponse); len(r.Activities) != 1 {
+		t.Errorf("expected the response to cover the 1 activity sent, got %d", len(r.Activities))
+	}
+
+	// Nothing left to send.
+	if _, err := svc.SendRequest(testActivitiesRequest(testActivity("b@example.com", "10")), t.Context()); err != nil {
+		t.Fatalf("SendRequest returned unexpected error: %v", err)
+	}
+	if len(mapper.sent) != 2 {
+		t.Errorf("expected no send for an unchanged activity, got %d sends", len(mapper.sent))
+	}
+
+	// A changed total goes through.
+	if _, err := svc.SendRequest(testActivitiesRequest(testActivity("a@example.com", "20")), t.Context()); err != nil {
+		t.Fatalf("SendRequest returned unexpected error: %v", err)
+	}
+	if len(mapper.sent) != 3 {
+		t.Errorf("expected a changed activity to be sent, got %d sends", len(mapper.sent))
+	}
+}
+
+func TestSendRequest_RecordsOnlyIngestedActivities(t *testing.T) {
+	t.Parallel()
+	mapper := &recordingOrttoMapper{activityStatus: "rejected"}
+	svc := newTestDedupeService(mapper)
+	req := testActivitiesRequest(testActivity("a@example.com", "10"))
+
+	for range 2 {
+		if _, err := svc.SendRequest(req, t.Context()); err != nil {
+			t.Fatalf("SendRequest returned unexpected error: %v", err)
+		}
+	}
+	if len(mapper.sent) != 2 {
+		t.Errorf("expected an activity Ortto didn't ingest to be resent, got %d sends", len(mapper.sent))
+	}
+}
+
+func TestSendRequests_SkipsUnchangedActivities(t *testing.T) {
+	t.Parallel()
+	mapper := &recordingOrttoMapper{}
+	svc := newTestDedupeService(mapper)
+	reqs := []OrttoRequest{
+		testActivitiesRequest(testActivity("a@example.com", "10")),
+		testActivitiesRequest(testActivity("b@example.com", "10")),
+	}
+
+	if _, err := svc.SendRequests(reqs, t.Context()); err != nil {
+		t.Fatalf("SendRequests returned unexpected error: %v", err)
+	}
+	reqs[1] = testActivitiesRequest(testActivity("b@example.com", "15"))
+	results, err := svc.SendRequests(reqs, t.Context())
+	if err != nil {
+		t.Fatalf("SendRequests returned unexpected error: %v", err)
+	}
+
+	if len(mapper.sent) != 2 || mapper.sent[1].ItemCount() != 1 {
+		t.Fatalf("expected the second call to send only b's changed activity, got %v", mapper.sent)
+	}
+	if results[0].Response != nil {
+		t.Errorf("expected no response for a fully skipped request, got %v", results[0].Response)
+	}
+	if r, _ := results[1].Response.(OrttoActivitiesResponse); len(r.Activities) != 1 {
+		t.Errorf("expected b's result to be split back out, got %v", results[1].Response)
+	}
+}
@@ -0,0 +1,361 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	gosync "sync"
+)
+
+// campaignRegistry is built by Init from the process environment.
+var campaignRegistry *CampaignRegistry
+
+// Campaigns returns the CampaignRegistry built by Init, or
+// ErrNotInitialised if Init has not been called.
+func Campaigns() (*CampaignRegistry, error) {
+	if _, err := initialised(); err != nil {
+		return nil, err
+	}
+	return campaignRegistry, nil
+}
+
+// CampaignRegistry indexes the campaign env vars (see
+// [CampaignEnvVarPrefix]) so webhook handling can find a campaign and
+// its parsed config without scanning the environment or re-reading YAML
+// on every request. Init builds it once; call Refresh after changing
+// the environment or the mapping files.
+type CampaignRegistry struct {
+	uuidKey    string
+	quarantine bool
+
+	mu          gosync.RWMutex
+	byUUID      map[string]CampaignEnvVar
+	all         []CampaignEnvVar // sorted by Name
+	quarantined []CampaignEnvVarError
+	configs     map[campaignConfigKey][]Config
+}
+
+// campaignConfigKey identifies cached configs: the same campaign loads
+// differently with different mappings and options (see
+// [ConfigWithCacheKey]), and Config (one target) and Configs (every
+// target) are cached separately.
+type campaignConfigKey struct {
+	uuid       string
+	cacheKey   string
+	allTargets bool
+}
+
+// CampaignEnvVarError is a problem with one env var found while
+// indexing the campaign env vars.
+type CampaignEnvVarError struct {
+	Name string // env var name
+	Err  error
+}
+
+func (e CampaignEnvVarError) Error() string {
+	return e.Err.Error()
+}
+
+func (e CampaignEnvVarError) Unwrap() error {
+	return e.Err
+}
+
+// CampaignEnvVarsError lists every env var problem found while indexing
+// the campaign env vars.
+type CampaignEnvVarsError struct {
+	Problems []CampaignEnvVarError
+}
+
+func (e *CampaignEnvVarsError) Error() string {
+	lines := make([]string, 0, len(e.Problems)+1)
+	lines = append(lines, fmt.Sprintf("%d invalid campaign env var(s):", len(e.Problems)))
+	for _, p := range e.Problems {
+		lines = append(lines, "  "+p.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *CampaignEnvVarsError) Unwrap() []error {
+	errs := make([]error, len(e.Problems))
+	for i, p := range e.Problems {
+		errs[i] = p
+	}
+	return errs
+}
+
+// newCampaignRegistry indexes environ (in os.Environ form) after
+// checking no campaign UUID is used twice and every env var name starts
+// with the org from its MAPPING_PATH. If quarantine is set, env vars
+// with problems are left out (see Quarantined) instead of failing.
+func newCampaignRegistry(flavour Flavour, environ []string, quarantine bool) (*CampaignRegistry, error) {
+	uuidKey, err := campaignUUIDKeyForFlavour(flavour)
+	if err != nil {
+		return nil, err
+	}
+	r := &CampaignRegistry{uuidKey: uuidKey, quarantine: quarantine}
+	if err := r.index(environ); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// jsonEnvVar is an env var whose value is a JSON object of strings.
+type jsonEnvVar struct {
+	name   string
+	values map[string]string
+}
+
+// parseJSONEnvVars returns the env vars in environ with JSON object
+// values. Most env vars are plain strings (e.g. PATH) and are skipped,
+// but a campaign env var must be valid JSON.
+func parseJSONEnvVars(environ []string) ([]jsonEnvVar, []CampaignEnvVarError) {
+	var result []jsonEnvVar
+	var problems []CampaignEnvVarError
+	for _, env := range environ {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name, value := parts[0], parts[1]
+
+		var m map[string]string
+		if err := json.Unmarshal([]byte(value), &m); err != nil {
+			if strings.HasPrefix(name, CampaignEnvVarPrefix) {
+				problems = append(problems, CampaignEnvVarError{name, fmt.Errorf("env var %q has %s prefix but contains invalid JSON: %w", name, CampaignEnvVarPrefix, err)})
+			}
+			continue
+		}
+		result = append(result, jsonEnvVar{name: name, values: m})
+	}
+	return result, problems
+}
+
+func (r *CampaignRegistry) index(environ []string) error {
+	vars, problems := parseJSONEnvVars(environ)
+	problems = append(problems, validateNoDuplicateCampaignUUIDs(r.uuidKey, vars)...)
+	problems = append(problems, validateEnvVarOrgPrefix(vars)...)
+	for _, v := range vars {
+		if _, ok := v.values[r.uuidKey]; ok && strings.HasPrefix(v.name, CampaignEnvVarPrefix) && v.values["MAPPING_PATH"] == "" {
+			problems = append(problems, CampaignEnvVarError{v.name, fmt.Errorf("env var %q contains %s but is missing MAPPING_PATH", v.name, r.uuidKey)})
+		}
+	}
+	if len(problems) > 0 && !r.quarantine {
+		return &CampaignEnvVarsError{Problems: problems}
+	}
+
+	quarantined := make(map[string]bool)
+	for _, p := range problems {
+		quarantined[p.Name] = true
+		log.Printf("Warning: quarantining env var %s: %v", p.Name, p.Err)
+	}
+
+	byUUID := make(map[string]CampaignEnvVar)
+	var all []CampaignEnvVar
+	for _, v := range vars {
+		if !strings.HasPrefix(v.name, CampaignEnvVarPrefix) || quarantined[v.name] {
+			continue
+		}
+		uuid, ok := v.values[r.uuidKey]
+		if !ok {
+			continue
+		}
+		envVar := CampaignEnvVar{Name: v.name, Path: v.values["MAPPING_PATH"], UUID: uuid, Config: v.values}
+		byUUID[uuid] = envVar
+		all = append(all, envVar)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byUUID = byUUID
+	r.all = all
+	r.quarantined = problems
+	r.configs = make(map[campaignConfigKey][]Config)
+	return nil
+}
+
+// Refresh rescans the environment and drops all cached configs. On
+// error (a *CampaignEnvVarsError unless the registry quarantines) the
+// registry is left unchanged.
+func (r *CampaignRegistry) Refresh() error {
+	return r.index(os.Environ())
+}
+
+// Quarantined returns the env var problems that kept campaigns out of
+// the registry when it was built with [InitWithQuarantine].
+func (r *CampaignRegistry) Quarantined() []CampaignEnvVarError {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]CampaignEnvVarError(nil), r.quarantined...)
+}
+
+// Lookup returns the campaign env var for a campaign UUID.
+func (r *CampaignRegistry) Lookup(uuid string) (CampaignEnvVar, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	envVar, ok := r.byUUID[uuid]
+	return envVar, ok
+}
+
+// All returns every campaign env var, sorted by env var name.
+func (r *CampaignRegistry) All() []CampaignEnvVar {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]CampaignEnvVar(nil), r.all...)
+}
+
+// ByOrg returns the campaign env vars whose MAPPING_PATH is in org,
+// sorted by env var name.
+func (r *CampaignRegistry) ByOrg(org string) []CampaignEnvVar {
+	return r.filter(func(c CampaignEnvVar) bool { return c.Org() == org })
+}
+
+// ByLabel returns the campaign env vars whose MAPPING_PATH is
+// org/label, sorted by env var name.
+func (r *CampaignRegistry) ByLabel(org, label string) []CampaignEnvVar {
+	return r.filter(func(c CampaignEnvVar) bool { return c.Org() == org && c.Label() == label })
+}
+
+func (r *CampaignRegistry) filter(match func(CampaignEnvVar) bool) []CampaignEnvVar {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []CampaignEnvVar
+	for _, c := range r.all {
+		if match(c) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// Config returns the parsed config for a campaign UUID. Loads with a
+// [ConfigWithCacheKey] option are cached per key until Refresh, so only
+// the first parses the mapping files; loads without one are never
+// cached. Config values are read from the env var as indexed, like
+// [LoadCampaignConfigFromEnvironment]. Each call returns its own copy of
+// the Config, so callers may modify it.
+func (r *CampaignRegistry) Config(embeddedMappings EmbeddedMappings, uuid string, opts ...ConfigOption) (Config, error) {
+	configs, err := r.cachedConfigs(embeddedMappings, uuid, false, opts, loadCampaignConfigAsSlice)
+	if err != nil {
+		return Config{}, err
+	}
+	return configs[0].clone(), nil
+}
+
+// Configs is Config returning one config per Ortto target of the
+// campaign, like [LoadCampaignConfigsFromEnvironment].
+func (r *CampaignRegistry) Configs(embeddedMappings EmbeddedMappings, uuid string, opts ...ConfigOption) ([]Config, error) {
+	configs, err := r.cachedConfigs(embeddedMappings, uuid, true, opts, loadCampaignConfigs)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Config, len(configs))
+	for i, config := range configs {
+		result[i] = config.clone()
+	}
+	return result, nil
+}
+
+type campaignConfigsLoader func(EmbeddedMappings, CampaignEnvVar, CompositeEnvVar, configOptions) ([]Config, error)
+
+func loadCampaignConfigAsSlice(embeddedMappings EmbeddedMappings, envVar CampaignEnvVar, compositeEnvVar CompositeEnvVar, options configOptions) ([]Config, error) {
+	config, err := loadCampaignConfig(embeddedMappings, envVar, compositeEnvVar, options)
+	if err != nil {
+		return nil, err
+	}
+	return []Config{config}, nil
+}
+
+func (r *CampaignRegistry) cachedConfigs(embeddedMappings EmbeddedMappings, uuid string, allTargets bool, opts []ConfigOption, load campaignConfigsLoader) ([]Config, error) {
+	var options configOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	envVar, ok := r.Lookup(uuid)
+	if !ok {
+		return nil, fmt.Errorf("no env var found with %s %q", r.uuidKey, uuid)
+	}
+	if options.cacheKey == "" {
+		return load(embeddedMappings, envVar, MapCompositeEnvVar{Values: envVar.Config}, options)
+	}
+	key := campaignConfigKey{uuid: uuid, cacheKey: options.cacheKey, allTargets: allTargets}
+
+	r.mu.RLock()
+	configs, cached := r.configs[key]
+	r.mu.RUnlock()
+	if cached {
+		return configs, nil
+	}
+
+	configs, err := load(embeddedMappings, envVar, MapCompositeEnvVar{Values: envVar.Config}, options)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if current, ok := r.byUUID[uuid]; ok && current.Name == envVar.Name {
+		r.configs[key] = configs // skip caching if a Refresh replaced the env var meanwhile
+	}
+	return configs, nil
+}
+
+// validateEnvVarOrgPrefix checks every env var with a MAPPING_PATH starts
+// with the org prefix from the path (the portion before the "/").
+func validateEnvVarOrgPrefix(vars []jsonEnvVar) []CampaignEnvVarError {
+	var problems []CampaignEnvVarError
+	for _, v := range vars {
+		mappingPath, ok := v.values["MAPPING_PATH"]
+		if !ok {
+			continue
+		}
+
+		index := strings.Index(mappingPath, "/")
+		if index == -1 {
+			problems = append(problems, CampaignEnvVarError{v.name, fmt.Errorf("MAPPING_PATH %q in env var %q must contain org directory (e.g. ORG/LABEL)", mappingPath, v.name)})
+			continue
+		}
+
+		org := mappingPath[:index]
+		nameWithoutPrefix := strings.TrimPrefix(v.name, CampaignEnvVarPrefix)
+		if !strings.HasPrefix(nameWithoutPrefix, org+"_") {
+			problems = append(problems, CampaignEnvVarError{v.name, fmt.Errorf("env var name %q must start with %s%s (from MAPPING_PATH %q)", v.name, CampaignEnvVarPrefix, org+"_", mappingPath)})
+		}
+	}
+	return problems
+}
+
+// validateNoDuplicateCampaignUUIDs reports each env var sharing its
+// campaign UUID with another, since it's unclear which one is meant.
+func validateNoDuplicateCampaignUUIDs(campaignUUIDKey string, vars []jsonEnvVar) []CampaignEnvVarError {
+	// map of UUID -> env var names
+	seen := make(map[string][]string)
+	var uuids []string
+	for _, v := range vars {
+		uuid, ok := v.values[campaignUUIDKey]
+		if !ok {
+			continue
+		}
+		if _, found := seen[uuid]; !found {
+			uuids = append(uuids, uuid)
+		}
+		seen[uuid] = append(seen[uuid], v.name)
+	}
+
+	var problems []CampaignEnvVarError
+	for _, uuid := range uuids {
+		names := seen[uuid]
+		if len(names) < 2 {
+			continue
+		}
+		err := fmt.Errorf("duplicate %s %q found in env vars %q", campaignUUIDKey, uuid, names)
+		for _, name := range names {
+			problems = append(problems, CampaignEnvVarError{name, err})
+		}
+	}
+	return problems
+}
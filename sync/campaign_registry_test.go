@@ -0,0 +1,227 @@
+package sync
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNewCampaignRegistry(t *testing.T) {
+	t.Parallel()
+	r, err := newCampaignRegistry(Raisely2Ortto, []string{
+		`PATH=/usr/bin`,
+		`FEZ_ACME_SPRING={"MAPPING_PATH":"ACME/SPRING","RAISELY_CAMPAIGN_UUID":"uuid-1"}`,
+		`FEZ_ACME_AUTUMN={"MAPPING_PATH":"ACME/AUTUMN","RAISELY_CAMPAIGN_UUID":"uuid-2"}`,
+		`FEZ_OTHER_SPRING={"MAPPING_PATH":"OTHER/SPRING","RAISELY_CAMPAIGN_UUID":"uuid-3"}`,
+		`FEZ_ACME_FUNRAISIN={"MAPPING_PATH":"ACME/SPRING","FUNRAISIN_CAMPAIGN_UUID":"uuid-4"}`,
+	}, false)
+	if err != nil {
+		t.Fatalf("newCampaignRegistry returned unexpected error: %v", err)
+	}
+
+	if c, ok := r.Lookup("uuid-2"); !ok || c.Name != "FEZ_ACME_AUTUMN" || c.Path != "ACME/AUTUMN" || c.Config["RAISELY_CAMPAIGN_UUID"] != "uuid-2" {
+		t.Errorf("Lookup(uuid-2) = %+v, %v", c, ok)
+	}
+	if _, ok := r.Lookup("uuid-4"); ok {
+		t.Error("expected another flavour's campaigns to be ignored")
+	}
+
+	names := func(envVars []CampaignEnvVar) string {
+		var result []string
+		for _, c := range envVars {
+			result = append(result, c.Name)
+		}
+		return strings.Join(result, ",")
+	}
+	if got := names(r.All()); got != "FEZ_ACME_AUTUMN,FEZ_ACME_SPRING,FEZ_OTHER_SPRING" {
+		t.Errorf("All() = %s", got)
+	}
+	if got := names(r.ByOrg("ACME")); got != "FEZ_ACME_AUTUMN,FEZ_ACME_SPRING" {
+		t.Errorf("ByOrg(ACME) = %s", got)
+	}
+	if got := names(r.ByLabel("OTHER", "SPRING")); got != "FEZ_OTHER_SPRING" {
+		t.Errorf("ByLabel(OTHER, SPRING) = %s", got)
+	}
+}
+
+func TestNewCampaignRegistry_Invalid(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		environ []string
+		wantErr string
+	}{
+		"duplicate uuid": {
+			[]string{
+				`FEZ_ACME_A={"MAPPING_PATH":"ACME/A","RAISELY_CAMPAIGN_UUID":"uuid-1"}`,
+				`FEZ_ACME_B={"MAPPING_PATH":"ACME/B","RAISELY_CAMPAIGN_UUID":"uuid-1"}`,
+			},
+			"duplicate RAISELY_CAMPAIGN_UUID",
+		},
+		"org prefix": {
+			[]string{`FEZ_ACME_A={"MAPPING_PATH":"OTHER/A","RAISELY_CAMPAIGN_UUID":"uuid-1"}`},
+			"must start with FEZ_OTHER_",
+		},
+		"invalid json": {
+			[]string{`FEZ_ACME_A={"MAPPING_PATH":`},
+			"invalid JSON",
+		},
+		"missing mapping path": {
+			[]string{`FEZ_ACME_A={"RAISELY_CAMPAIGN_UUID":"uuid-1"}`},
+			"missing MAPPING_PATH",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := newCampaignRegistry(Raisely2Ortto, tc.environ, false)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("err = %v, want it to contain %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewCampaignRegistry_CollectsAndQuarantines(t *testing.T) {
+	t.Parallel()
+	environ := []string{
+		`FEZ_ACME_GOOD={"MAPPING_PATH":"ACME/GOOD","RAISELY_CAMPAIGN_UUID":"uuid-1"}`,
+		`FEZ_ACME_A={"MAPPING_PATH":"ACME/A","RAISELY_CAMPAIGN_UUID":"uuid-2"}`,
+		`FEZ_ACME_B={"MAPPING_PATH":"ACME/B","RAISELY_CAMPAIGN_UUID":"uuid-2"}`,
+		`FEZ_ACME_BROKEN={`,
+		`FEZ_ACME_WRONG={"MAPPING_PATH":"OTHER/WRONG","RAISELY_CAMPAIGN_UUID":"uuid-3"}`,
+	}
+
+	_, err := newCampaignRegistry(Raisely2Ortto, environ, false)
+	var envErr *CampaignEnvVarsError
+	if !errors.As(err, &envErr) {
+		t.Fatalf("expected a *CampaignEnvVarsError, got %v", err)
+	}
+	var names []string
+	for _, p := range envErr.Problems {
+		names = append(names, p.Name)
+	}
+	if got := strings.Join(names, ","); got != "FEZ_ACME_BROKEN,FEZ_ACME_A,FEZ_ACME_B,FEZ_ACME_WRONG" {
+		t.Errorf("problems for %s", got)
+	}
+
+	r, err := newCampaignRegistry(Raisely2Ortto, environ, true)
+	if err != nil {
+		t.Fatalf("newCampaignRegistry with quarantine returned unexpected error: %v", err)
+	}
+	if all := r.All(); len(all) != 1 || all[0].Name != "FEZ_ACME_GOOD" {
+		t.Errorf("expected only the good campaign to be indexed, got %+v", all)
+	}
+	if q := r.Quarantined(); len(q) != len(envErr.Problems) {
+		t.Errorf("Quarantined() = %v, want %d problems", q, len(envErr.Problems))
+	}
+}
+
+func TestCampaignRegistry_ConfigCached(t *testing.T) {
+	t.Parallel()
+	files := map[string]string{
+		"mappings/required.yaml":    "api:\n  keys:\n    raisely: ${RAISELY_KEY}\n",
+		"mappings/defaults.yaml":    "campaignPrefix: default\n",
+		"mappings/ACME/SPRING.yaml": "campaignPrefix: spring\n",
+	}
+	em := memMappings(t, "mappings", files)
+	r, err := newCampaignRegistry(Raisely2Ortto, []string{
+		`FEZ_ACME_SPRING={"MAPPING_PATH":"ACME/SPRING","RAISELY_CAMPAIGN_UUID":"uuid-1","RAISELY_KEY":"secret"}`,
+	}, false)
+	if err != nil {
+		t.Fatalf("newCampaignRegistry returned unexpected error: %v", err)
+	}
+
+	cfg, err := r.Config(em, "uuid-1", ConfigWithCacheKey("a"))
+	if err != nil {
+		t.Fatalf("Config returned unexpected error: %v", err)
+	}
+	if cfg.CampaignPrefix != "spring" || cfg.API.Keys.Raisely != "secret" || cfg.EnvVar.Name != "FEZ_ACME_SPRING" {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+	// Callers get their own copy of a cached config.
+	cfg.EnvVar.Config["RAISELY_KEY"] = "changed"
+
+	// A cached config doesn't see mapping changes until Refresh.
+	em.Files.(fstest.MapFS)["mappings/ACME/SPRING.yaml"].Data = []byte("campaignPrefix: changed\n")
+	if cfg, _ := r.Config(em, "uuid-1", ConfigWithCacheKey("a")); cfg.CampaignPrefix != "spring" || cfg.EnvVar.Config["RAISELY_KEY"] != "secret" {
+		t.Errorf("expected the unmodified cached config, got prefix %q and key %q", cfg.CampaignPrefix, cfg.EnvVar.Config["RAISELY_KEY"])
+	}
+	// Loads without a cache key are never cached.
+	if cfg, _ := r.Config(em, "uuid-1"); cfg.CampaignPrefix != "changed" {
+		t.Errorf("expected a fresh load without a cache key, got prefix %q", cfg.CampaignPrefix)
+	}
+	// Loads with a CRMFieldMapper are cached under their own key.
+	if cfg, _ := r.Config(em, "uuid-1", ConfigWithCRMFieldMapper(OrttoCRMFieldMapper), ConfigWithCacheKey("ortto")); cfg.CampaignPrefix != "changed" {
+		t.Errorf("expected a fresh load for a new cache key, got prefix %q", cfg.CampaignPrefix)
+	}
+	em.Files.(fstest.MapFS)["mappings/ACME/SPRING.yaml"].Data = []byte("campaignPrefix: changed again\n")
+	if cfg, _ := r.Config(em, "uuid-1", ConfigWithCRMFieldMapper(OrttoCRMFieldMapper), ConfigWithCacheKey("ortto")); cfg.CampaignPrefix != "changed" {
+		t.Errorf("expected the cached config, got prefix %q", cfg.CampaignPrefix)
+	}
+	// Different mappings are cached under different keys.
+	other := memMappings(t, "mappings", map[string]string{
+		"mappings/required.yaml":    files["mappings/required.yaml"],
+		"mappings/defaults.yaml":    files["mappings/defaults.yaml"],
+		"mappings/ACME/SPRING.yaml": "campaignPrefix: other\n",
+	})
+	if cfg, _ := r.Config(other, "uuid-1", ConfigWithCacheKey("b")); cfg.CampaignPrefix != "other" {
+		t.Errorf("expected other mappings to load their own config, got prefix %q", cfg.CampaignPrefix)
+	}
+	if cfg, _ := r.Config(em, "uuid-1", ConfigWithCacheKey("a")); cfg.CampaignPrefix != "spring" {
+		t.Errorf("expected the first mappings' config to stay cached, got prefix %q", cfg.CampaignPrefix)
+	}
+
+	if _, err := r.Config(em, "uuid-missing", ConfigWithCacheKey("a")); err == nil || !strings.Contains(err.Error(), "no env var found") {
+		t.Errorf("err = %v, want a not found error", err)
+	}
+}
+
+func TestCampaignRegistry_ConfigWithSecretProvider(t *testing.T) {
+	t.Parallel()
+	em := memMappings(t, "mappings", map[string]string{
+		"mappings/required.yaml":    "api:\n  keys:\n    ortto: ${secret:ORTTO_KEY}\n",
+		"mappings/defaults.yaml":    "campaignPrefix: default\n",
+		"mappings/ACME/SPRING.yaml": "campaignPrefix: spring\n",
+	})
+	environ := []string{
+		`FEZ_ACME_SPRING={"MAPPING_PATH":"ACME/SPRING","RAISELY_CAMPAIGN_UUID":"uuid-1"}`,
+	}
+	r, err := newCampaignRegistry(Raisely2Ortto, environ, false)
+	if err != nil {
+		t.Fatalf("newCampaignRegistry returned unexpected error: %v", err)
+	}
+	config := func(key string, opts ...ConfigOption) string {
+		t.Helper()
+		provider := &mapSecretProvider{secrets: map[string]string{"ORTTO_KEY": key}}
+		cfg, err := r.Config(em, "uuid-1", append(opts, ConfigWithSecretProvider(provider))...)
+		if err != nil {
+			t.Fatalf("Config returned unexpected error: %v", err)
+		}
+		return cfg.API.Keys.Ortto
+	}
+
+	// Without a cache key, each load resolves secrets with its own provider.
+	for _, key := range []string{"key-a", "key-b"} {
+		if got := config(key); got != key {
+			t.Errorf("Ortto key = %q, want %q from this call's provider", got, key)
+		}
+	}
+	if _, err := r.Config(em, "uuid-1"); err == nil || !strings.Contains(err.Error(), "no SecretProvider") {
+		t.Errorf("err = %v, want a missing provider error rather than another call's config", err)
+	}
+
+	// With one, resolved secrets are cached until Refresh.
+	if got := config("key-a", ConfigWithCacheKey("ortto")); got != "key-a" {
+		t.Errorf("Ortto key = %q, want key-a", got)
+	}
+	if got := config("key-b", ConfigWithCacheKey("ortto")); got != "key-a" {
+		t.Errorf("Ortto key = %q, want the cached key-a", got)
+	}
+	if err := r.index(environ); err != nil {
+		t.Fatalf("index returned unexpected error: %v", err)
+	}
+	if got := config("key-b", ConfigWithCacheKey("ortto")); got != "key-b" {
+		t.Errorf("Ortto key = %q, want key-b after a refresh", got)
+	}
+}
@@ -0,0 +1,261 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	gosync "sync"
+	"time"
+)
+
+// SyncCampaignChunkSize is how many profiles [Service.SyncCampaign] maps
+// before sending the resulting Ortto requests and advancing the
+// checkpoint.
+const SyncCampaignChunkSize = 100
+
+// SyncCheckpointStore persists the high-water mark reached by
+// [Service.SyncCampaign], so each run only walks profiles updated since
+// the last one. Implementations typically live downstream in a shared
+// store (e.g. Redis, a database row); [MemorySyncCheckpointStore] is
+// provided for single instance deployments and tests.
+//
+// Keys are built by the Service from the campaign UUID and config target
+// (see [Service.SyncCheckpointKey]). Get returns ok=false when no
+// checkpoint has been recorded, in which case the whole campaign is
+// walked. A non-nil err from Get aborts the sync rather than silently
+// falling back to a full walk.
+type SyncCheckpointStore interface {
+	Get(ctx context.Context, key string) (checkpoint time.Time, ok bool, err error)
+	Set(ctx context.Context, key string, checkpoint time.Time) error
+}
+
+// MemorySyncCheckpointStore is an in-process [SyncCheckpointStore]. Safe
+// for concurrent use.
+type MemorySyncCheckpointStore struct {
+	mu          gosync.Mutex
+	checkpoints map[string]time.Time
+}
+
+// NewMemorySyncCheckpointStore returns an empty MemorySyncCheckpointStore.
+func NewMemorySyncCheckpointStore() *MemorySyncCheckpointStore {
+	return &MemorySyncCheckpointStore{checkpoints: make(map[string]time.Time)}
+}
+
+// Get implements [SyncCheckpointStore].
+func (s *MemorySyncCheckpointStore) Get(ctx context.Context, key string) (time.Time, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	checkpoint, ok := s.checkpoints[key]
+	return checkpoint, ok, nil
+}
+
+// Set implements [SyncCheckpointStore].
+func (s *MemorySyncCheckpointStore) Set(ctx context.Context, key string, checkpoint time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkpoints[key] = checkpoint
+	return nil
+}
+
+// SyncCampaignFailure records a profile that SyncCampaign could not map.
+type SyncCampaignFailure struct {
+	P2PID string
+	Err   error
+}
+
+// SyncCampaignResult summarises a [Service.SyncCampaign] run.
+type SyncCampaignResult struct {
+	From        time.Time // checkpoint the walk started from (zero for a full walk)
+	Checkpoint  time.Time // high-water mark reached; persisted when a store is configured
+	Profiles    int       // profiles yielded by Raisely
+	Individuals int       // individual profiles mapped
+	Teams       int       // teams mapped (each at most once per run)
+	Requests    int       // Ortto calls made
+	Failures    []SyncCampaignFailure
+}
+
+// SyncCheckpointKey returns the [SyncCheckpointStore] key for this
+// Service: the campaign UUID and config target, so each target of a
+// campaign keeps its own high-water mark.
+func (s *Service) SyncCheckpointKey() string {
+	return fmt.Sprintf("%s/%s", s.sc.Campaign, s.sc.Config.Target)
+}
+
+// SyncCampaign walks every profile in the campaign updated since the
+// stored checkpoint (or the whole campaign when there is none) and
+// re-syncs it to Ortto. It is the recovery path for missed webhooks and
+// is safe to run on a schedule.
+//
+// Profiles are processed in chunks of [SyncCampaignChunkSize]. Within a
+// run each team is mapped once via MapTeamFundraisingPage, however many
+// of its members were updated; individuals are mapped as for
+// MapFundraisingProfile but without referrals processing. The mapped
+// requests for a chunk are sent with SendRequests (merged into as few
+// Ortto calls as possible), then the checkpoint is advanced to the last
+// profile in the chunk.
+//
+// A profile that fails to fetch or map is recorded in Failures and
+// skipped so one bad profile cannot stall the sync; so is each team
+// member whose page could not be fetched, while the rest of the team is
+// sent. The checkpoint is then held just before the first failed
+// profile (for a team member, the profile that triggered its team), so
+// the next run retries it and re-syncs everything after it; a profile
+// that keeps failing holds the checkpoint until it is fixed. Any other error
+// (listing profiles, sending to Ortto, the checkpoint store) stops the
+// run; the checkpoint is left at the last fully sent chunk, so the next
+// run resumes from there.
+//
+// Only supported for the Raisely2Ortto flavour. FetchCampaign must be
+// called first.
+func (s *Service) SyncCampaign(ctx context.Context) (*SyncCampaignResult, error) {
+	if err := s.requireMapper(); err != nil {
+		return nil, err
+	}
+	if s.data != nil {
+		return nil, errors.New("SyncCampaign requires the Raisely2Ortto flavour")
+	}
+
+	key := s.SyncCheckpointKey()
+	var from time.Time
+	if s.checkpoints != nil {
+		checkpoint, ok, err := s.checkpoints.Get(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sync checkpoint %s: %w", key, err)
+		}
+		if ok {
+			from = checkpoint
+		}
+	}
+
+	result := &SyncCampaignResult{From: from, Checkpoint: from}
+	teamsMapped := make(map[string]bool)
+	chunk := make([]FundraisingProfile, 0, SyncCampaignChunkSize)
+
+	retrying := false // a profile has failed; the checkpoint stays before it
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		failed, err := s.syncCampaignChunk(chunk, teamsMapped, result, ctx)
+		if err != nil {
+			return err
+		}
+		if retrying {
+			chunk = chunk[:0]
+			return nil
+		}
+
+		// Step back 1ms (the updatedAtAfter precision) so profiles sharing
+		// the last timestamp but not yet seen are included next run.
+		// Re-syncing the boundary profiles is harmless. After a failure
+		// the checkpoint stops just before the failed profile, so the
+		// next run retries it.
+		last := chunk[len(chunk)-1]
+		if failed != nil {
+			last = *failed
+			retrying = true
+		}
+		chunk = chunk[:0]
+		updatedAt, err := time.Parse(time.RFC3339, last.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("profile %s has invalid updatedAt %q: %w", last.P2PID, last.UpdatedAt, err)
+		}
+		checkpoint := updatedAt.Add(-time.Millisecond)
+		if !checkpoint.After(result.Checkpoint) {
+			return nil
+		}
+		result.Checkpoint = checkpoint
+		if s.checkpoints == nil {
+			return nil
+		}
+		if err := s.checkpoints.Set(ctx, key, checkpoint); err != nil {
+			return fmt.Errorf("failed to write sync checkpoint %s: %w", key, err)
+		}
+		return nil
+	}
+
+	for profile, err := range s.fetcher.FetchProfilesSince(s.sc.Campaign, from, ctx) {
+		if err != nil {
+			return result, fmt.Errorf("failed to list profiles: %w", err)
+		}
+		result.Profiles++
+		chunk = append(chunk, profile)
+		if len(chunk) == SyncCampaignChunkSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// syncCampaignChunk maps and sends one chunk of SyncCampaign profiles,
+// returning the first profile in the chunk that failed (if any).
+// teamsMapped carries the teams already mapped earlier in the run.
+func (s *Service) syncCampaignChunk(profiles []FundraisingProfile, teamsMapped map[string]bool, result *SyncCampaignResult, ctx context.Context) (*FundraisingProfile, error) {
+	var reqs []OrttoRequest
+	var failed *FundraisingProfile
+	for i := range profiles {
+		profile := profiles[i]
+		fail := func(p2pID string, err error) {
+			log.Printf("Warning: SyncCampaign skipping profile %s: %v", p2pID, err)
+			result.Failures = append(result.Failures, SyncCampaignFailure{P2PID: p2pID, Err: err})
+			if failed == nil {
+				failed = &profiles[i]
+			}
+		}
+
+		if team := profile.TeamP2PID(s.campaign); team != "" {
+			if teamsMapped[team] {
+				continue
+			}
+			teamsMapped[team] = true
+			teamData, err := s.dataFetcher().FetchTeamData(team, ctx)
+			if err != nil {
+				fail(profile.P2PID, fmt.Errorf("failed to fetch team data for %s: %w", team, err))
+				continue
+			}
+			req, err := s.mapper.MapTeamFundraisingPage(s.campaign, teamData)
+			if err != nil {
+				fail(profile.P2PID, err)
+				continue
+			}
+			for _, member := range teamData.FailedMembers {
+				fail(member.P2PID, fmt.Errorf("failed to fetch team member of %s: %w", team, member.Err))
+			}
+			result.Teams++
+			reqs = append(reqs, req)
+			continue
+		}
+
+		// The campaign profile and any other non-fundraiser profiles
+		// have nothing to map.
+		if profile.Type != "INDIVIDUAL" {
+			continue
+		}
+		data, err := s.dataFetcher().FetchFundraiserData(profile.P2PID, ctx)
+		if err != nil {
+			fail(profile.P2PID, fmt.Errorf("failed to fetch fundraiser data for %s: %w", profile.P2PID, err))
+			continue
+		}
+		req, _, err := s.mapIndividual(profile.P2PID, data, false)
+		if err != nil {
+			fail(profile.P2PID, err)
+			continue
+		}
+		result.Individuals++
+		reqs = append(reqs, req)
+	}
+
+	_, calls, err := s.sendRequests(reqs, ctx)
+	result.Requests += calls
+	if err != nil {
+		return failed, fmt.Errorf("failed to send Ortto requests: %w", err)
+	}
+	return failed, nil
+}
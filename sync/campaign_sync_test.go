@@ -0,0 +1,250 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordingOrttoMapper is an OrttoMapper that maps each page to a contact
+// carrying only its uuid and records every request sent. Sent contacts
+// get a result whose PersonID is that uuid; sent activities get a result
+// with activityStatus ("ingested" if unset).
+type recordingOrttoMapper struct {
+	sent           []OrttoRequest
+	sendErr        error
+	activityStatus string
+}
+
+func (m *recordingOrttoMapper) contact(page FundraisingPage) OrttoContact {
+	uuid, _ := page.Source.StringForPath("uuid")
+	return OrttoContact{Fields: map[string]interface{}{"str::p2p-id": uuid}}
+}
+
+func (m *recordingOrttoMapper) MapFundraisingPage(campaign *FundraisingCampaign, data FundraiserData) (OrttoRequest, error) {
+	return OrttoContactsRequest{MergeBy: []string{"str::email"}, Contacts: []OrttoContact{m.contact(data.Page)}}, nil
+}
+
+func (m *recordingOrttoMapper) MapTeamFundraisingPage(campaign *FundraisingCampaign, data TeamData) (OrttoRequest, error) {
+	req := OrttoContactsRequest{MergeBy: []string{"str::email"}}
+	for _, page := range data.MemberPages {
+		if !data.MapsMember(page) {
+			continue
+		}
+		req.Contacts = append(req.Contacts, m.contact(page))
+	}
+	return req, nil
+}
+
+func (m *recordingOrttoMapper) MapTrackingData(campaign *FundraisingCampaign, data map[string]string, ctx context.Context) (OrttoRequest, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *recordingOrttoMapper) SendRequest(req OrttoRequest, ctx context.Context) (OrttoResponse, error) {
+	if m.sendErr != nil {
+		return nil, m.sendErr
+	}
+	m.sent = append(m.sent, req)
+	if activities, ok := req.AsOrttoActivitiesRequest(); ok {
+		status := m.activityStatus
+		if status == "" {
+			status = "ingested"
+		}
+		var resp OrttoActivitiesResponse
+		for _, a := range activities.Activities {
+			resp.Activities = append(resp.Activities, OrttoActivityIngestResult{ActivityID: a.ActivityID, Status: status})
+		}
+		return resp, nil
+	}
+	var resp OrttoContactsResponse
+	contacts, _ := req.AsOrttoContactsRequest()
+	for _, c := range contacts.Contacts {
+		id, _ := c.Fields["str::p2p-id"].(string)
+		resp.Results = append(resp.Results, OrttoContactsResult{PersonID: id, Status: "merged"})
+	}
+	return resp, nil
+}
+
+// newTestRaiselyCampaignServer serves the campaign profile list (filtered
+// by updatedAtAfter, as for newTestRaiselyProfilesServer), individual
+// profiles and team member lists.
+func newTestRaiselyCampaignServer(t *testing.T, profiles []FundraisingProfile) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		switch {
+		case strings.HasSuffix(path, "/profiles") && strings.HasPrefix(path, "/v3/campaigns/"):
+			after, _ := time.Parse(FundraisingProfilesSinceTimestampFormat, r.URL.Query().Get("updatedAtAfter"))
+			window := []FundraisingProfile{}
+			for _, p := range profiles {
+				updatedAt, _ := time.Parse(time.RFC3339, p.UpdatedAt)
+				if updatedAt.After(after) {
+					window = append(window, p)
+				}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": window})
+		case strings.HasSuffix(path, "/members"):
+			team := strings.TrimSuffix(strings.TrimPrefix(path, "/v3/profiles/"), "/members")
+			members := []TeamMember{}
+			for _, p := range profiles {
+				if p.Parent.P2PID == team {
+					members = append(members, TeamMember{P2PID: p.P2PID})
+				}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": members})
+		default:
+			uuid := strings.TrimPrefix(path, "/v3/profiles/")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]string{"uuid": uuid}})
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestSyncCampaignService(endpoint string, mapper OrttoMapper, store SyncCheckpointStore) *Service {
+	sc := &SyncContext{Campaign: "c1"}
+	sc.Config.API.Keys.Raisely = "k"
+	sc.Config.API.Endpoints.Raisely = endpoint
+	campaign := &FundraisingCampaign{Name: "Test"}
+	campaign.Profile.P2PID = "campaign-profile"
+	return &Service{
+		sc:          sc,
+		fetcher:     &RaiselyFetcherAndUpdater{SyncContext: sc},
+		campaign:    campaign,
+		mapper:      mapper,
+		checkpoints: store,
+	}
+}
+
+func testCampaignProfiles() []FundraisingProfile {
+	profile := func(uuid, typ, parent, parentType, updatedAt string) FundraisingProfile {
+		p := FundraisingProfile{P2PID: uuid, Type: typ, UpdatedAt: updatedAt}
+		p.Parent.P2PID = parent
+		p.Parent.Type = parentType
+		return p
+	}
+	return []FundraisingProfile{
+		profile("campaign-profile", "CAMPAIGN", "", "", "2026-03-01T00:00:00Z"),
+		profile("p-1", "INDIVIDUAL", "campaign-profile", "CAMPAIGN", "2026-03-01T01:00:00Z"),
+		profile("t-1", "GROUP", "campaign-profile", "CAMPAIGN", "2026-03-01T02:00:00Z"),
+		profile("m-1", "INDIVIDUAL", "t-1", "GROUP", "2026-03-01T03:00:00Z"),
+		profile("m-2", "INDIVIDUAL", "t-1", "GROUP", "2026-03-01T04:00:00Z"),
+		profile("p-2", "INDIVIDUAL", "campaign-profile", "CAMPAIGN", "2026-03-01T05:00:00Z"),
+	}
+}
+
+func TestSyncCampaign_MapsTeamsOnceAndAdvancesCheckpoint(t *testing.T) {
+	t.Parallel()
+	srv := newTestRaiselyCampaignServer(t, testCampaignProfiles())
+	mapper := &recordingOrttoMapper{}
+	store := NewMemorySyncCheckpointStore()
+	svc := newTestSyncCampaignService(srv.URL, mapper, store)
+
+	result, err := svc.SyncCampaign(t.Context())
+	if err != nil {
+		t.Fatalf("SyncCampaign returned unexpected error: %v", err)
+	}
+
+	if result.Profiles != 6 || result.Individuals != 2 || result.Teams != 1 || len(result.Failures) != 0 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if len(mapper.sent) != 1 || result.Requests != 1 {
+		t.Fatalf("expected 1 merged request, sent %d", len(mapper.sent))
+	}
+	var got []string
+	contacts, _ := mapper.sent[0].AsOrttoContactsRequest()
+	for _, c := range contacts.Contacts {
+		got = append(got, c.Fields["str::p2p-id"].(string))
+	}
+	if want := "p-1,m-1,m-2,p-2"; strings.Join(got, ",") != want {
+		t.Errorf("contacts = %v, want %s", got, want)
+	}
+
+	want := time.Date(2026, 3, 1, 5, 0, 0, 0, time.UTC).Add(-time.Millisecond)
+	checkpoint, ok, _ := store.Get(t.Context(), svc.SyncCheckpointKey())
+	if !ok || !checkpoint.Equal(want) || !result.Checkpoint.Equal(want) {
+		t.Errorf("checkpoint = %v (stored %v, ok=%v), want %v", result.Checkpoint, checkpoint, ok, want)
+	}
+}
+
+func TestSyncCampaign_ResumesFromCheckpoint(t *testing.T) {
+	t.Parallel()
+	srv := newTestRaiselyCampaignServer(t, testCampaignProfiles())
+	mapper := &recordingOrttoMapper{}
+	store := NewMemorySyncCheckpointStore()
+	svc := newTestSyncCampaignService(srv.URL, mapper, store)
+	_ = store.Set(t.Context(), svc.SyncCheckpointKey(), time.Date(2026, 3, 1, 3, 30, 0, 0, time.UTC))
+
+	result, err := svc.SyncCampaign(t.Context())
+	if err != nil {
+		t.Fatalf("SyncCampaign returned unexpected error: %v", err)
+	}
+	// m-2 pulls in the whole team; p-2 is mapped on its own.
+	if result.Profiles != 2 || result.Teams != 1 || result.Individuals != 1 {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestSyncCampaign_SendErrorKeepsCheckpoint(t *testing.T) {
+	t.Parallel()
+	srv := newTestRaiselyCampaignServer(t, testCampaignProfiles())
+	mapper := &recordingOrttoMapper{sendErr: errors.New("ortto unavailable")}
+	store := NewMemorySyncCheckpointStore()
+	svc := newTestSyncCampaignService(srv.URL, mapper, store)
+
+	if _, err := svc.SyncCampaign(t.Context()); err == nil {
+		t.Fatal("expected an error when the send fails")
+	}
+	if _, ok, _ := store.Get(t.Context(), svc.SyncCheckpointKey()); ok {
+		t.Error("checkpoint should not advance past an unsent chunk")
+	}
+}
+
+func TestSyncCampaign_FailureIsRetriedNextRun(t *testing.T) {
+	t.Parallel()
+	campaign := newTestRaiselyCampaignServer(t, testCampaignProfiles())
+	var failing atomic.Bool
+	failing.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() && r.URL.Path == "/v3/profiles/p-1" {
+			http.NotFound(w, r)
+			return
+		}
+		campaign.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	mapper := &recordingOrttoMapper{}
+	store := NewMemorySyncCheckpointStore()
+	svc := newTestSyncCampaignService(srv.URL, mapper, store)
+
+	result, err := svc.SyncCampaign(t.Context())
+	if err != nil {
+		t.Fatalf("SyncCampaign returned unexpected error: %v", err)
+	}
+	if len(result.Failures) != 1 || result.Failures[0].P2PID != "p-1" || result.Individuals != 1 || result.Teams != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	want := time.Date(2026, 3, 1, 1, 0, 0, 0, time.UTC).Add(-time.Millisecond)
+	if checkpoint, _, _ := store.Get(t.Context(), svc.SyncCheckpointKey()); !checkpoint.Equal(want) {
+		t.Errorf("checkpoint = %v, want just before the failed profile (%v)", checkpoint, want)
+	}
+
+	failing.Store(false)
+	result, err = svc.SyncCampaign(t.Context())
+	if err != nil {
+		t.Fatalf("SyncCampaign returned unexpected error: %v", err)
+	}
+	if len(result.Failures) != 0 || result.Individuals != 2 {
+		t.Errorf("expected p-1 to be retried, got %+v", result)
+	}
+	want = time.Date(2026, 3, 1, 5, 0, 0, 0, time.UTC).Add(-time.Millisecond)
+	if checkpoint, _, _ := store.Get(t.Context(), svc.SyncCheckpointKey()); !checkpoint.Equal(want) {
+		t.Errorf("checkpoint = %v, want %v", checkpoint, want)
+	}
+}
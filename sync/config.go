@@ -1,11 +1,15 @@
 package sync
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"go.uber.org/config"
 )
@@ -30,14 +34,19 @@ type Config struct {
 		Builtin FieldMappings
 		Custom  FieldMappings
 	}
-	FundraiserFieldTransforms map[string]string
+	FundraiserFieldTransforms map[string]TransformPipeline
 	TeamFieldMappings         struct {
 		Custom FieldMappings
 	}
-	TeamFieldTransforms             map[string]string
+	TeamFieldTransforms             map[string]TransformPipeline
 	FundraiserReferralFieldMappings RaiselyMessageMappings `yaml:"fundraiserReferralFieldMappings"`
 	FundraiserExtensions            FundraiserExtensionsConfig
 	TeamExtensions                  TeamExtensionsConfig
+
+	// Positions records where each YAML key was defined across the merged
+	// mapping files. Populated by YAMLConfigUnmarshaler and used by
+	// ValidateConfig to report issues by file, line and column.
+	Positions ConfigPositions
 }
 
 // RaiselyMessageMappings is the pass-through field map for a Raisely
@@ -51,6 +60,9 @@ type RaiselyMessageMappings struct {
 }
 
 type APISettings struct {
+	// Keys are redacted when APISettings is printed but not when it is
+	// marshalled (marshal Redacted() to log them); use ${secret:NAME} in
+	// the mapping files to read them from a SecretProvider.
 	Keys struct {
 		Raisely   string
 		Funraisin string
@@ -69,6 +81,7 @@ type APISettings struct {
 		Ortto           string
 		Raisely         string
 		RaiselyMessages string `yaml:"raiselyMessages"`
+		Funraisin       string
 	}
 }
 
@@ -88,6 +101,27 @@ type FundraiserExtensionsConfig struct {
 	}
 	SplitExerciseTotals SplitExerciseTotals `yaml:"splitExerciseTotals"`
 	TotalInWindow       TotalInWindow       `yaml:"totalInWindow"`
+	Leaderboard         Leaderboard         `yaml:"leaderboard"`
+	Milestones          Milestones          `yaml:"milestones"`
+}
+
+// Milestones award each threshold once it is reached (see
+// AddMissingMilestones): Total and ExerciseTotal compare against the
+// page's total and exerciseTotal (in the page's units, e.g. cents), and
+// Donors against the number of distinct donors.
+type Milestones struct {
+	Total         Milestone
+	ExerciseTotal Milestone `yaml:"exerciseTotal"`
+	Donors        Milestone
+}
+
+type Milestone struct {
+	Thresholds []int64
+	Mapping    string
+}
+
+func (m Milestone) IsConfigured() bool {
+	return len(m.Thresholds) > 0 && m.Mapping != ""
 }
 
 type TotalInWindow struct {
@@ -101,6 +135,30 @@ func (t TotalInWindow) IsConfigured() bool {
 
 type TeamExtensionsConfig struct {
 	SplitExerciseTotals SplitExerciseTotals `yaml:"splitExerciseTotals"`
+	Leaderboard         Leaderboard         `yaml:"leaderboard"`
+}
+
+// Leaderboard ranks every live individual (fundraiserExtensions) or team
+// (teamExtensions) in the campaign by By, "total" (the default) or
+// "exerciseTotal". The rank (1 = highest; ties share a rank) is written
+// to RankMapping and the top percentage the profile falls in (e.g. 10
+// for the top 10%) to PercentileMapping; either may be left empty.
+type Leaderboard struct {
+	By                string
+	RankMapping       string `yaml:"rankMapping"`
+	PercentileMapping string `yaml:"percentileMapping"`
+}
+
+func (l Leaderboard) IsConfigured() bool {
+	return l.RankMapping != "" || l.PercentileMapping != ""
+}
+
+// ByPath returns the profile path ranked on.
+func (l Leaderboard) ByPath() string {
+	if l.By == "" {
+		return "total"
+	}
+	return l.By
 }
 
 type SplitExerciseTotals struct {
@@ -305,6 +363,7 @@ func (c JSONCompositeEnvVar) LookupEnv(child string) (string, bool) {
 
 type YAMLConfigUnmarshaler struct {
 	CRMFieldMapper CRMFieldMapper
+	SecretProvider SecretProvider // resolves ${secret:NAME} references
 }
 
 type CRMFieldMapper interface {
@@ -312,19 +371,31 @@ type CRMFieldMapper interface {
 }
 
 func (u YAMLConfigUnmarshaler) Unmarshal(compev CompositeEnvVar, sources ...MappingFile) (Config, error) {
-	var result Config
+	result := Config{Positions: make(ConfigPositions)}
+	secrets := secretRefs{provider: u.SecretProvider}
 	var options []config.YAMLOption
 	for _, s := range sources {
 		if s.Length > 0 {
-			options = append(options, config.Source(s.Reader))
+			data, err := io.ReadAll(s.Reader)
+			if err != nil {
+				return result, fmt.Errorf("failed to read yaml config %s %w", s.Name, err)
+			}
+			result.Positions.record(s.Name, data)
+			options = append(options, config.Source(bytes.NewReader(secrets.rewrite(data))))
 		}
 	}
-	options = append(options, config.Expand(compev.LookupEnv))
+	options = append(options, config.Expand(secrets.lookup(compev.LookupEnv)))
 	yaml, err := config.NewYAML(options...)
+	if secrets.err != nil {
+		return result, secrets.err
+	}
 	if err != nil {
 		return result, fmt.Errorf("failed to read yaml config %w", err)
 	}
 	readError := func(key string, cause error) error {
+		if pos := result.Positions.lookup(key).String(); pos != "" {
+			return fmt.Errorf("failed to read '%s' from yaml config (%s) %w", key, pos, cause)
+		}
 		return fmt.Errorf("failed to read '%s' from yaml config %w", key, cause)
 	}
 	key := "api"
@@ -345,6 +416,9 @@ func (u YAMLConfigUnmarshaler) Unmarshal(compev CompositeEnvVar, sources ...Mapp
 		if err != nil {
 			return result, readError(key, err)
 		}
+		if err = validateTransforms(key, result.FundraiserFieldTransforms); err != nil {
+			return result, err
+		}
 	}
 	key = "teamFieldMappings"
 	err = yaml.Get(key).Populate(&result.TeamFieldMappings)
@@ -357,6 +431,9 @@ func (u YAMLConfigUnmarshaler) Unmarshal(compev CompositeEnvVar, sources ...Mapp
 		if err != nil {
 			return result, readError(key, err)
 		}
+		if err = validateTransforms(key, result.TeamFieldTransforms); err != nil {
+			return result, err
+		}
 	}
 	key = "fundraiserReferralFieldMappings"
 	if yaml.Get(key).HasValue() {
@@ -376,6 +453,19 @@ func (u YAMLConfigUnmarshaler) Unmarshal(compev CompositeEnvVar, sources ...Mapp
 		return result, readError(key, err)
 	}
 
+	for _, m := range []struct {
+		key      string
+		mappings FieldMappings
+	}{
+		{"fundraiserFieldMappings.builtin", result.FundraiserFieldMappings.Builtin},
+		{"fundraiserFieldMappings.custom", result.FundraiserFieldMappings.Custom},
+		{"teamFieldMappings.custom", result.TeamFieldMappings.Custom},
+	} {
+		if err = ValidateFieldMappingExpressions(m.mappings); err != nil {
+			return result, fmt.Errorf("invalid '%s' in yaml config: %w", m.key, err)
+		}
+	}
+
 	// Only expand field mappings if CRMFieldMapper is provided.
 	// This allows loading config for Raisely-only use cases (like extensions)
 	// without requiring Ortto/CRM dependencies.
@@ -395,6 +485,69 @@ func (u YAMLConfigUnmarshaler) Unmarshal(compev CompositeEnvVar, sources ...Mapp
 	return result, nil
 }
 
+// clone returns a deep copy of c, so a cached Config can be handed out
+// without callers sharing its maps and slices.
+func (c Config) clone() Config {
+	c.EnvVar.Config = maps.Clone(c.EnvVar.Config)
+	c.API.Settings.OrttoActivityAdditionalPersonFields = slices.Clone(c.API.Settings.OrttoActivityAdditionalPersonFields)
+	c.API.Settings.RaiselyWebhookEvents = slices.Clone(c.API.Settings.RaiselyWebhookEvents)
+	c.FundraiserFieldMappings.Builtin = c.FundraiserFieldMappings.Builtin.clone()
+	c.FundraiserFieldMappings.Custom = c.FundraiserFieldMappings.Custom.clone()
+	c.FundraiserFieldTransforms = cloneTransforms(c.FundraiserFieldTransforms)
+	c.TeamFieldMappings.Custom = c.TeamFieldMappings.Custom.clone()
+	c.TeamFieldTransforms = cloneTransforms(c.TeamFieldTransforms)
+	c.FundraiserReferralFieldMappings.User = maps.Clone(c.FundraiserReferralFieldMappings.User)
+	c.FundraiserReferralFieldMappings.Custom = maps.Clone(c.FundraiserReferralFieldMappings.Custom)
+
+	streaks := &c.FundraiserExtensions.Streaks
+	streaks.Donation.Days = slices.Clone(streaks.Donation.Days)
+	streaks.Activity.Filter = slices.Clone(streaks.Activity.Filter)
+	streaks.Activity.Days = slices.Clone(streaks.Activity.Days)
+	c.FundraiserExtensions.SplitExerciseTotals.Mappings = slices.Clone(c.FundraiserExtensions.SplitExerciseTotals.Mappings)
+	milestones := &c.FundraiserExtensions.Milestones
+	milestones.Total.Thresholds = slices.Clone(milestones.Total.Thresholds)
+	milestones.ExerciseTotal.Thresholds = slices.Clone(milestones.ExerciseTotal.Thresholds)
+	milestones.Donors.Thresholds = slices.Clone(milestones.Donors.Thresholds)
+	c.TeamExtensions.SplitExerciseTotals.Mappings = slices.Clone(c.TeamExtensions.SplitExerciseTotals.Mappings)
+
+	c.Positions = maps.Clone(c.Positions)
+	return c
+}
+
+func (m FieldMappings) clone() FieldMappings {
+	cloneNested := func(nested map[string]map[string]string) map[string]map[string]string {
+		if nested == nil {
+			return nil
+		}
+		result := make(map[string]map[string]string, len(nested))
+		for k, v := range nested {
+			result[k] = maps.Clone(v)
+		}
+		return result
+	}
+	return FieldMappings{
+		Strings:    maps.Clone(m.Strings),
+		Texts:      maps.Clone(m.Texts),
+		Decimals:   maps.Clone(m.Decimals),
+		Booleans:   maps.Clone(m.Booleans),
+		Timestamps: maps.Clone(m.Timestamps),
+		Phones:     cloneNested(m.Phones),
+		Geos:       cloneNested(m.Geos),
+		Integers:   maps.Clone(m.Integers),
+	}
+}
+
+func cloneTransforms(transforms map[string]TransformPipeline) map[string]TransformPipeline {
+	if transforms == nil {
+		return nil
+	}
+	result := make(map[string]TransformPipeline, len(transforms))
+	for k, v := range transforms {
+		result[k] = slices.Clone(v)
+	}
+	return result
+}
+
 // ActivityName returns the activity name extracted from the OrttoActivityID setting.
 // e.g., "act:cm:myorg-mycampaign-sync" -> "myorg-mycampaign-sync"
 // NOTE: act:cm: is the prefix for custom activities in Ortto,
@@ -414,5 +567,62 @@ func (c Config) MapDonations() bool {
 	if len(c.FundraiserExtensions.Streaks.Donation.Days) > 0 {
 		return true
 	}
+	if c.FundraiserExtensions.Milestones.Donors.IsConfigured() {
+		return true
+	}
 	return false
 }
+
+// fetchNeeds is the optional data fetched alongside a profile for the
+// configs that will map it.
+type fetchNeeds struct {
+	activityLogs       bool
+	activityLogsFrom   time.Time // zero to fetch every entry; also bounds teamExerciseTotals
+	donations          bool
+	teamExerciseTotals bool
+	teamAggregates     bool
+}
+
+func (c Config) fetchNeeds() fetchNeeds {
+	needs := fetchNeeds{
+		activityLogs:       c.MapActivityLogs(),
+		donations:          c.MapDonations(),
+		teamExerciseTotals: c.MapTeamExerciseTotals(),
+		teamAggregates:     c.MapTeamAggregates(),
+	}
+	if needs.fetchesActivityLogs() {
+		// Entries before the activity window are ignored by
+		// IncludeForStreak and left out of @team.exerciseTotals, so
+		// there is no need to page past them.
+		needs.activityLogsFrom, _ = time.Parse(time.RFC3339, c.FundraiserExtensions.Streaks.Activity.From)
+	}
+	return needs
+}
+
+// fetchesActivityLogs reports whether exercise logs are fetched, for the
+// profile or its team members.
+func (n fetchNeeds) fetchesActivityLogs() bool {
+	return n.activityLogs || n.teamExerciseTotals
+}
+
+// union returns the needs of both n and other, fetching activity logs
+// back to the earlier of their windows.
+func (n fetchNeeds) union(other fetchNeeds) fetchNeeds {
+	from := n.activityLogsFrom
+	switch {
+	case !other.fetchesActivityLogs():
+	case !n.fetchesActivityLogs():
+		from = other.activityLogsFrom
+	case from.IsZero() || other.activityLogsFrom.IsZero():
+		from = time.Time{}
+	case other.activityLogsFrom.Before(from):
+		from = other.activityLogsFrom
+	}
+	return fetchNeeds{
+		activityLogs:       n.activityLogs || other.activityLogs,
+		activityLogsFrom:   from,
+		donations:          n.donations || other.donations,
+		teamExerciseTotals: n.teamExerciseTotals || other.teamExerciseTotals,
+		teamAggregates:     n.teamAggregates || other.teamAggregates,
+	}
+}
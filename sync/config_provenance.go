@@ -0,0 +1,166 @@
+package sync
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigProvenance is the effective value of one config key and the
+// mapping file that set it.
+type ConfigProvenance struct {
+	Target   string         // the config target the key belongs to, as Config.Target
+	Key      string         // dotted YAML key path, e.g. "api.settings.orttoActivityId"
+	Value    interface{}    // scalar, list, or nil for an explicit null, as written in the file
+	Position ConfigPosition // Position.File is the MappingFile.Name that set the value
+
+	// Overrides are the values from earlier files this one replaced,
+	// grouped by key with each key's values earliest first.
+	Overrides []ConfigOverride
+}
+
+// ConfigOverride is a value from an earlier mapping file that was
+// replaced by a later one.
+type ConfigOverride struct {
+	Key      string // differs from the overriding key when a later file replaced a whole section
+	Value    interface{}
+	Position ConfigPosition
+}
+
+// ExplainConfig loads the mapping files for mappingPath the same way as
+// the LoadCampaignConfigs* functions (for each target: required,
+// defaults, campaign, then the optional referrals companion) and returns
+// every effective key with the file that set it and the values it
+// overrode. Keys are grouped by target, in the order LoadCampaignConfigs
+// returns the configs, and sorted within each. Merging follows
+// go.uber.org/config: sections merge key by key, while scalars, lists
+// and explicit nulls replace earlier values outright.
+//
+// Values are reported as written, before ${VAR} expansion, so the result
+// doesn't depend on the environment.
+func (em EmbeddedMappings) ExplainConfig(mappingPath string) ([]ConfigProvenance, error) {
+	targets, err := em.findCampaignMappingFilesByTarget(mappingPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []ConfigProvenance
+	for _, t := range targets {
+		entries, err := explainMappingFiles(t.sources)
+		if err != nil {
+			return nil, err
+		}
+		for i := range entries {
+			entries[i].Target = t.target
+		}
+		result = append(result, entries...)
+	}
+	return result, nil
+}
+
+// explainMappingFiles merges one target's mapping files in order and
+// returns the effective keys, sorted.
+func explainMappingFiles(sources []MappingFile) ([]ConfigProvenance, error) {
+	e := configExplainer{entries: make(map[string]*ConfigProvenance)}
+	for _, s := range sources {
+		if s.Length == 0 {
+			continue
+		}
+		data, err := io.ReadAll(s.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read mapping file %s %w", s.Name, err)
+		}
+		var root yaml.Node
+		if err := yaml.Unmarshal(data, &root); err != nil {
+			return nil, fmt.Errorf("failed to parse mapping file %s %w", s.Name, err)
+		}
+		if len(root.Content) == 0 {
+			continue // empty or comment-only, so it contributes nothing
+		}
+		if err := e.walk(s.Name, "", ConfigPosition{}, root.Content[0], nil); err != nil {
+			return nil, fmt.Errorf("failed to explain mapping file %s %w", s.Name, err)
+		}
+	}
+
+	result := make([]ConfigProvenance, 0, len(e.entries))
+	for _, entry := range e.entries {
+		result = append(result, *entry)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result, nil
+}
+
+// configExplainer tracks the effective leaf values while mapping files
+// are merged in order.
+type configExplainer struct {
+	entries map[string]*ConfigProvenance
+}
+
+// walk merges node, defined at path by the key at pos, into the entries.
+// replaced holds earlier values the enclosing section displaced, which
+// every leaf beneath it records as overridden.
+func (e *configExplainer) walk(file, path string, pos ConfigPosition, n *yaml.Node, replaced []ConfigOverride) error {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	if n.Kind == yaml.MappingNode && (path == "" || len(n.Content) > 0 || e.hasDescendants(path)) {
+		// A section merges into an earlier one, but replaces an earlier scalar.
+		if path != "" {
+			replaced = append(replaced, e.take(path, false)...)
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			keyPos := ConfigPosition{File: file, Line: k.Line, Column: k.Column}
+			if err := e.walk(file, joinConfigPath(path, k.Value), keyPos, v, replaced); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if path == "" {
+		return fmt.Errorf("top level must be a mapping")
+	}
+
+	var value interface{}
+	if err := n.Decode(&value); err != nil {
+		return fmt.Errorf("failed to decode '%s' %w", path, err)
+	}
+	overrides := append([]ConfigOverride(nil), replaced...)
+	overrides = append(overrides, e.take(path, true)...)
+	e.entries[path] = &ConfigProvenance{Key: path, Value: value, Position: pos, Overrides: overrides}
+	return nil
+}
+
+// take removes the entry at path (and, if descendants is set, every
+// entry beneath it) and returns them as overrides, each key's values
+// earliest first.
+func (e *configExplainer) take(path string, descendants bool) []ConfigOverride {
+	var keys []string
+	for key := range e.entries {
+		if key == path || (descendants && strings.HasPrefix(key, path+".")) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var result []ConfigOverride
+	for _, key := range keys {
+		entry := e.entries[key]
+		result = append(result, entry.Overrides...)
+		result = append(result, ConfigOverride{Key: entry.Key, Value: entry.Value, Position: entry.Position})
+		delete(e.entries, key)
+	}
+	return result
+}
+
+func (e *configExplainer) hasDescendants(path string) bool {
+	for key := range e.entries {
+		if strings.HasPrefix(key, path+".") {
+			return true
+		}
+	}
+	return false
+}
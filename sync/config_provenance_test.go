@@ -0,0 +1,151 @@
+package sync
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestExplainConfig(t *testing.T) {
+	t.Parallel()
+	em := memMappings(t, "mappings", map[string]string{
+		"mappings/required.yaml": `
+api:
+  keys:
+    raisely: ${RAISELY_API_KEY}
+fundraiserFieldMappings:
+  builtin:
+    strings:
+      email: "user.email"
+`,
+		"mappings/defaults.yaml": `
+campaignPrefix: "default"
+api:
+  settings:
+    raiselyWebhookEvents: ["profile.created", "profile.updated"]
+fundraiserExtensions:
+  totalInWindow:
+    window: "168h"
+    mapping: "int:cm:weekly"
+`,
+		"mappings/ORG/LABEL.yaml": `
+campaignPrefix: "acme"
+api:
+  settings:
+    raiselyWebhookEvents: ["donation.succeeded"]
+fundraiserExtensions:
+  totalInWindow: ~
+`,
+		"mappings/ORG/LABEL.referrals.yaml": `
+fundraiserReferralFieldMappings:
+  user:
+    email: "email"
+`,
+	})
+
+	got, err := em.ExplainConfig("ORG/LABEL")
+	if err != nil {
+		t.Fatalf("ExplainConfig returned unexpected error: %v", err)
+	}
+
+	want := []ConfigProvenance{
+		{
+			Key:      "api.keys.raisely",
+			Value:    "${RAISELY_API_KEY}",
+			Position: ConfigPosition{"mappings/required.yaml", 4, 5},
+		},
+		{
+			Key:      "api.settings.raiselyWebhookEvents",
+			Value:    []interface{}{"donation.succeeded"},
+			Position: ConfigPosition{"mappings/ORG/LABEL.yaml", 5, 5},
+			Overrides: []ConfigOverride{{
+				Key:      "api.settings.raiselyWebhookEvents",
+				Value:    []interface{}{"profile.created", "profile.updated"},
+				Position: ConfigPosition{"mappings/defaults.yaml", 5, 5},
+			}},
+		},
+		{
+			Key:      "campaignPrefix",
+			Value:    "acme",
+			Position: ConfigPosition{"mappings/ORG/LABEL.yaml", 2, 1},
+			Overrides: []ConfigOverride{{
+				Key:      "campaignPrefix",
+				Value:    "default",
+				Position: ConfigPosition{"mappings/defaults.yaml", 2, 1},
+			}},
+		},
+		{
+			Key:      "fundraiserExtensions.totalInWindow",
+			Value:    nil,
+			Position: ConfigPosition{"mappings/ORG/LABEL.yaml", 7, 3},
+			Overrides: []ConfigOverride{
+				{Key: "fundraiserExtensions.totalInWindow.mapping", Value: "int:cm:weekly", Position: ConfigPosition{"mappings/defaults.yaml", 9, 5}},
+				{Key: "fundraiserExtensions.totalInWindow.window", Value: "168h", Position: ConfigPosition{"mappings/defaults.yaml", 8, 5}},
+			},
+		},
+		{
+			Key:      "fundraiserFieldMappings.builtin.strings.email",
+			Value:    "user.email",
+			Position: ConfigPosition{"mappings/required.yaml", 8, 7},
+		},
+		{
+			Key:      "fundraiserReferralFieldMappings.user.email",
+			Value:    "email",
+			Position: ConfigPosition{"mappings/ORG/LABEL.referrals.yaml", 4, 5},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExplainConfig mismatch\ngot:  %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestExplainConfig_SectionReplacesScalar(t *testing.T) {
+	t.Parallel()
+	em := memMappings(t, "mappings", map[string]string{
+		"mappings/required.yaml":  "teamExtensions: ~\n",
+		"mappings/defaults.yaml":  "",
+		"mappings/ORG/LABEL.yaml": "teamExtensions:\n  splitExerciseTotals:\n    from: exercise\n",
+	})
+
+	got, err := em.ExplainConfig("ORG/LABEL")
+	if err != nil {
+		t.Fatalf("ExplainConfig returned unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0].Key != "teamExtensions.splitExerciseTotals.from" {
+		t.Fatalf("unexpected keys %+v", got)
+	}
+	if o := got[0].Overrides; len(o) != 1 || o[0].Key != "teamExtensions" || o[0].Position.File != "mappings/required.yaml" {
+		t.Errorf("expected the null section from required.yaml to be recorded as overridden, got %+v", o)
+	}
+}
+
+func TestExplainConfig_EveryTarget(t *testing.T) {
+	t.Parallel()
+	em := memMappings(t, "mappings", map[string]string{
+		"mappings/required.yaml":                   "",
+		"mappings/defaults.yaml":                   "campaignPrefix: contacts-default\n",
+		"mappings/required.ortto-activities.yaml":  "",
+		"mappings/defaults.ortto-activities.yaml":  "campaignPrefix: activities-default\n",
+		"mappings/ORG/LABEL.ortto-activities.yaml": "api:\n  settings:\n    orttoActivityId: act:cm:donation\n",
+		"mappings/ORG/LABEL.ortto-contacts.yaml":   "",
+	})
+
+	got, err := em.ExplainConfig("ORG/LABEL")
+	if err != nil {
+		t.Fatalf("ExplainConfig returned unexpected error: %v", err)
+	}
+
+	var keys []string
+	for _, p := range got {
+		keys = append(keys, fmt.Sprintf("%s %s=%v", p.Target, p.Key, p.Value))
+	}
+	want := []string{
+		"ortto-activities api.settings.orttoActivityId=act:cm:donation",
+		"ortto-activities campaignPrefix=activities-default",
+		"ortto-contacts campaignPrefix=contacts-default",
+	}
+	if !slices.Equal(keys, want) {
+		t.Errorf("ExplainConfig keys = %q, want %q", keys, want)
+	}
+}
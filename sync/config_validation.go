@@ -0,0 +1,464 @@
+package sync
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigPosition is where a key was defined in the YAML mapping files.
+type ConfigPosition struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// String returns the position as "file:line:column", or "" if unknown.
+func (p ConfigPosition) String() string {
+	if p.File == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// ConfigPositions maps dotted YAML key paths (e.g.
+// "fundraiserFieldMappings.custom.strings.team-name") to the position
+// of the key. Sequence items are keyed by index ("...days.0"). When the
+// same key appears in several merged files, the last file wins, matching
+// how the values themselves are merged.
+type ConfigPositions map[string]ConfigPosition
+
+// record adds the positions of every key in a YAML document. Positions
+// are best effort: a document yaml.v3 can't parse is skipped, as the
+// config loader reports its own error for it.
+func (p ConfigPositions) record(file string, data []byte) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil || len(root.Content) == 0 {
+		return
+	}
+	p.walk(file, "", root.Content[0])
+}
+
+func (p ConfigPositions) walk(file, prefix string, n *yaml.Node) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			path := joinConfigPath(prefix, k.Value)
+			p[path] = ConfigPosition{File: file, Line: k.Line, Column: k.Column}
+			if v.Kind == yaml.SequenceNode {
+				// Lists replace rather than merge, so drop items from earlier files.
+				for existing := range p {
+					if strings.HasPrefix(existing, path+".") {
+						delete(p, existing)
+					}
+				}
+			}
+			p.walk(file, path, v)
+		}
+	case yaml.SequenceNode:
+		for i, item := range n.Content {
+			path := joinConfigPath(prefix, strconv.Itoa(i))
+			p[path] = ConfigPosition{File: file, Line: item.Line, Column: item.Column}
+			p.walk(file, path, item)
+		}
+	}
+}
+
+// lookup returns the position of path, falling back to its nearest
+// recorded parent so an issue on a missing key points at its section.
+func (p ConfigPositions) lookup(path string) ConfigPosition {
+	for path != "" {
+		if pos, ok := p[path]; ok {
+			return pos
+		}
+		i := strings.LastIndex(path, ".")
+		if i < 0 {
+			break
+		}
+		path = path[:i]
+	}
+	return ConfigPosition{}
+}
+
+func joinConfigPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// ConfigIssue is a single problem found by [ValidateConfig].
+type ConfigIssue struct {
+	Position ConfigPosition // zero if the config wasn't loaded from YAML
+	Key      string         // dotted YAML key path
+	Message  string
+}
+
+func (i ConfigIssue) String() string {
+	if pos := i.Position.String(); pos != "" {
+		return fmt.Sprintf("%s: %s: %s", pos, i.Key, i.Message)
+	}
+	return fmt.Sprintf("%s: %s", i.Key, i.Message)
+}
+
+// ConfigValidationError is returned by [ValidateConfig] and lists every
+// issue found.
+type ConfigValidationError struct {
+	Issues []ConfigIssue
+}
+
+func (e *ConfigValidationError) Error() string {
+	lines := make([]string, 0, len(e.Issues)+1)
+	lines = append(lines, fmt.Sprintf("invalid config: %d issue(s)", len(e.Issues)))
+	for _, issue := range e.Issues {
+		lines = append(lines, "  "+issue.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ValidateConfig checks a loaded config for mistakes that would
+// otherwise only surface at sync time, returning a *ConfigValidationError
+// listing each with the file, line and column it came from (when the
+// config was loaded by [YAMLConfigUnmarshaler]). It checks:
+//
+//   - field IDs and names have the Ortto shape and type prefix expected
+//     by their section
+//   - transforms are registered and configured on mapped fields
+//   - gjson modifiers used in mapping paths are registered, and mapping
+//     expressions parse and type-check
+//   - extension settings (streak days and dates, window durations,
+//     splitExerciseTotals mappings)
+//   - settings required by the ortto-activities target
+//   - referral settings, mappings and endpoints are all present together
+//
+// Modifiers are registered by [Init], so call it first or every modifier
+// is reported as unknown. The config may be validated before or after
+// its field mappings are expanded.
+func ValidateConfig(c Config) error {
+	v := configValidator{positions: c.Positions}
+
+	fundraiserFields := make(map[string]bool)
+	teamFields := make(map[string]bool)
+	v.fieldMappings("fundraiserFieldMappings.builtin", c.FundraiserFieldMappings.Builtin, false, fundraiserFields)
+	v.fieldMappings("fundraiserFieldMappings.custom", c.FundraiserFieldMappings.Custom, true, fundraiserFields)
+	v.fieldMappings("teamFieldMappings.custom", c.TeamFieldMappings.Custom, true, teamFields)
+	for field := range fundraiserFields {
+		teamFields[field] = true
+	}
+	v.transforms("fundraiserFieldTransforms", c.FundraiserFieldTransforms, fundraiserFields)
+	v.transforms("teamFieldTransforms", c.TeamFieldTransforms, teamFields)
+
+	v.extensions(c)
+	v.target(c)
+	v.referrals(c)
+
+	if len(v.issues) > 0 {
+		return &ConfigValidationError{Issues: v.issues}
+	}
+	return nil
+}
+
+type configValidator struct {
+	positions ConfigPositions
+	issues    []ConfigIssue
+}
+
+func (v *configValidator) add(key string, format string, args ...interface{}) {
+	v.issues = append(v.issues, ConfigIssue{
+		Position: v.positions.lookup(key),
+		Key:      key,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+var (
+	orttoFieldName     = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	orttoFieldID       = regexp.MustCompile(`^(str|txt|int|bol|tme|phn|geo):(cm)?:([a-z0-9][a-z0-9_-]*)$`)
+	orttoActivityID    = regexp.MustCompile(`^act:cm:[a-z0-9][a-z0-9_-]*$`)
+	gjsonModifierUsage = regexp.MustCompile(`(?:^|[|.])@([A-Za-z0-9_]*)`)
+)
+
+// fieldIDName returns the field name from a field ID or plain name, used
+// to find a field's key in the YAML before its mappings were expanded.
+func fieldIDName(field string) string {
+	return field[strings.LastIndex(field, ":")+1:]
+}
+
+// fieldMappings checks one mappings section and adds the field IDs it
+// will produce to mapped.
+func (v *configValidator) fieldMappings(section string, m FieldMappings, custom bool, mapped map[string]bool) {
+	simple := []struct {
+		key       string
+		prefix    string
+		fieldType SimpleFieldType
+		values    map[string]string
+	}{
+		{"strings", "str", String, m.Strings},
+		{"texts", "txt", Text, m.Texts},
+		{"decimals", "int", Decimal, m.Decimals},
+		{"booleans", "bol", Boolean, m.Booleans},
+		{"timestamps", "tme", Timestamp, m.Timestamps},
+		{"integers", "int", Integer, m.Integers},
+	}
+	for _, s := range simple {
+		for _, field := range sortedKeys(s.values) {
+			key := joinConfigPath(section+"."+s.key, fieldIDName(field))
+			mapped[v.fieldID(key, field, s.prefix, custom)] = true
+			v.mappingValue(key, field, s.values[field], s.fieldType)
+		}
+	}
+	nested := []struct {
+		key    string
+		prefix string
+		values map[string]map[string]string
+	}{
+		{"phones", "phn", m.Phones},
+		{"geos", "geo", m.Geos},
+	}
+	for _, n := range nested {
+		for _, field := range sortedKeysPhones(n.values) {
+			key := joinConfigPath(section+"."+n.key, fieldIDName(field))
+			mapped[v.fieldID(key, field, n.prefix, custom)] = true
+			for _, sub := range sortedKeys(n.values[field]) {
+				v.mappingValue(key+"."+sub, field+"."+sub, n.values[field][sub], String)
+			}
+		}
+	}
+}
+
+// fieldID checks a mapping key, which is either a plain name or (once
+// expanded) a full field ID, and returns the full field ID.
+func (v *configValidator) fieldID(key, field, prefix string, custom bool) string {
+	cm := ""
+	if custom {
+		cm = "cm"
+	}
+	want := prefix + ":" + cm + ":" + fieldIDName(field)
+	if !strings.Contains(field, ":") {
+		if !orttoFieldName.MatchString(field) {
+			v.add(key, "invalid field name %q: use lowercase letters, digits, '-' and '_'", field)
+		}
+		return want
+	}
+	if !orttoFieldID.MatchString(field) {
+		v.add(key, "invalid field ID %q", field)
+	} else if field != want {
+		v.add(key, "field ID %q does not match its section, expected %q", field, want)
+	}
+	return field
+}
+
+// mappingValue checks a mapping expression or gjson path.
+func (v *configValidator) mappingValue(key, field, value string, fieldType SimpleFieldType) {
+	if isMappingExpr(value) {
+		if err := validateMappingExpr(field, value, fieldType); err != nil {
+			v.add(key, "%v", err)
+		}
+		return
+	}
+	if len(value) >= 2 && value[0] == '`' && value[len(value)-1] == '`' {
+		return // static value
+	}
+	v.modifiers(key, value)
+}
+
+// modifiers reports unregistered or malformed gjson modifiers in path.
+// A leading @team. is a team aggregate, not a modifier.
+func (v *configValidator) modifiers(key, path string) {
+	for _, match := range gjsonModifierUsage.FindAllStringSubmatch(strings.TrimPrefix(path, teamAggregatesPrefix), -1) {
+		name := match[1]
+		switch {
+		case name == "":
+			v.add(key, "malformed modifier in %q: '@' must be followed by a modifier name", path)
+		case !gjson.ModifierExists(name, nil):
+			v.add(key, "unknown modifier @%s in %q", name, path)
+		}
+	}
+}
+
+// transforms checks a transforms section against the registered
+// transforms and the fields the config maps.
+func (v *configValidator) transforms(section string, configured map[string]TransformPipeline, mapped map[string]bool) {
+	for _, field := range sortedTransformFields(configured) {
+		key := section + "." + field
+		if !orttoFieldID.MatchString(field) {
+			v.add(key, "invalid field ID %q: transforms need the full ID, e.g. \"str:cm:%s\"", field, fieldIDName(field))
+		} else if !mapped[field] {
+			v.add(key, "transform on unmapped field %s", field)
+		}
+		for i, transform := range configured[field] {
+			if _, _, ok := lookupTransform(transform); !ok {
+				itemKey := key
+				if len(configured[field]) > 1 {
+					itemKey = key + "." + strconv.Itoa(i)
+				}
+				v.add(itemKey, "unknown transform %q (registered: %s)", transform, strings.Join(RegisteredTransforms(), ", "))
+			}
+		}
+	}
+}
+
+func (v *configValidator) extensions(c Config) {
+	const fundraiser = "fundraiserExtensions"
+	donation := c.FundraiserExtensions.Streaks.Donation
+	v.streakDays(fundraiser+".streaks.donation", donation.Days, donation.Mapping)
+
+	activity := c.FundraiserExtensions.Streaks.Activity
+	v.streakDays(fundraiser+".streaks.activity", activity.Days, activity.Mapping)
+	var from, to time.Time
+	var err error
+	if activity.From != "" {
+		if from, err = time.Parse(time.RFC3339, activity.From); err != nil {
+			v.add(fundraiser+".streaks.activity.from", "invalid RFC3339 time %q", activity.From)
+		}
+	}
+	if activity.To != "" {
+		if to, err = time.Parse(time.RFC3339, activity.To); err != nil {
+			v.add(fundraiser+".streaks.activity.to", "invalid RFC3339 time %q", activity.To)
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		v.add(fundraiser+".streaks.activity.to", "must be after from (%s)", activity.From)
+	}
+
+	window := c.FundraiserExtensions.TotalInWindow
+	if window.Window != "" || window.Mapping != "" {
+		key := fundraiser + ".totalInWindow"
+		if d, err := time.ParseDuration(window.Window); err != nil || d <= 0 {
+			v.add(key+".window", "invalid window %q: must be a positive duration such as \"168h\"", window.Window)
+		}
+		if window.Mapping == "" {
+			v.add(key+".mapping", "mapping is required when a window is set")
+		}
+	}
+
+	v.splitExerciseTotals(fundraiser+".splitExerciseTotals", c.FundraiserExtensions.SplitExerciseTotals)
+	v.splitExerciseTotals("teamExtensions.splitExerciseTotals", c.TeamExtensions.SplitExerciseTotals)
+	v.leaderboard(fundraiser+".leaderboard", c.FundraiserExtensions.Leaderboard)
+	v.leaderboard("teamExtensions.leaderboard", c.TeamExtensions.Leaderboard)
+
+	milestones := c.FundraiserExtensions.Milestones
+	v.milestone(fundraiser+".milestones.total", milestones.Total)
+	v.milestone(fundraiser+".milestones.exerciseTotal", milestones.ExerciseTotal)
+	v.milestone(fundraiser+".milestones.donors", milestones.Donors)
+}
+
+func (v *configValidator) milestone(key string, m Milestone) {
+	for i, threshold := range m.Thresholds {
+		if threshold <= 0 {
+			v.add(key+".thresholds."+strconv.Itoa(i), "milestone thresholds must be positive, got %d", threshold)
+		}
+	}
+	if len(m.Thresholds) > 0 && m.Mapping == "" {
+		v.add(key+".mapping", "mapping is required when thresholds are set")
+	}
+	if len(m.Thresholds) == 0 && m.Mapping != "" {
+		v.add(key+".thresholds", "thresholds are required when a mapping is set")
+	}
+}
+
+// leaderboard reports an unknown By, or a By with nothing to write.
+func (v *configValidator) leaderboard(key string, l Leaderboard) {
+	if l.By != "" && l.By != "total" && l.By != "exerciseTotal" {
+		v.add(key+".by", "invalid by %q: must be \"total\" or \"exerciseTotal\"", l.By)
+	}
+	if l.By != "" && !l.IsConfigured() {
+		v.add(key, "rankMapping or percentileMapping is required when by is set")
+	}
+}
+
+func (v *configValidator) streakDays(key string, days []int, mapping string) {
+	for i, d := range days {
+		if d <= 0 {
+			v.add(key+".days."+strconv.Itoa(i), "streak days must be positive, got %d", d)
+		}
+	}
+	if len(days) > 0 && mapping == "" {
+		v.add(key+".mapping", "mapping is required when days are set")
+	}
+	if len(days) == 0 && mapping != "" {
+		v.add(key+".days", "days are required when a mapping is set")
+	}
+}
+
+// splitExerciseTotals reports a partial configuration, which
+// SplitExerciseTotals.IsConfigured would otherwise silently ignore.
+func (v *configValidator) splitExerciseTotals(key string, s SplitExerciseTotals) {
+	if s.From == "" && len(s.Mappings) == 0 {
+		return
+	}
+	if s.From == "" {
+		v.add(key+".from", "from is required when mappings are set")
+	}
+	if len(s.Mappings) != 2 {
+		v.add(key+".mappings", "needs exactly 2 mappings, got %d", len(s.Mappings))
+	}
+}
+
+// target checks the settings the ortto-activities target needs, and the
+// shape of any Ortto field IDs in the API settings.
+func (v *configValidator) target(c Config) {
+	const settings = "api.settings"
+	s := c.API.Settings
+	if c.Target == "ortto-activities" {
+		if s.OrttoActivityID == "" {
+			v.add(settings+".orttoActivityId", "required for the ortto-activities target")
+		}
+		if s.OrttoFundraiserMergeField == "" {
+			v.add(settings+".orttoFundraiserMergeField", "required for the ortto-activities target")
+		}
+	}
+	if s.OrttoActivityID != "" && !orttoActivityID.MatchString(s.OrttoActivityID) {
+		v.add(settings+".orttoActivityId", "invalid activity ID %q: expected \"act:cm:<name>\"", s.OrttoActivityID)
+	}
+	for _, f := range []struct {
+		key   string
+		value string
+	}{
+		{settings + ".orttoFundraiserMergeField", s.OrttoFundraiserMergeField},
+		{settings + ".orttoFundraiserSnapshotField", s.OrttoFundraiserSnapshotField},
+	} {
+		if f.value != "" && !orttoFieldID.MatchString(f.value) {
+			v.add(f.key, "invalid field ID %q", f.value)
+		}
+	}
+	for i, field := range s.OrttoActivityAdditionalPersonFields {
+		if !orttoFieldID.MatchString(field) {
+			v.add(settings+".orttoActivityAdditionalPersonFields."+strconv.Itoa(i), "invalid field ID %q", field)
+		}
+	}
+}
+
+// referrals checks the referrals field, its companion mappings and the
+// messages endpoint are configured together.
+func (v *configValidator) referrals(c Config) {
+	const key = "fundraiserReferralFieldMappings"
+	m := c.FundraiserReferralFieldMappings
+	configured := len(m.User) > 0 || len(m.Custom) > 0
+	if c.API.Settings.RaiselyFundraiserReferralsField == "" {
+		if configured {
+			v.add(key, "set, but api.settings.raiselyFundraiserReferralsField is not, so referrals are never sent")
+		}
+		return
+	}
+	if _, ok := m.User["email"]; !ok {
+		v.add(key+".user.email", "required: referrals without an email are skipped")
+	}
+	if c.API.Endpoints.RaiselyMessages == "" {
+		v.add("api.endpoints.raiselyMessages", "required when api.settings.raiselyFundraiserReferralsField is set")
+	}
+	for _, section := range []struct {
+		key    string
+		values map[string]string
+	}{{key + ".user", m.User}, {key + ".custom", m.Custom}} {
+		for _, field := range sortedKeys(section.values) {
+			v.modifiers(section.key+"."+field, section.values[field])
+		}
+	}
+}
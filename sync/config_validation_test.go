@@ -0,0 +1,207 @@
+package sync
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testMappingFile(name, body string) MappingFile {
+	return MappingFile{Name: name, Reader: strings.NewReader(body), Length: len(body)}
+}
+
+func TestValidateConfig_ReportsPositions(t *testing.T) {
+	t.Parallel()
+	required := testMappingFile("required.yaml", `
+api:
+  settings:
+    orttoActivityId: "act:cm:fundraiser-update"
+fundraiserFieldMappings:
+  builtin:
+    strings:
+      email: "user.email"
+`)
+	campaign := testMappingFile("campaign.yaml", `
+api:
+  settings:
+    raiselyFundraiserReferralsField: "private.invitations"
+fundraiserFieldMappings:
+  custom:
+    strings:
+      team-name: "team.name|@uppercase"
+      Shirt_Size: "public.shirtSize"
+    integers:
+      raised: "sumTotal|@default:0"
+fundraiserFieldTransforms:
+  "str:cm:team-name": [trim, toUpper]
+  "str:cm:nickname": toUpper
+fundraiserExtensions:
+  streaks:
+    donation:
+      days: [7, 0]
+      mapping: "int:cm:streak"
+  totalInWindow:
+    window: "7 days"
+    mapping: "int:cm:weekly"
+  splitExerciseTotals:
+    from: "exercise"
+    mappings: ["int:cm:walk"]
+`)
+	cfg, err := YAMLConfigUnmarshaler{}.Unmarshal(JSONCompositeEnvVar{}, required, campaign)
+	if err != nil {
+		t.Fatalf("Unmarshal returned unexpected error: %v", err)
+	}
+	cfg.Target = "ortto-activities"
+
+	err = ValidateConfig(cfg)
+	var verr *ConfigValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected a *ConfigValidationError, got %v", err)
+	}
+
+	want := []struct {
+		key      string
+		position string
+		message  string
+	}{
+		{"fundraiserFieldMappings.custom.strings.Shirt_Size", "campaign.yaml:9:7", "invalid field name"},
+		{"fundraiserFieldMappings.custom.strings.team-name", "campaign.yaml:8:7", "unknown modifier @uppercase"},
+		{"fundraiserFieldTransforms.str:cm:nickname", "campaign.yaml:14:3", "unmapped field"},
+		{"fundraiserExtensions.streaks.donation.days.1", "campaign.yaml:18:17", "must be positive"},
+		{"fundraiserExtensions.totalInWindow.window", "campaign.yaml:21:5", "invalid window"},
+		{"fundraiserExtensions.splitExerciseTotals.mappings", "campaign.yaml:25:5", "exactly 2 mappings"},
+		{"api.settings.orttoFundraiserMergeField", "campaign.yaml:3:3", "required for the ortto-activities target"},
+		{"fundraiserReferralFieldMappings.user.email", "", "required"},
+		{"api.endpoints.raiselyMessages", "campaign.yaml:2:1", "required"},
+	}
+	for _, w := range want {
+		found := false
+		for _, issue := range verr.Issues {
+			if issue.Key == w.key && strings.Contains(issue.Message, w.message) {
+				found = true
+				if got := issue.Position.String(); got != w.position {
+					t.Errorf("%s: position = %q, want %q", w.key, got, w.position)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("missing issue %s: %s", w.key, w.message)
+		}
+	}
+	if len(verr.Issues) != len(want) {
+		t.Errorf("got %d issues, want %d:\n%v", len(verr.Issues), len(want), err)
+	}
+}
+
+func TestValidateConfig_Valid(t *testing.T) {
+	t.Parallel()
+	body := `
+api:
+  settings:
+    orttoActivityId: "act:cm:fundraiser-update"
+    orttoFundraiserMergeField: "str::email"
+fundraiserFieldMappings:
+  builtin:
+    strings:
+      email: "user.email"
+  custom:
+    integers:
+      raised: "sumTotal|@default:0"
+    booleans:
+      has-goal: "= goal > 0"
+teamFieldMappings:
+  custom:
+    strings:
+      team-type: "public.organisationType"
+      team-captain: "@team.captain.user.fullName"
+    integers:
+      team-size: "= @team.members.count"
+teamFieldTransforms:
+  "str:cm:team-type": onlyIfOrgTypeSchool
+  "int:cm:raised": "warnIfEqual:0"
+`
+	// Validation works on expanded and unexpanded mappings alike.
+	for _, mapper := range []CRMFieldMapper{nil, OrttoCRMFieldMapper} {
+		file := testMappingFile("campaign.yaml", body)
+		cfg, err := YAMLConfigUnmarshaler{CRMFieldMapper: mapper}.Unmarshal(JSONCompositeEnvVar{}, file)
+		if err != nil {
+			t.Fatalf("Unmarshal returned unexpected error: %v", err)
+		}
+		cfg.Target = "ortto-activities"
+		if err := ValidateConfig(cfg); err != nil {
+			t.Errorf("expected a valid config (mapper %v), got %v", mapper, err)
+		}
+	}
+}
+
+func TestValidateConfig_FieldIDPrefixes(t *testing.T) {
+	t.Parallel()
+	var cfg Config
+	cfg.FundraiserFieldMappings.Custom = FieldMappings{
+		Strings:  map[string]string{"int:cm:total": "sumTotal"},
+		Booleans: map[string]string{"bol::opt-in": "private.optIn"},
+	}
+	cfg.FundraiserFieldTransforms = map[string]TransformPipeline{
+		"team-type":    {"toUpper"},
+		"str:cm:total": {"trim", "toUpperr"},
+	}
+	cfg.API.Settings.OrttoActivityID = "fundraiser-update"
+
+	err := ValidateConfig(cfg)
+	if err == nil {
+		t.Fatal("expected validation issues")
+	}
+	for _, want := range []string{
+		`fundraiserFieldMappings.custom.strings.total: field ID "int:cm:total" does not match its section, expected "str:cm:total"`,
+		`fundraiserFieldMappings.custom.booleans.opt-in: field ID "bol::opt-in" does not match its section, expected "bol:cm:opt-in"`,
+		`fundraiserFieldTransforms.team-type: invalid field ID "team-type"`,
+		`fundraiserFieldTransforms.str:cm:total.1: unknown transform "toUpperr"`,
+		`api.settings.orttoActivityId: invalid activity ID "fundraiser-update"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error should contain %q, got:\n%v", want, err)
+		}
+	}
+}
+
+func TestYAMLConfigUnmarshaler_ReadErrorPosition(t *testing.T) {
+	t.Parallel()
+	file := testMappingFile("campaign.yaml", `
+campaignPrefix: "acme"
+fundraiserExtensions:
+  streaks:
+    donation:
+      days: "weekly"
+`)
+	_, err := YAMLConfigUnmarshaler{}.Unmarshal(JSONCompositeEnvVar{}, file)
+	if err == nil || !strings.Contains(err.Error(), "(campaign.yaml:3:1)") {
+		t.Errorf("err = %v, want it to include the key's position", err)
+	}
+}
+
+func TestValidateConfig_Leaderboard(t *testing.T) {
+	t.Parallel()
+	var cfg Config
+	cfg.FundraiserExtensions.Leaderboard = Leaderboard{By: "donations", RankMapping: "public.rank"}
+	cfg.TeamExtensions.Leaderboard = Leaderboard{By: "exerciseTotal"}
+
+	var verr *ConfigValidationError
+	if !errors.As(ValidateConfig(cfg), &verr) {
+		t.Fatal("expected a *ConfigValidationError")
+	}
+	got := map[string]string{}
+	for _, issue := range verr.Issues {
+		if strings.Contains(issue.Key, "leaderboard") {
+			got[issue.Key] = issue.Message
+		}
+	}
+	if !strings.Contains(got["fundraiserExtensions.leaderboard.by"], "invalid by") {
+		t.Errorf("expected an invalid by issue, got %v", got)
+	}
+	if !strings.Contains(got["teamExtensions.leaderboard"], "rankMapping or percentileMapping is required") {
+		t.Errorf("expected a missing mapping issue, got %v", got)
+	}
+	if len(got) != 2 {
+		t.Errorf("got %d leaderboard issues, want 2: %v", len(got), got)
+	}
+}
@@ -0,0 +1,205 @@
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"slices"
+	gosync "sync"
+	"sync/atomic"
+	"time"
+)
+
+// DirMappings returns EmbeddedMappings backed by a directory on disk
+// laid out like the embedded mappings (required*.yaml and
+// defaults*.yaml at the top, one directory per org), so mapping changes
+// can be picked up without a rebuild. See [ConfigWatcher].
+func DirMappings(dir string) EmbeddedMappings {
+	return EmbeddedMappings{Root: ".", Files: dirFS{os.DirFS(dir)}}
+}
+
+// dirFS adapts an fs.FS to EmbeddedFS.
+type dirFS struct {
+	fs.FS
+}
+
+func (d dirFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(d.FS, name)
+}
+
+func (d dirFS) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(d.FS, name)
+}
+
+// ConfigWatcher keeps a campaign's configs up to date with its mapping
+// files. Each reload re-runs the same load as the LoadCampaignConfigs*
+// functions, one config per target, and checks each with
+// [ValidateConfig]; if any fails to load or validate the error is logged
+// and the previous configs are all kept.
+//
+// Config and Configs return snapshots, so a [Service] created from one
+// keeps the config it started with while later NewService calls see the
+// update:
+//
+//	w, err := sync.WatchCampaignConfigFromEnvironment(sync.DirMappings("/etc/fez/mappings"), campaignUUID)
+//	...
+//	go w.Run(30*time.Second, ctx)
+//	...
+//	svc, err := sync.NewService(w.Config(), campaignUUID, trigger)
+type ConfigWatcher struct {
+	mappings        EmbeddedMappings
+	envVar          CampaignEnvVar
+	compositeEnvVar CompositeEnvVar
+	options         configOptions
+
+	current     atomic.Pointer[[]Config]
+	mu          gosync.Mutex // serialises reloads
+	fingerprint string       // of the files last loaded, successfully or not
+}
+
+// WatchCampaignConfigFromEnvironment loads a campaign's configs like
+// [LoadCampaignConfigsFromEnvironment] and returns a ConfigWatcher that
+// reloads them when the mapping files change.
+func WatchCampaignConfigFromEnvironment(mappings EmbeddedMappings, campaign string, opts ...ConfigOption) (*ConfigWatcher, error) {
+	if _, err := initialised(); err != nil {
+		return nil, err
+	}
+
+	var options configOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	envVar, compositeEnvVar, err := campaignEnvVarFromEnvironment(campaign)
+	if err != nil {
+		return nil, err
+	}
+	return newConfigWatcher(mappings, envVar, compositeEnvVar, options)
+}
+
+// WatchCampaignConfigFromJSON loads a campaign's configs like
+// [LoadCampaignConfigsFromJSON] and returns a ConfigWatcher that reloads
+// them when the mapping files change.
+func WatchCampaignConfigFromJSON(mappings EmbeddedMappings, configJSON map[string]string, opts ...ConfigOption) (*ConfigWatcher, error) {
+	if _, err := initialised(); err != nil {
+		return nil, err
+	}
+
+	var options configOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	envVar, compositeEnvVar, err := campaignEnvVarFromJSON(configJSON)
+	if err != nil {
+		return nil, err
+	}
+	return newConfigWatcher(mappings, envVar, compositeEnvVar, options)
+}
+
+// newConfigWatcher performs the initial load, which must succeed.
+func newConfigWatcher(mappings EmbeddedMappings, envVar CampaignEnvVar, compositeEnvVar CompositeEnvVar, options configOptions) (*ConfigWatcher, error) {
+	w := &ConfigWatcher{
+		mappings:        mappings,
+		envVar:          envVar,
+		compositeEnvVar: compositeEnvVar,
+		options:         options,
+	}
+	if _, err := w.Reload(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Config returns the current config snapshot for a campaign with a
+// single target. For a campaign with several targets it is the first of
+// Configs.
+func (w *ConfigWatcher) Config() Config {
+	return (*w.current.Load())[0]
+}
+
+// Configs returns the current config snapshots, one per target, in the
+// order LoadCampaignConfigs returns them.
+func (w *ConfigWatcher) Configs() []Config {
+	return slices.Clone(*w.current.Load())
+}
+
+// Reload checks the campaign's mapping files (for each target: required,
+// defaults, campaign and referrals companion) and, if any changed since
+// the last load, loads and validates the configs again. It reports
+// whether new configs were swapped in. On error the previous configs are
+// kept until the files change again.
+func (w *ConfigWatcher) Reload() (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	fingerprint, err := w.mappings.fingerprint(w.envVar.Path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read mapping files %w", err)
+	}
+	if fingerprint == w.fingerprint {
+		return false, nil
+	}
+	w.fingerprint = fingerprint
+
+	configs, err := loadCampaignConfigs(w.mappings, w.envVar, w.compositeEnvVar, w.options)
+	if err != nil {
+		return false, fmt.Errorf("failed to load config for %s %w", w.envVar.Path, err)
+	}
+	for _, config := range configs {
+		if err := ValidateConfig(config); err != nil {
+			return false, fmt.Errorf("failed to load config for %s target %s %w", w.envVar.Path, config.Target, err)
+		}
+	}
+
+	w.current.Store(&configs)
+	return true, nil
+}
+
+// Run calls Reload every interval until ctx is done, logging reloads
+// and any errors.
+func (w *ConfigWatcher) Run(interval time.Duration, ctx context.Context) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := w.Reload()
+			if err != nil {
+				log.Printf("Warning: keeping previous config: %v", err)
+			} else if reloaded {
+				log.Printf("Reloaded config for %s", w.envVar.Path)
+			}
+		}
+	}
+}
+
+// fingerprint hashes the names and contents of the mapping files loaded
+// for every target of mappingPath, so changes to other campaigns' files
+// are ignored.
+func (em EmbeddedMappings) fingerprint(mappingPath string) (string, error) {
+	targets, err := em.findCampaignMappingFilesByTarget(mappingPath)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, t := range targets {
+		fmt.Fprintf(h, "%s\x00", t.target)
+		for _, source := range t.sources {
+			data, err := io.ReadAll(source.Reader)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(h, "%s\x00%d\x00", source.Name, len(data))
+			h.Write(data)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,123 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeMappingFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigWatcher_Reload(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeMappingFile(t, dir, "required.yaml", "api:\n  keys:\n    raisely: ${RAISELY_KEY}\n")
+	writeMappingFile(t, dir, "defaults.yaml", "campaignPrefix: default\n")
+	writeMappingFile(t, dir, "ORG/LABEL.yaml", "campaignPrefix: v1\n")
+
+	values := map[string]string{"MAPPING_PATH": "ORG/LABEL", "RAISELY_KEY": "secret"}
+	w, err := newConfigWatcher(DirMappings(dir), CampaignEnvVar{Path: "ORG/LABEL"}, MapCompositeEnvVar{Values: values}, configOptions{})
+	if err != nil {
+		t.Fatalf("newConfigWatcher returned unexpected error: %v", err)
+	}
+	snapshot := w.Config()
+	if snapshot.CampaignPrefix != "v1" || snapshot.API.Keys.Raisely != "secret" {
+		t.Fatalf("unexpected initial config %+v", snapshot)
+	}
+
+	if reloaded, err := w.Reload(); reloaded || err != nil {
+		t.Errorf("Reload with no changes = %v, %v; want false, nil", reloaded, err)
+	}
+
+	writeMappingFile(t, dir, "ORG/LABEL.yaml", "campaignPrefix: v2\n")
+	if reloaded, err := w.Reload(); !reloaded || err != nil {
+		t.Fatalf("Reload after a change = %v, %v; want true, nil", reloaded, err)
+	}
+	if got := w.Config().CampaignPrefix; got != "v2" {
+		t.Errorf("CampaignPrefix = %q, want v2", got)
+	}
+	if snapshot.CampaignPrefix != "v1" {
+		t.Errorf("earlier snapshot changed to %q", snapshot.CampaignPrefix)
+	}
+
+	// An invalid config is rejected and the previous one kept.
+	writeMappingFile(t, dir, "ORG/LABEL.yaml", "campaignPrefix: v3\nteamExtensions:\n  splitExerciseTotals:\n    from: exercise\n")
+	reloaded, err := w.Reload()
+	if reloaded || err == nil || !strings.Contains(err.Error(), "exactly 2 mappings") {
+		t.Errorf("Reload of an invalid config = %v, %v; want a validation error", reloaded, err)
+	}
+	if got := w.Config().CampaignPrefix; got != "v2" {
+		t.Errorf("CampaignPrefix = %q, want the previous v2", got)
+	}
+	if reloaded, err := w.Reload(); reloaded || err != nil {
+		t.Errorf("Reload of the same invalid files = %v, %v; want false, nil", reloaded, err)
+	}
+
+	// Another campaign's files are not part of the fingerprint.
+	writeMappingFile(t, dir, "OTHER/LABEL.yaml", "campaignPrefix: other\n")
+	if reloaded, err := w.Reload(); reloaded || err != nil {
+		t.Errorf("Reload after another campaign changed = %v, %v; want false, nil", reloaded, err)
+	}
+
+	writeMappingFile(t, dir, "ORG/LABEL.yaml", "campaignPrefix: v4\n")
+	if reloaded, err := w.Reload(); !reloaded || err != nil || w.Config().CampaignPrefix != "v4" {
+		t.Errorf("Reload after fixing the config = %v, %v, %q; want true, nil, v4", reloaded, err, w.Config().CampaignPrefix)
+	}
+}
+
+func TestNewConfigWatcher_InitialLoadMustSucceed(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeMappingFile(t, dir, "required.yaml", "campaignPrefix: x\n")
+	writeMappingFile(t, dir, "defaults.yaml", "campaignPrefix: x\n")
+
+	_, err := newConfigWatcher(DirMappings(dir), CampaignEnvVar{Path: "ORG/LABEL"}, MapCompositeEnvVar{}, configOptions{})
+	if err == nil {
+		t.Fatal("expected an error for a missing campaign mapping file")
+	}
+}
+
+func TestConfigWatcher_EveryTarget(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeMappingFile(t, dir, "required.yaml", "")
+	writeMappingFile(t, dir, "defaults.yaml", "")
+	writeMappingFile(t, dir, "required.ortto-activities.yaml", "")
+	writeMappingFile(t, dir, "defaults.ortto-activities.yaml", "api:\n  settings:\n    orttoActivityId: act:cm:donation\n    orttoFundraiserMergeField: str::email\n")
+	writeMappingFile(t, dir, "ORG/LABEL.ortto-activities.yaml", "campaignPrefix: a1\n")
+	writeMappingFile(t, dir, "ORG/LABEL.ortto-contacts.yaml", "campaignPrefix: c1\n")
+
+	w, err := newConfigWatcher(DirMappings(dir), CampaignEnvVar{Path: "ORG/LABEL"}, MapCompositeEnvVar{}, configOptions{})
+	if err != nil {
+		t.Fatalf("newConfigWatcher returned unexpected error: %v", err)
+	}
+	prefixes := func() []string {
+		var result []string
+		for _, config := range w.Configs() {
+			result = append(result, config.Target+"="+config.CampaignPrefix)
+		}
+		return result
+	}
+	if got := prefixes(); !slices.Equal(got, []string{"ortto-activities=a1", "ortto-contacts=c1"}) {
+		t.Fatalf("initial configs = %v", got)
+	}
+
+	writeMappingFile(t, dir, "ORG/LABEL.ortto-contacts.yaml", "campaignPrefix: c2\n")
+	if reloaded, err := w.Reload(); !reloaded || err != nil {
+		t.Fatalf("Reload after a change = %v, %v; want true, nil", reloaded, err)
+	}
+	if got := prefixes(); !slices.Equal(got, []string{"ortto-activities=a1", "ortto-contacts=c2"}) {
+		t.Errorf("reloaded configs = %v", got)
+	}
+}
@@ -71,7 +71,7 @@ func GenerateFieldDocumentation(config Config, campaignLabel string, isPersonFie
 
 // processFieldMappings extracts field documentation from a FieldMappings struct.
 // Fields are processed in sorted order by field ID for deterministic output.
-func processFieldMappings(rows *[]FieldDocRow, mappings FieldMappings, transforms map[string]string, isBuiltin bool, isTeamField bool, isReferralField bool, isPersonFieldFn func(fieldID string) bool) {
+func processFieldMappings(rows *[]FieldDocRow, mappings FieldMappings, transforms map[string]TransformPipeline, isBuiltin bool, isTeamField bool, isReferralField bool, isPersonFieldFn func(fieldID string) bool) {
 	// Strings
 	for _, fieldID := range sortedKeys(mappings.Strings) {
 		*rows = append(*rows, createFieldDocRow(fieldID, mappings.Strings[fieldID], "Text", transforms, isBuiltin, isTeamField, isReferralField, isPersonFieldFn))
@@ -153,7 +153,7 @@ func extractNestedSourcePath(nestedMap map[string]string) string {
 }
 
 // createFieldDocRow creates a FieldDocRow from field mapping data.
-func createFieldDocRow(fieldID string, sourcePathWithTransforms string, fieldType string, transforms map[string]string, isBuiltin bool, isTeamField bool, isReferralField bool, isPersonFieldFn func(fieldID string) bool) FieldDocRow {
+func createFieldDocRow(fieldID string, sourcePathWithTransforms string, fieldType string, transforms map[string]TransformPipeline, isBuiltin bool, isTeamField bool, isReferralField bool, isPersonFieldFn func(fieldID string) bool) FieldDocRow {
 	row := FieldDocRow{
 		FieldName:      extractFieldName(fieldID),
 		FieldID:        fieldID,
@@ -175,8 +175,8 @@ func createFieldDocRow(fieldID string, sourcePathWithTransforms string, fieldTyp
 		notes = append(notes, formatTransformNote(transform))
 	}
 
-	// Add field transforms to notes
-	if transform, exists := transforms[fieldID]; exists {
+	// Add field transforms to notes, in the order they are applied
+	for _, transform := range transforms[fieldID] {
 		notes = append(notes, formatTransformNote(transform))
 	}
 
@@ -221,6 +221,9 @@ func parseSourcePath(value string) (string, []string) {
 	if value == "" {
 		return "(computed)", nil
 	}
+	if isMappingExpr(value) {
+		return value, nil
+	}
 
 	parts := strings.Split(value, "|")
 	sourcePath := parts[0]
@@ -276,6 +279,8 @@ func formatTransformNote(transform string) string {
 		return "Uses @now transform"
 	case strings.HasPrefix(transform, "onlyIfSelfDonatedDuringRegistrationWindow:"):
 		return "Only syncs if self-donated during registration window"
+	case transform == "trim":
+		return "Trims surrounding whitespace"
 	case transform == "toLower":
 		return "Converts to lowercase"
 	case transform == "toUpper":
@@ -407,6 +412,30 @@ func GenerateExtensionsDocumentation(config Config, campaignLabel string) Extens
 		})
 	}
 
+	// Fundraiser leaderboard
+	doc.Rows = append(doc.Rows, leaderboardDocRows(config.FundraiserExtensions.Leaderboard, "Fundraiser", "fundraiserExtensions.leaderboard")...)
+
+	// Fundraiser milestones
+	milestones := config.FundraiserExtensions.Milestones
+	for _, m := range []struct {
+		name      string
+		key       string
+		milestone Milestone
+	}{
+		{"total", "total", milestones.Total},
+		{"exercise total", "exerciseTotal", milestones.ExerciseTotal},
+		{"donors", "donors", milestones.Donors},
+	} {
+		if m.milestone.IsConfigured() {
+			doc.Rows = append(doc.Rows, ExtensionsDocRow{
+				Extension:    fmt.Sprintf("Milestones (%s)", m.name),
+				RaiselyField: m.milestone.Mapping,
+				AppliesTo:    "Fundraiser",
+				Config:       fmt.Sprintf("fundraiserExtensions.milestones.%s.mapping", m.key),
+			})
+		}
+	}
+
 	// Team split exercise totals
 	if config.TeamExtensions.SplitExerciseTotals.IsConfigured() {
 		for i, mapping := range config.TeamExtensions.SplitExerciseTotals.Mappings {
@@ -423,9 +452,34 @@ func GenerateExtensionsDocumentation(config Config, campaignLabel string) Extens
 		}
 	}
 
+	// Team leaderboard
+	doc.Rows = append(doc.Rows, leaderboardDocRows(config.TeamExtensions.Leaderboard, "Team", "teamExtensions.leaderboard")...)
+
 	return doc
 }
 
+// leaderboardDocRows documents the Raisely fields written by a leaderboard.
+func leaderboardDocRows(leaderboard Leaderboard, appliesTo string, key string) []ExtensionsDocRow {
+	var rows []ExtensionsDocRow
+	if leaderboard.RankMapping != "" {
+		rows = append(rows, ExtensionsDocRow{
+			Extension:    fmt.Sprintf("Leaderboard Rank (%s)", leaderboard.ByPath()),
+			RaiselyField: leaderboard.RankMapping,
+			AppliesTo:    appliesTo,
+			Config:       key + ".rankMapping",
+		})
+	}
+	if leaderboard.PercentileMapping != "" {
+		rows = append(rows, ExtensionsDocRow{
+			Extension:    fmt.Sprintf("Leaderboard Percentile (%s)", leaderboard.ByPath()),
+			RaiselyField: leaderboard.PercentileMapping,
+			AppliesTo:    appliesTo,
+			Config:       key + ".percentileMapping",
+		})
+	}
+	return rows
+}
+
 // FormatCSV formats the extensions documentation as CSV.
 func (d ExtensionsDocumentation) FormatCSV() (string, error) {
 	var buf bytes.Buffer
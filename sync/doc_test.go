@@ -246,3 +246,46 @@ func TestExtensionsDocumentation_FormatCSV(t *testing.T) {
 		t.Errorf("expected Total In Window row, got %q", lines[3])
 	}
 }
+
+func TestGenerateExtensionsDocumentation_Leaderboard(t *testing.T) {
+	config := Config{}
+	config.FundraiserExtensions.Leaderboard = Leaderboard{RankMapping: "public.rank", PercentileMapping: "public.topPercent"}
+	config.TeamExtensions.Leaderboard = Leaderboard{By: "exerciseTotal", RankMapping: "public.teamRank"}
+
+	doc := GenerateExtensionsDocumentation(config, "CAMPAIGN")
+
+	want := []ExtensionsDocRow{
+		{"Leaderboard Rank (total)", "public.rank", "Fundraiser", "fundraiserExtensions.leaderboard.rankMapping"},
+		{"Leaderboard Percentile (total)", "public.topPercent", "Fundraiser", "fundraiserExtensions.leaderboard.percentileMapping"},
+		{"Leaderboard Rank (exerciseTotal)", "public.teamRank", "Team", "teamExtensions.leaderboard.rankMapping"},
+	}
+	if len(doc.Rows) != len(want) {
+		t.Fatalf("expected %d rows, got %d", len(want), len(doc.Rows))
+	}
+	for i, w := range want {
+		if doc.Rows[i] != w {
+			t.Errorf("row %d: expected %+v, got %+v", i, w, doc.Rows[i])
+		}
+	}
+}
+
+func TestGenerateExtensionsDocumentation_Milestones(t *testing.T) {
+	config := Config{}
+	config.FundraiserExtensions.Milestones.Total = Milestone{Thresholds: []int64{10000}, Mapping: "public.totalMilestones"}
+	config.FundraiserExtensions.Milestones.Donors = Milestone{Thresholds: []int64{5}, Mapping: "public.donorMilestones"}
+
+	doc := GenerateExtensionsDocumentation(config, "CAMPAIGN")
+
+	want := []ExtensionsDocRow{
+		{"Milestones (total)", "public.totalMilestones", "Fundraiser", "fundraiserExtensions.milestones.total.mapping"},
+		{"Milestones (donors)", "public.donorMilestones", "Fundraiser", "fundraiserExtensions.milestones.donors.mapping"},
+	}
+	if len(doc.Rows) != len(want) {
+		t.Fatalf("expected %d rows, got %d", len(want), len(doc.Rows))
+	}
+	for i, w := range want {
+		if doc.Rows[i] != w {
+			t.Errorf("row %d: expected %+v, got %+v", i, w, doc.Rows[i])
+		}
+	}
+}
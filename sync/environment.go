@@ -10,6 +10,8 @@ import (
 // configOptions holds optional configuration for LoadCampaignConfigFromEnvironment.
 type configOptions struct {
 	crmFieldMapper CRMFieldMapper
+	secretProvider SecretProvider
+	cacheKey       string
 }
 
 // ConfigOption is a functional option for configuring LoadCampaignConfigFromEnvironment.
@@ -23,17 +25,51 @@ func ConfigWithCRMFieldMapper(mapper CRMFieldMapper) ConfigOption {
 	}
 }
 
+// ConfigWithSecretProvider sets the SecretProvider that resolves
+// ${secret:NAME} references in the mapping files.
+func ConfigWithSecretProvider(provider SecretProvider) ConfigOption {
+	return func(o *configOptions) {
+		o.secretProvider = provider
+	}
+}
+
+// ConfigWithCacheKey lets [CampaignRegistry] cache the configs it loads
+// under key (e.g. "ortto"), so later loads with the same key skip parsing
+// the mapping files until [CampaignRegistry.Refresh]. The key stands for
+// the EmbeddedMappings and the other options of the load: use a
+// different key whenever either differs. Secrets resolved by a
+// SecretProvider are cached along with the rest of the config, so call
+// Refresh after rotating them.
+func ConfigWithCacheKey(key string) ConfigOption {
+	return func(o *configOptions) {
+		o.cacheKey = key
+	}
+}
+
 // FindCampaignEnvVar scans environment variables for a JSON value containing
 // a campaignUUIDKey key matching the given campaignUUID and returns the full
 // CampaignEnvVar (Name, Path, UUID, parsed Config map).
 //
 // The campaignUUIDKey parameter specifies the JSON key to look for
-// (e.g. "RAISELY_CAMPAIGN_UUID" for the Raisely2Ortto flavour).
+// (e.g. "RAISELY_CAMPAIGN_UUID" for the Raisely2Ortto flavour, or
+// "FUNRAISIN_CAMPAIGN_UUID" for the Funraisin2Ortto flavour).
 //
 // Returns a zero CampaignEnvVar with nil error if no env var matches.
 // Returns an error if multiple env vars match the same UUID, or if MAPPING_PATH
 // is missing.
+//
+// Once Init has run, lookups by the initialised flavour's key are served
+// from the [CampaignRegistry] when it has the campaign, so an env var
+// changed after Init is seen as it was until [CampaignRegistry.Refresh]
+// is called. Campaigns the registry doesn't have (added after Init, or
+// quarantined) are looked for in the environment as before.
 func FindCampaignEnvVar(campaignUUIDKey string, campaignUUID string) (CampaignEnvVar, error) {
+	if r := campaignRegistry; r != nil && r.uuidKey == campaignUUIDKey {
+		if envVar, ok := r.Lookup(campaignUUID); ok {
+			return envVar, nil
+		}
+	}
+
 	var matches []CampaignEnvVar
 
 	for _, env := range os.Environ() {
@@ -85,6 +121,8 @@ func campaignUUIDKeyForFlavour(flavour Flavour) (string, error) {
 	switch flavour {
 	case Raisely2Ortto:
 		return "RAISELY_CAMPAIGN_UUID", nil
+	case Funraisin2Ortto:
+		return "FUNRAISIN_CAMPAIGN_UUID", nil
 	default:
 		return "", fmt.Errorf("unsupported flavour %v", flavour)
 	}
@@ -102,62 +140,47 @@ type CampaignEnvVar struct {
 	Config map[string]string // Full parsed JSON config from the env var
 }
 
-// FindAllCampaignEnvVars scans environment variables for JSON values containing
-// a campaign UUID key (determined by the initialised flavour) and a MAPPING_PATH.
-// Returns one entry per matching env var.
+// FindAllCampaignEnvVars returns the env vars containing a campaign UUID
+// key (determined by the initialised flavour) and a MAPPING_PATH, as
+// indexed by Init (see [CampaignRegistry]), sorted by env var name.
 func FindAllCampaignEnvVars() ([]CampaignEnvVar, error) {
-	campaignUUIDKey, err := campaignUUIDKeyForFlavour(GetInitialisedFlavour())
+	r, err := Campaigns()
 	if err != nil {
 		return nil, err
 	}
-
-	var result []CampaignEnvVar
-	for _, env := range os.Environ() {
-		parts := strings.SplitN(env, "=", 2)
-		if len(parts) != 2 {
-			continue
-		}
-		name, value := parts[0], parts[1]
-
-		if !strings.HasPrefix(name, CampaignEnvVarPrefix) {
-			continue
-		}
-
-		var m map[string]string
-		if err := json.Unmarshal([]byte(value), &m); err != nil {
-			return nil, fmt.Errorf("env var %q has %s prefix but contains invalid JSON: %w", name, CampaignEnvVarPrefix, err)
-		}
-
-		p, hasPath := m["MAPPING_PATH"]
-		uuid, hasUUID := m[campaignUUIDKey]
-		if hasPath && hasUUID {
-			result = append(result, CampaignEnvVar{Name: name, Path: p, UUID: uuid, Config: m})
-		}
-	}
-	return result, nil
+	return r.All(), nil
 }
 
+// LoadCampaignConfigFromEnvironment returns the config for a campaign
+// UUID via [CampaignRegistry.Config]. Pass [ConfigWithCacheKey] to parse
+// the YAML once per campaign rather than on every call.
 func LoadCampaignConfigFromEnvironment(embeddedMappings EmbeddedMappings, campaign string, opts ...ConfigOption) (Config, error) {
-	mustBeInitialised()
-
-	var options configOptions
-	for _, opt := range opts {
-		opt(&options)
+	r, err := Campaigns()
+	if err != nil {
+		return Config{}, err
 	}
+	return r.Config(embeddedMappings, campaign, opts...)
+}
 
-	campaignUUIDKey, err := campaignUUIDKeyForFlavour(GetInitialisedFlavour())
+// campaignEnvVarFromEnvironment finds the FEZ_ env var for a campaign
+// UUID and the CompositeEnvVar that reads config values from it.
+func campaignEnvVarFromEnvironment(campaign string) (CampaignEnvVar, CompositeEnvVar, error) {
+	flavour, err := initialised()
 	if err != nil {
-		return Config{}, err
+		return CampaignEnvVar{}, nil, err
+	}
+	campaignUUIDKey, err := campaignUUIDKeyForFlavour(flavour)
+	if err != nil {
+		return CampaignEnvVar{}, nil, err
 	}
 	envVar, err := FindCampaignEnvVar(campaignUUIDKey, campaign)
 	if err != nil {
-		return Config{}, fmt.Errorf("failed to find campaign env var %w", err)
+		return CampaignEnvVar{}, nil, fmt.Errorf("failed to find campaign env var %w", err)
 	}
 	if envVar.Name == "" {
-		return Config{}, fmt.Errorf("no env var found with %s %q", campaignUUIDKey, campaign)
+		return CampaignEnvVar{}, nil, fmt.Errorf("no env var found with %s %q", campaignUUIDKey, campaign)
 	}
-
-	return loadCampaignConfig(embeddedMappings, envVar, JSONCompositeEnvVar{Parent: envVar.Name}, options.crmFieldMapper)
+	return envVar, JSONCompositeEnvVar{Parent: envVar.Name}, nil
 }
 
 // MapCompositeEnvVar implements CompositeEnvVar using an in-memory map.
@@ -177,25 +200,42 @@ func (c MapCompositeEnvVar) LookupEnv(child string) (string, bool) {
 // YAML mapping files. This is used by admin API routes where the config is
 // received in the request body rather than read from environment variables.
 func LoadCampaignConfigFromJSON(embeddedMappings EmbeddedMappings, configJSON map[string]string, opts ...ConfigOption) (Config, error) {
-	mustBeInitialised()
+	if _, err := initialised(); err != nil {
+		return Config{}, err
+	}
 
 	var options configOptions
 	for _, opt := range opts {
 		opt(&options)
 	}
 
+	envVar, compositeEnvVar, err := campaignEnvVarFromJSON(configJSON)
+	if err != nil {
+		return Config{}, err
+	}
+
+	return loadCampaignConfig(embeddedMappings, envVar, compositeEnvVar, options)
+}
+
+// campaignEnvVarFromJSON synthesizes a CampaignEnvVar from an in-memory
+// config map, along with the CompositeEnvVar that reads values from it.
+func campaignEnvVarFromJSON(configJSON map[string]string) (CampaignEnvVar, CompositeEnvVar, error) {
 	mappingPath := configJSON["MAPPING_PATH"]
 	if mappingPath == "" {
-		return Config{}, fmt.Errorf("MAPPING_PATH is required")
+		return CampaignEnvVar{}, nil, fmt.Errorf("MAPPING_PATH is required")
 	}
 
 	// Synthesize a CampaignEnvVar from the provided JSON: there is no
 	// real underlying env var, so Name is empty. UUID is read via the
 	// flavour key when present (admin paths may omit it). Config carries
 	// the full input map so consumers retain access to extra keys.
-	campaignUUIDKey, err := campaignUUIDKeyForFlavour(GetInitialisedFlavour())
+	flavour, err := initialised()
 	if err != nil {
-		return Config{}, err
+		return CampaignEnvVar{}, nil, err
+	}
+	campaignUUIDKey, err := campaignUUIDKeyForFlavour(flavour)
+	if err != nil {
+		return CampaignEnvVar{}, nil, err
 	}
 	envVar := CampaignEnvVar{
 		Name:   "",
@@ -203,8 +243,39 @@ func LoadCampaignConfigFromJSON(embeddedMappings EmbeddedMappings, configJSON ma
 		UUID:   configJSON[campaignUUIDKey],
 		Config: configJSON,
 	}
+	return envVar, MapCompositeEnvVar{Values: configJSON}, nil
+}
 
-	return loadCampaignConfig(embeddedMappings, envVar, MapCompositeEnvVar{Values: configJSON}, options.crmFieldMapper)
+// LoadCampaignConfigsFromEnvironment returns one config per Ortto target
+// of a campaign (see [EmbeddedMappings.FindCampaignMappingFilesWithTargetsByPath])
+// via [CampaignRegistry.Configs]. Use it with [NewMultiTargetService]
+// for campaigns that sync to more than one target.
+func LoadCampaignConfigsFromEnvironment(embeddedMappings EmbeddedMappings, campaign string, opts ...ConfigOption) ([]Config, error) {
+	r, err := Campaigns()
+	if err != nil {
+		return nil, err
+	}
+	return r.Configs(embeddedMappings, campaign, opts...)
+}
+
+// LoadCampaignConfigsFromJSON is LoadCampaignConfigFromJSON returning
+// one config per Ortto target of the campaign.
+func LoadCampaignConfigsFromJSON(embeddedMappings EmbeddedMappings, configJSON map[string]string, opts ...ConfigOption) ([]Config, error) {
+	if _, err := initialised(); err != nil {
+		return nil, err
+	}
+
+	var options configOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	envVar, compositeEnvVar, err := campaignEnvVarFromJSON(configJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadCampaignConfigs(embeddedMappings, envVar, compositeEnvVar, options)
 }
 
 // loadCampaignConfig is the shared file-load + unmarshal + validation
@@ -213,37 +284,43 @@ func LoadCampaignConfigFromJSON(embeddedMappings EmbeddedMappings, configJSON ma
 // envVar and which CompositeEnvVar implementation supplies config
 // values. The CampaignEnvVar is stamped onto the result as Config.EnvVar
 // so consumers can derive org/label without re-scanning the environment.
-func loadCampaignConfig(embeddedMappings EmbeddedMappings, envVar CampaignEnvVar, compositeEnvVar CompositeEnvVar, crmFieldMapper CRMFieldMapper) (Config, error) {
-	var result Config
-	mappingPath := envVar.Path
-
-	campaignMappingFile, target, err := embeddedMappings.MustFindFirstCampaignMappingFileWithTargetByPath(mappingPath)
+func loadCampaignConfig(embeddedMappings EmbeddedMappings, envVar CampaignEnvVar, compositeEnvVar CompositeEnvVar, options configOptions) (Config, error) {
+	campaignMappingFile, target, err := embeddedMappings.MustFindFirstCampaignMappingFileWithTargetByPath(envVar.Path)
 	if err != nil {
-		return result, fmt.Errorf("failed to read campaign mapping file %w", err)
+		return Config{}, fmt.Errorf("failed to read campaign mapping file %w", err)
 	}
+	return loadCampaignConfigForTarget(embeddedMappings, envVar, compositeEnvVar, options, campaignMappingFile, target)
+}
 
-	requiredMappingFile, err := embeddedMappings.MustFindRequiredMappingFileForTarget(target)
+// loadCampaignConfigs is loadCampaignConfig for every target of the
+// campaign, in the order their mapping files were found.
+func loadCampaignConfigs(embeddedMappings EmbeddedMappings, envVar CampaignEnvVar, compositeEnvVar CompositeEnvVar, options configOptions) ([]Config, error) {
+	campaignMappingFiles, targets, err := embeddedMappings.FindCampaignMappingFilesWithTargetsByPath(envVar.Path)
 	if err != nil {
-		return result, fmt.Errorf("failed to read required mapping file %w", err)
+		return nil, fmt.Errorf("failed to read campaign mapping file %w", err)
 	}
-
-	defaultsMappingFile, err := embeddedMappings.MustFindDefaultsMappingFileForTarget(target)
-	if err != nil {
-		return result, fmt.Errorf("failed to read defaults mapping file %w", err)
+	configs := make([]Config, 0, len(targets))
+	for i, target := range targets {
+		config, err := loadCampaignConfigForTarget(embeddedMappings, envVar, compositeEnvVar, options, campaignMappingFiles[i], target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config for target %q %w", target, err)
+		}
+		configs = append(configs, config)
 	}
+	return configs, nil
+}
 
-	// Optional referrals companion file (Raisely Custom Messages mapping)
-	referralsCompanionFile, err := embeddedMappings.FindReferralsCompanionMappingFileByPath(mappingPath, target)
-	if err != nil {
-		return result, fmt.Errorf("failed to read referrals companion mapping file %w", err)
-	}
+func loadCampaignConfigForTarget(embeddedMappings EmbeddedMappings, envVar CampaignEnvVar, compositeEnvVar CompositeEnvVar, options configOptions, campaignMappingFile MappingFile, target string) (Config, error) {
+	var result Config
+	mappingPath := envVar.Path
 
-	sources := []MappingFile{requiredMappingFile, defaultsMappingFile, campaignMappingFile}
-	if referralsCompanionFile.Length > 0 {
-		sources = append(sources, referralsCompanionFile)
+	sources, referralsCompanionFile, err := embeddedMappings.findCampaignMappingFilesForTarget(mappingPath, campaignMappingFile, target)
+	if err != nil {
+		return result, err
 	}
 
-	result, err = YAMLConfigUnmarshaler{CRMFieldMapper: crmFieldMapper}.Unmarshal(compositeEnvVar, sources...)
+	unmarshaler := YAMLConfigUnmarshaler{CRMFieldMapper: options.crmFieldMapper, SecretProvider: options.secretProvider}
+	result, err = unmarshaler.Unmarshal(compositeEnvVar, sources...)
 	if err != nil {
 		return result, fmt.Errorf("failed to load config %w", err)
 	}
@@ -258,3 +335,55 @@ func loadCampaignConfig(embeddedMappings EmbeddedMappings, envVar CampaignEnvVar
 
 	return result, nil
 }
+
+// campaignTargetMappingFiles is the merge order mapping files for one
+// target of a campaign.
+type campaignTargetMappingFiles struct {
+	target  string
+	sources []MappingFile
+}
+
+// findCampaignMappingFilesByTarget returns the merge order mapping files
+// for every target of a campaign, in the order the campaign mapping files
+// were found (as for loadCampaignConfigs).
+func (em EmbeddedMappings) findCampaignMappingFilesByTarget(mappingPath string) ([]campaignTargetMappingFiles, error) {
+	campaignMappingFiles, targets, err := em.FindCampaignMappingFilesWithTargetsByPath(mappingPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read campaign mapping file %w", err)
+	}
+	result := make([]campaignTargetMappingFiles, 0, len(targets))
+	for i, target := range targets {
+		sources, _, err := em.findCampaignMappingFilesForTarget(mappingPath, campaignMappingFiles[i], target)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, campaignTargetMappingFiles{target: target, sources: sources})
+	}
+	return result, nil
+}
+
+// findCampaignMappingFilesForTarget returns the merge order mapping files
+// for one target's campaign mapping file.
+func (em EmbeddedMappings) findCampaignMappingFilesForTarget(mappingPath string, campaignMappingFile MappingFile, target string) (sources []MappingFile, referralsCompanionFile MappingFile, err error) {
+	requiredMappingFile, err := em.MustFindRequiredMappingFileForTarget(target)
+	if err != nil {
+		return nil, referralsCompanionFile, fmt.Errorf("failed to read required mapping file %w", err)
+	}
+
+	defaultsMappingFile, err := em.MustFindDefaultsMappingFileForTarget(target)
+	if err != nil {
+		return nil, referralsCompanionFile, fmt.Errorf("failed to read defaults mapping file %w", err)
+	}
+
+	// Optional referrals companion file (Raisely Custom Messages mapping)
+	referralsCompanionFile, err = em.FindReferralsCompanionMappingFileByPath(mappingPath, target)
+	if err != nil {
+		return nil, referralsCompanionFile, fmt.Errorf("failed to read referrals companion mapping file %w", err)
+	}
+
+	sources = []MappingFile{requiredMappingFile, defaultsMappingFile, campaignMappingFile}
+	if referralsCompanionFile.Length > 0 {
+		sources = append(sources, referralsCompanionFile)
+	}
+	return sources, referralsCompanionFile, nil
+}
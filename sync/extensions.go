@@ -3,6 +3,7 @@ package sync
 import (
 	"fmt"
 	"log"
+	"math"
 	"slices"
 	"strconv"
 	"strings"
@@ -183,6 +184,122 @@ func AddMissingDaysForStreak(max int, days []int, value string) string {
 	return result
 }
 
+// MilestoneDateFormat is the format of the date recorded with each
+// awarded milestone.
+const MilestoneDateFormat = "2006-01-02"
+
+// CurrentMilestones parses awarded milestones, stored as
+// "<threshold>@<date>" entries joined by "|" (e.g.
+// "1000@2026-03-01|5000@2026-03-09"), returning the date each threshold
+// was reached ("" if it has no date).
+func CurrentMilestones(value string) map[int64]string {
+	result := make(map[int64]string)
+	if value == "" {
+		return result
+	}
+	for _, s := range strings.Split(value, "|") {
+		threshold, date, _ := strings.Cut(s, "@")
+		i, err := strconv.ParseInt(threshold, 10, 64)
+		if err == nil {
+			result[i] = date
+		}
+	}
+	return result
+}
+
+// AddMissingMilestones appends to value each threshold that current has
+// reached but that is not already awarded, dated date. Awarded
+// milestones are never removed, even if current later drops below them,
+// so each is only ever awarded once.
+func AddMissingMilestones(current int64, thresholds []int64, value string, date string) string {
+	awarded := CurrentMilestones(value)
+	sorted := slices.Clone(thresholds)
+	slices.Sort(sorted)
+	result := value
+	for _, threshold := range slices.Compact(sorted) {
+		if _, exists := awarded[threshold]; exists || current < threshold {
+			continue
+		}
+		entry := fmt.Sprintf("%d@%s", threshold, date)
+		if result == "" {
+			result = entry
+		} else {
+			result = fmt.Sprintf("%s|%s", result, entry)
+		}
+	}
+	return result
+}
+
+// DonorCount returns the number of distinct donors with a positive
+// donation, identified by user (or by donation when there is no user).
+func DonorCount(donations []Donation) int64 {
+	donors := make(map[string]bool)
+	for _, d := range donations {
+		if d.Amount <= 0 {
+			continue
+		}
+		if d.User.Uuid != "" {
+			donors["user:"+d.User.Uuid] = true
+		} else {
+			donors["donation:"+d.UUID] = true
+		}
+	}
+	return int64(len(donors))
+}
+
+// milestoneDate returns the date to record for milestones reached by this
+// event: the event's date, or today (UTC) if it has none.
+func (e FundraiserExtensions) milestoneDate() string {
+	if e.EventCreatedAt != "" {
+		t, err := time.Parse(time.RFC3339, e.EventCreatedAt)
+		if err == nil {
+			return t.UTC().Format(MilestoneDateFormat)
+		}
+		log.Printf("Warning: milestones extension using today's date — failed to parse eventCreatedAt %q: %v", e.EventCreatedAt, err)
+	}
+	return time.Now().UTC().Format(MilestoneDateFormat)
+}
+
+// AddMilestones awards the configured total, exercise total and donor
+// milestones the page has newly reached (see AddMissingMilestones).
+func AddMilestones(extensions FundraiserExtensions, donations []Donation, json string) (string, error) {
+	var err error
+	result := json
+	milestones := extensions.Config.Milestones
+
+	date := ""
+	for _, m := range []struct {
+		milestone Milestone
+		value     func() int64
+	}{
+		{milestones.Total, func() int64 {
+			total, _ := extensions.Page.Source.IntForPath("total")
+			return total
+		}},
+		{milestones.ExerciseTotal, func() int64 {
+			exerciseTotal, _ := extensions.Page.Source.IntForPath("exerciseTotal")
+			return exerciseTotal
+		}},
+		{milestones.Donors, func() int64 { return DonorCount(donations) }},
+	} {
+		if !m.milestone.IsConfigured() {
+			continue
+		}
+		if date == "" {
+			date = extensions.milestoneDate()
+		}
+		currentValue, _ := extensions.Page.Source.StringForPath(m.milestone.Mapping)
+		newValue := AddMissingMilestones(m.value(), m.milestone.Thresholds, currentValue, date)
+		if currentValue != newValue {
+			result, err = sjson.Set(result, "data."+m.milestone.Mapping, newValue)
+			if err != nil {
+				return result, err
+			}
+		}
+	}
+	return result, nil
+}
+
 func AddTotalInWindow(extensions FundraiserExtensions, json string) (string, error) {
 	if !extensions.Config.TotalInWindow.IsConfigured() {
 		return json, nil
@@ -309,6 +426,12 @@ func ApplyRaiselyFundraiserExtensions(extensions FundraiserExtensions, exerciseL
 
 	// total in window
 	result, err = AddTotalInWindow(extensions, result)
+	if err != nil {
+		return result, err
+	}
+
+	// milestones
+	result, err = AddMilestones(extensions, donations, result)
 	return result, err
 
 }
@@ -370,3 +493,77 @@ func AddSplitExerciseTotals(campaign *FundraisingCampaign, page FundraisingPage,
 
 	return result, nil
 }
+
+// LeaderboardEntry is a profile's value on a [Leaderboard], with the rank
+// and percentile already written to it (if any).
+type LeaderboardEntry struct {
+	P2PID         string
+	Value         int64
+	Rank          int64
+	HasRank       bool
+	Percentile    int64
+	HasPercentile bool
+}
+
+// NewLeaderboardEntry reads a profile's leaderboard value and current
+// rank and percentile.
+func NewLeaderboardEntry(leaderboard Leaderboard, profile FundraisingProfile) LeaderboardEntry {
+	entry := LeaderboardEntry{P2PID: profile.P2PID}
+	entry.Value, _ = profile.Source.IntForPath(leaderboard.ByPath())
+	if leaderboard.RankMapping != "" {
+		entry.Rank, entry.HasRank = profile.Source.IntForPath(leaderboard.RankMapping)
+	}
+	if leaderboard.PercentileMapping != "" {
+		entry.Percentile, entry.HasPercentile = profile.Source.IntForPath(leaderboard.PercentileMapping)
+	}
+	return entry
+}
+
+// ApplyRaiselyLeaderboardExtension ranks entries by value (1 = highest;
+// ties share a rank) and returns an UpdateRaiselyDataRequest for each
+// profile whose rank or percentile differs from what is already stored,
+// so a settled leaderboard writes nothing.
+func ApplyRaiselyLeaderboardExtension(leaderboard Leaderboard, entries []LeaderboardEntry) ([]UpdateRaiselyDataRequest, error) {
+	var result []UpdateRaiselyDataRequest
+	if !leaderboard.IsConfigured() || len(entries) == 0 {
+		return result, nil
+	}
+
+	sorted := slices.Clone(entries)
+	slices.SortStableFunc(sorted, func(a, b LeaderboardEntry) int {
+		switch {
+		case a.Value > b.Value:
+			return -1
+		case a.Value < b.Value:
+			return 1
+		}
+		return 0
+	})
+
+	var rank int64
+	for i, entry := range sorted {
+		if i == 0 || entry.Value != sorted[i-1].Value {
+			rank = int64(i + 1)
+		}
+		percentile := int64(math.Ceil(float64(rank) * 100 / float64(len(sorted))))
+
+		var json string
+		var err error
+		if leaderboard.RankMapping != "" && (!entry.HasRank || entry.Rank != rank) {
+			json, err = sjson.Set(json, "data."+leaderboard.RankMapping, rank)
+			if err != nil {
+				return result, err
+			}
+		}
+		if leaderboard.PercentileMapping != "" && (!entry.HasPercentile || entry.Percentile != percentile) {
+			json, err = sjson.Set(json, "data."+leaderboard.PercentileMapping, percentile)
+			if err != nil {
+				return result, err
+			}
+		}
+		if json != "" {
+			result = append(result, UpdateRaiselyDataRequest{P2PID: entry.P2PID, JSON: json})
+		}
+	}
+	return result, nil
+}
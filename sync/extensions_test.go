@@ -3,6 +3,9 @@ package sync
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
 	"testing"
 	"time"
 
@@ -396,3 +399,169 @@ func TestAddTotalInWindow(t *testing.T) {
 		}
 	})
 }
+
+func TestApplyRaiselyLeaderboardExtension(t *testing.T) {
+	t.Parallel()
+	leaderboard := Leaderboard{RankMapping: "public.rank", PercentileMapping: "public.topPercent"}
+	entry := func(id string, value int64) LeaderboardEntry {
+		return LeaderboardEntry{P2PID: id, Value: value}
+	}
+
+	t.Run("ranks with ties", func(t *testing.T) {
+		t.Parallel()
+		result, err := ApplyRaiselyLeaderboardExtension(leaderboard, []LeaderboardEntry{
+			entry("a", 100), entry("b", 300), entry("c", 100), entry("d", 0),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]string{
+			"b": `{"data":{"public":{"rank":1,"topPercent":25}}}`,
+			"a": `{"data":{"public":{"rank":2,"topPercent":50}}}`,
+			"c": `{"data":{"public":{"rank":2,"topPercent":50}}}`,
+			"d": `{"data":{"public":{"rank":4,"topPercent":100}}}`,
+		}
+		if len(result) != len(want) {
+			t.Fatalf("got %d requests, want %d: %+v", len(result), len(want), result)
+		}
+		for _, r := range result {
+			if r.JSON != want[r.P2PID] {
+				t.Errorf("%s: got %s, want %s", r.P2PID, r.JSON, want[r.P2PID])
+			}
+		}
+	})
+
+	t.Run("only writes changes", func(t *testing.T) {
+		t.Parallel()
+		settled := LeaderboardEntry{P2PID: "a", Value: 300, Rank: 1, HasRank: true, Percentile: 50, HasPercentile: true}
+		moved := LeaderboardEntry{P2PID: "b", Value: 100, Rank: 1, HasRank: true, Percentile: 100, HasPercentile: true}
+		result, err := ApplyRaiselyLeaderboardExtension(leaderboard, []LeaderboardEntry{settled, moved})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result) != 1 || result[0].P2PID != "b" || result[0].JSON != `{"data":{"public":{"rank":2}}}` {
+			t.Errorf("expected only b's rank to be written, got %+v", result)
+		}
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		t.Parallel()
+		result, err := ApplyRaiselyLeaderboardExtension(Leaderboard{By: "total"}, []LeaderboardEntry{entry("a", 1)})
+		if err != nil || len(result) != 0 {
+			t.Errorf("expected no requests, got %+v, %v", result, err)
+		}
+	})
+}
+
+func TestRaiselyExtensionsMapper_MapCampaignForLeaderboard(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/campaigns/test-campaign/profiles" || r.URL.Query().Get("private") != "true" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[
+			{"uuid":"campaign-profile","type":"CAMPAIGN","status":"ACTIVE","total":9000,"updatedAt":"2026-03-01T00:00:00Z"},
+			{"uuid":"p-1","type":"INDIVIDUAL","status":"ACTIVE","total":100,"exerciseTotal":5000,"private":{"rank":2},"updatedAt":"2026-03-01T01:00:00Z"},
+			{"uuid":"p-2","type":"INDIVIDUAL","status":"ACTIVE","total":200,"exerciseTotal":1000,"private":{"rank":2},"updatedAt":"2026-03-01T02:00:00Z"},
+			{"uuid":"p-3","type":"INDIVIDUAL","status":"DRAFT","total":0,"exerciseTotal":9000,"updatedAt":"2026-03-01T03:00:00Z"},
+			{"uuid":"p-4","type":"INDIVIDUAL","status":"ARCHIVED","total":0,"exerciseTotal":8000,"updatedAt":"2026-03-01T04:00:00Z"},
+			{"uuid":"t-1","type":"GROUP","status":"ACTIVE","total":300,"updatedAt":"2026-03-01T05:00:00Z"},
+			{"uuid":"t-2","type":"GROUP","status":"INACTIVE","total":900,"updatedAt":"2026-03-01T06:00:00Z"}
+		]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	fetcher := newTestRaiselyAPIFetcher(srv.URL, "", "")
+	fetcher.Config.FundraiserExtensions.Leaderboard = Leaderboard{By: "exerciseTotal", RankMapping: "private.rank"}
+	fetcher.Config.TeamExtensions.Leaderboard = Leaderboard{PercentileMapping: "public.topPercent"}
+	mapper := RaiselyExtensionsMapper{SyncContext: fetcher.SyncContext, RaiselyFetcherAndUpdater: fetcher}
+
+	result, err := mapper.MapCampaignForLeaderboard(t.Context())
+	if err != nil {
+		t.Fatalf("MapCampaignForLeaderboard returned unexpected error: %v", err)
+	}
+	want := []UpdateRaiselyDataRequest{
+		{P2PID: "p-1", JSON: `{"data":{"private":{"rank":1}}}`},
+		{P2PID: "t-1", JSON: `{"data":{"public":{"topPercent":100}}}`},
+	}
+	if !slices.Equal(result, want) {
+		t.Errorf("got %+v, want %+v", result, want)
+	}
+}
+
+func TestAddMissingMilestones(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		current    int64
+		thresholds []int64
+		value      string
+		want       string
+	}{
+		{"none reached", 500, []int64{1000, 5000}, "", ""},
+		{"first reached", 1000, []int64{5000, 1000}, "", "1000@2026-03-09"},
+		{"several reached at once", 6000, []int64{1000, 5000, 10000}, "", "1000@2026-03-09|5000@2026-03-09"},
+		{"keeps earlier dates", 6000, []int64{1000, 5000}, "1000@2026-03-01", "1000@2026-03-01|5000@2026-03-09"},
+		{"never revoked", 0, []int64{1000, 5000}, "1000@2026-03-01|5000@2026-03-02", "1000@2026-03-01|5000@2026-03-02"},
+		{"undated award is kept", 1000, []int64{1000}, "1000", "1000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := AddMissingMilestones(tt.current, tt.thresholds, tt.value, "2026-03-09"); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDonorCount(t *testing.T) {
+	t.Parallel()
+	donation := func(uuid, user string, amount float64) Donation {
+		d := Donation{UUID: uuid, Amount: amount}
+		d.User.Uuid = user
+		return d
+	}
+	donations := []Donation{
+		donation("d-1", "u-1", 10),
+		donation("d-2", "u-1", 20),
+		donation("d-3", "u-2", 5),
+		donation("d-4", "", 15),
+		donation("d-5", "u-3", 0),
+	}
+	if got := DonorCount(donations); got != 3 {
+		t.Errorf("DonorCount = %d, want 3", got)
+	}
+}
+
+func TestAddMilestones(t *testing.T) {
+	t.Parallel()
+	var config FundraiserExtensionsConfig
+	config.Milestones.Total = Milestone{Thresholds: []int64{10000, 50000}, Mapping: "public.totalMilestones"}
+	config.Milestones.ExerciseTotal = Milestone{Thresholds: []int64{5000}, Mapping: "public.distanceMilestones"}
+	config.Milestones.Donors = Milestone{Thresholds: []int64{2, 10}, Mapping: "private.donorMilestones"}
+	extensions := FundraiserExtensions{
+		Config: config,
+		Page: FundraisingPage{Source: Source{data: gjson.Parse(
+			`{"total":60000,"exerciseTotal":1000,"public":{"totalMilestones":"10000@2026-03-01"}}`,
+		)}},
+		EventCreatedAt: "2026-03-09T23:30:00+01:00",
+	}
+	donations := []Donation{{UUID: "d-1", Amount: 10}, {UUID: "d-2", Amount: 10}}
+
+	result, err := AddMilestones(extensions, donations, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"data":{"public":{"totalMilestones":"10000@2026-03-01|50000@2026-03-09"},"private":{"donorMilestones":"2@2026-03-09"}}}`
+	if result != expected {
+		t.Errorf("expected %s but got %s", expected, result)
+	}
+
+	extensions.Page.Source = Source{data: gjson.Parse(`{"total":0,"public":{"totalMilestones":"10000@2026-03-01|50000@2026-03-09"},"private":{"donorMilestones":"2@2026-03-09"}}`)}
+	result, err = AddMilestones(extensions, donations, "")
+	if err != nil || result != "" {
+		t.Errorf("expected awarded milestones to be left alone, got %q, %v", result, err)
+	}
+}
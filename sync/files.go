@@ -73,22 +73,46 @@ func (em EmbeddedMappings) MustFindDefaultsMappingFileForTarget(target string) (
 // The path must be in the format "<org>/<label>" where <org> is the org directory
 // and <label> must exactly match the first dot-separated segment of the filename.
 // Filename format: <LABEL>[.<target>].yaml
-// Returns the mapping file, the target, and any error.
+// Returns the mapping file, the target, and any error. It is an error for
+// more than one target's file to exist; use
+// FindCampaignMappingFilesWithTargetsByPath for campaigns that sync to
+// several targets.
 func (em EmbeddedMappings) MustFindFirstCampaignMappingFileWithTargetByPath(mappingpath string) (result MappingFile, target string, err error) {
-	orgDir, fileLabel, err := ParseMappingPath(mappingpath)
+	results, targets, err := em.FindCampaignMappingFilesWithTargetsByPath(mappingpath)
 	if err != nil {
 		return result, target, err
 	}
+	// multiple matches are not supported here - guard against misconfiguration
+	if len(results) > 1 {
+		orgDir, _, _ := ParseMappingPath(mappingpath)
+		err = fmt.Errorf("found multiple mapping files with path: %s in dir: %s", mappingpath, path.Join(em.Root, orgDir))
+		return result, target, err
+	}
+	return results[0], targets[0], nil
+}
+
+// FindCampaignMappingFilesWithTargetsByPath finds every campaign mapping
+// file for a path, one per target, so e.g. LABEL.ortto-contacts.yaml and
+// LABEL.ortto-activities.yaml can run side by side while a campaign
+// migrates between targets. The path and filename formats are as for
+// MustFindFirstCampaignMappingFileWithTargetByPath. Files are returned in
+// directory order along with their targets. A legacy LABEL.yaml counts as
+// ortto-contacts, so it cannot be combined with LABEL.ortto-contacts.yaml.
+func (em EmbeddedMappings) FindCampaignMappingFilesWithTargetsByPath(mappingpath string) (results []MappingFile, targets []string, err error) {
+	orgDir, fileLabel, err := ParseMappingPath(mappingpath)
+	if err != nil {
+		return nil, nil, err
+	}
 	dir := path.Join(em.Root, orgDir)
 
-	var files []fs.DirEntry
-	files, err = em.Files.ReadDir(dir)
+	files, err := em.Files.ReadDir(dir)
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
-			return result, target, &MappingFileNotFoundError{Path: mappingpath, Dir: dir}
+			return nil, nil, &MappingFileNotFoundError{Path: mappingpath, Dir: dir}
 		}
-		return result, target, err
+		return nil, nil, err
 	}
+	seen := make(map[string]string) // effective target -> file name
 	for _, file := range files {
 		p := file.Name()
 		name := strings.TrimSuffix(p, ".yaml")
@@ -112,27 +136,32 @@ func (em EmbeddedMappings) MustFindFirstCampaignMappingFileWithTargetByPath(mapp
 			continue
 		}
 
-		// multiple matches are not supported - guard against misconfiguration
-		if result.Name != "" {
-			err = fmt.Errorf("found multiple mapping files with path: %s in dir: %s", mappingpath, dir)
-			return result, target, err
+		// one file per target - guard against misconfiguration
+		effectiveTarget := matchedTarget
+		if effectiveTarget == "" {
+			effectiveTarget = "ortto-contacts"
 		}
-
-		target = matchedTarget
+		if other, ok := seen[effectiveTarget]; ok {
+			return nil, nil, fmt.Errorf("found multiple mapping files for target %s with path: %s in dir: %s (%s and %s)", effectiveTarget, mappingpath, dir, other, p)
+		}
+		seen[effectiveTarget] = p
 
 		fullpath := path.Join(dir, p)
-		var campaignMappings []byte
-		campaignMappings, err = em.Files.ReadFile(fullpath)
-		if err == nil {
-			result.Name = fullpath
-			result.Reader = bytes.NewReader(campaignMappings)
-			result.Length = len(campaignMappings)
+		campaignMappings, err := em.Files.ReadFile(fullpath)
+		if err != nil {
+			return nil, nil, err
 		}
+		results = append(results, MappingFile{
+			Name:   fullpath,
+			Reader: bytes.NewReader(campaignMappings),
+			Length: len(campaignMappings),
+		})
+		targets = append(targets, matchedTarget)
 	}
-	if result.Name == "" {
-		err = &MappingFileNotFoundError{Path: mappingpath, Dir: dir}
+	if len(results) == 0 {
+		return nil, nil, &MappingFileNotFoundError{Path: mappingpath, Dir: dir}
 	}
-	return result, target, err
+	return results, targets, nil
 }
 
 // knownTargets defines the recognized target suffixes for mapping files
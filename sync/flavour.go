@@ -4,4 +4,5 @@ type Flavour int
 
 const (
 	Raisely2Ortto Flavour = iota
+	Funraisin2Ortto
 )
@@ -1,6 +1,9 @@
 package sync
 
-import "context"
+import (
+	"context"
+	"log"
+)
 
 // FundraisingCampaignCache is an optional cross-call cache for
 // [FundraisingCampaign] documents fetched from the Raisely API. The
@@ -51,3 +54,34 @@ type FundraisingCampaignCache interface {
 	// a non-nil err on backing-store failure.
 	Delete(ctx context.Context, p2pID string) error
 }
+
+// cachedFundraisingCampaign is the cache orchestration shared by
+// [RaiselyFetcherAndUpdater.CachedFundraisingCampaign] and
+// [FunraisinFetcher.CachedFundraisingCampaign]. fetch performs the
+// platform fetch; everything else (nil cache, refresh bypass, fail-open
+// Get, logged Set) follows the policy documented on
+// [FundraisingCampaignCache].
+func cachedFundraisingCampaign(cache FundraisingCampaignCache, p2pID, org string, refresh bool, fetch func() (*FundraisingCampaign, error), ctx context.Context) (*FundraisingCampaign, error) {
+	if cache == nil {
+		return fetch()
+	}
+
+	if !refresh {
+		hit, ok, err := cache.Get(ctx, p2pID)
+		if err != nil {
+			log.Printf("FundraisingCampaignCache.Get(%s): %v (treating as miss)", p2pID, err)
+		} else if ok {
+			return hit, nil
+		}
+	}
+
+	fresh, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cache.Set(ctx, p2pID, org, fresh); err != nil {
+		log.Printf("FundraisingCampaignCache.Set(%s): %v (continuing)", p2pID, err)
+	}
+	return fresh, nil
+}
@@ -0,0 +1,19 @@
+package sync
+
+import "context"
+
+// FundraisingDataFetcher is the read side of a fundraising platform as
+// used by [Service] to map a profile: the campaign, a single page, a
+// fundraiser with their activity/donation history, and a team with its
+// members. [RaiselyFetcherAndUpdater] implements it for the Raisely2Ortto
+// flavour and [FunraisinFetcher] for the Funraisin2Ortto flavour.
+//
+// Write-back and Raisely-only operations (webhooks, custom messages,
+// referrals) are not part of the interface and remain on
+// [RaiselyFetcherAndUpdater].
+type FundraisingDataFetcher interface {
+	CachedFundraisingCampaign(p2pID string, refresh bool, ctx context.Context) (*FundraisingCampaign, error)
+	FetchFundraisingPage(p2pID string, ctx context.Context) (FundraisingPage, error)
+	FetchFundraiserData(p2pID string, ctx context.Context) (FundraiserData, error)
+	FetchTeamData(p2pTeamID string, ctx context.Context) (TeamData, error)
+}
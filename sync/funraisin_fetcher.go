@@ -0,0 +1,341 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	gosync "sync"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// FunraisinTimestampFormat is the layout Funraisin uses for its
+// date_created / date_paid / activity_date fields. Funraisin timestamps
+// carry no zone and are treated as UTC.
+const FunraisinTimestampFormat = "2006-01-02 15:04:05"
+
+// FunraisinListLimit is the page size used to walk Funraisin list
+// endpoints (member activities and donations, team members).
+const FunraisinListLimit = "100"
+
+type FunraisinError map[string]interface{}
+
+// FunraisinFetcher handles fetching data from the Funraisin API for the
+// Funraisin2Ortto flavour. It embeds *SyncContext for shared sync
+// configuration and returns the same [FundraisingPage], [FundraisingTeam],
+// [FundraiserData], [TeamData] and [FundraisingCampaign] types as
+// [RaiselyFetcherAndUpdater], so the Ortto mappers work unchanged.
+//
+// Funraisin member and team records are kept verbatim (mapping files
+// address them with Funraisin's own snake_case paths, e.g.
+// `total_raised|@currency:FUNRAISIN_DECIMAL`), with a small set of
+// Raisely-shaped keys added alongside so the flavour-neutral code paths
+// (team detection, isCaptain, extensions) resolve:
+//
+//   - uuid          — member_id / team_id
+//   - type          — "INDIVIDUAL" or "GROUP"
+//   - parent.uuid   — team_id (members of a team only)
+//   - parent.type   — "GROUP" (members of a team only)
+//   - user.uuid     — user_id
+//   - total         — total_raised in the smallest currency unit (cents)
+//   - exerciseTotal — total_distance in metres
+//   - createdAt     — date_created as RFC3339
+//
+// Any existing key with one of these names is replaced.
+type FunraisinFetcher struct {
+	*SyncContext
+
+	// FundraisingCampaignCache supplies cross-call cache state for
+	// CachedFundraisingCampaign, as for [RaiselyFetcherAndUpdater]; nil
+	// disables caching.
+	FundraisingCampaignCache FundraisingCampaignCache
+}
+
+// FunraisinAPIKey returns the Funraisin API key from the config.
+func (f *FunraisinFetcher) FunraisinAPIKey() string {
+	return f.Config.API.Keys.Funraisin
+}
+
+// FunraisinAPIBuilder returns a new requests.Builder configured for the
+// Funraisin API. Carries the same UA + per-call attribution log line as
+// [RaiselyFetcherAndUpdater.RaiselyAPIBuilder] (`Funraisin call: …`).
+func (f *FunraisinFetcher) FunraisinAPIBuilder() *requests.Builder {
+	apiBuilder := requests.
+		URL(f.Config.API.Endpoints.Funraisin).
+		UserAgent(f.userAgent()).
+		Client(&http.Client{Timeout: HTTPRequestTimeout}).
+		AddValidator(funraisinCallValidator(f.TriggerType))
+	var transport http.RoundTripper
+	if f.RecordRequests {
+		transport = requests.Record(nil, fmt.Sprintf("pkg/testdata/.requests/%s/funraisin", f.Campaign))
+	}
+	return f.apiTransport(apiBuilder, transport)
+}
+
+// funraisinCallValidator emits the per-call attribution log line for
+// every Funraisin request. Non-2xx responses fall through to the
+// caller's `.ErrorJSON(…)` chain.
+func funraisinCallValidator(triggerType string) requests.ResponseHandler {
+	return func(resp *http.Response) error {
+		logAPICall("Funraisin", triggerType, resp)
+		return nil
+	}
+}
+
+// fetchFunraisinData GETs path and returns the "data" element of the
+// response envelope.
+func (f *FunraisinFetcher) fetchFunraisinData(path string, ctx context.Context) (gjson.Result, error) {
+	return f.fetchFunraisin(f.FunraisinAPIBuilder().Path(path), ctx)
+}
+
+// fetchFunraisinList pages through a Funraisin list endpoint using
+// limit/offset until a short page is returned, and returns every entry.
+func (f *FunraisinFetcher) fetchFunraisinList(path string, ctx context.Context) ([]gjson.Result, error) {
+	pageSize, _ := strconv.Atoi(FunraisinListLimit)
+	var result []gjson.Result
+
+	for offset := 0; ; {
+		builder := f.FunraisinAPIBuilder().
+			Path(path).
+			Param("limit", FunraisinListLimit)
+		if offset > 0 {
+			builder = builder.Param("offset", strconv.Itoa(offset))
+		}
+		data, err := f.fetchFunraisin(builder, ctx)
+		if err != nil {
+			return result, err
+		}
+		page := data.Array()
+		result = append(result, page...)
+		if len(page) < pageSize {
+			return result, nil
+		}
+		offset += len(page)
+	}
+}
+
+// fetchFunraisin sends builder's request and returns the "data" element
+// of the response envelope.
+func (f *FunraisinFetcher) fetchFunraisin(builder *requests.Builder, ctx context.Context) (gjson.Result, error) {
+	funraisinError := FunraisinError{}
+	var json string
+	err := builder.
+		Bearer(f.FunraisinAPIKey()).
+		ToString(&json).
+		ErrorJSON(&funraisinError).
+		Fetch(ctx)
+	if err != nil {
+		log.Printf("Funraisin Error: %+v", funraisinError)
+		return gjson.Result{}, err
+	}
+	if !gjson.Valid(json) {
+		log.Printf("Invalid Funraisin Response:\n%s", json)
+		return gjson.Result{}, errors.New("invalid json response")
+	}
+	return gjson.Parse(json).Get("data"), nil
+}
+
+// FetchFundraisingCampaign fetches the event data from Funraisin.
+// Funraisin events have no campaign-level profile, so Profile.P2PID is
+// left empty.
+func (f *FunraisinFetcher) FetchFundraisingCampaign(p2pID string, ctx context.Context) (*FundraisingCampaign, error) {
+	data, err := f.fetchFunraisinData(fmt.Sprintf("/v1/events/%s", p2pID), ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &FundraisingCampaign{Name: data.Get("event_name").String()}, nil
+}
+
+// CachedFundraisingCampaign returns the event data for p2pID, consulting
+// the configured [FundraisingCampaignCache] when present. See
+// [RaiselyFetcherAndUpdater.CachedFundraisingCampaign] for the policy.
+func (f *FunraisinFetcher) CachedFundraisingCampaign(p2pID string, refresh bool, ctx context.Context) (*FundraisingCampaign, error) {
+	return cachedFundraisingCampaign(f.FundraisingCampaignCache, p2pID, f.Config.EnvVar.Org(), refresh, func() (*FundraisingCampaign, error) {
+		return f.FetchFundraisingCampaign(p2pID, ctx)
+	}, ctx)
+}
+
+// FetchFundraisingPage fetches a member record and normalises it into a FundraisingPage.
+func (f *FunraisinFetcher) FetchFundraisingPage(p2pID string, ctx context.Context) (FundraisingPage, error) {
+	data, err := f.fetchFunraisinData(fmt.Sprintf("/v1/members/%s", p2pID), ctx)
+	if err != nil {
+		return FundraisingPage{}, err
+	}
+	return funraisinFundraisingPage(data, "INDIVIDUAL", "member_id")
+}
+
+// FetchFundraiserData fetches a member record and optionally their
+// activities and donations, paging through the full history of each.
+func (f *FunraisinFetcher) FetchFundraiserData(p2pID string, ctx context.Context) (FundraiserData, error) {
+	var result FundraiserData
+	var wg gosync.WaitGroup
+	var errPage, errLogs, errDonations error
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		result.Page, errPage = f.FetchFundraisingPage(p2pID, ctx)
+	}()
+
+	needs := f.fetchNeeds()
+	if needs.activityLogs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var data []gjson.Result
+			data, errLogs = f.fetchFunraisinList(fmt.Sprintf("/v1/members/%s/activities", p2pID), ctx)
+			for _, v := range data {
+				result.ExerciseLogs.ExerciseLogs = append(result.ExerciseLogs.ExerciseLogs, ExerciseLogEntry{
+					Activity: v.Get("activity_type").String(),
+					Date:     funraisinTimestamp(v.Get("activity_date").String()),
+					Distance: math.Round(v.Get("distance").Float() * 1000), // km → metres
+				})
+			}
+		}()
+	}
+
+	if needs.donations {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var data []gjson.Result
+			data, errDonations = f.fetchFunraisinList(fmt.Sprintf("/v1/members/%s/donations", p2pID), ctx)
+			for _, v := range data {
+				var donation Donation
+				donation.User.Uuid = v.Get("user_id").String()
+				donation.CreatedAt = funraisinTimestamp(v.Get("date_paid").String())
+				donation.Date = donation.CreatedAt
+				donation.Type = "ONLINE"
+				if v.Get("payment_type").String() == "offline" {
+					donation.Type = "OFFLINE"
+				}
+				donation.Amount = math.Round(v.Get("amount").Float() * 100) // dollars → cents
+				result.Donations.Donations = append(result.Donations.Donations, donation)
+			}
+		}()
+	}
+
+	wg.Wait()
+	if err := errors.Join(errPage, errLogs, errDonations); err != nil {
+		return result, fmt.Errorf("funraisin errors: %w", err)
+	}
+
+	return result, nil
+}
+
+// FetchTeamData fetches a team, its fundraising page, and all member pages.
+// Funraisin's team members endpoint returns full member records, so no
+// per-member fetch is needed.
+func (f *FunraisinFetcher) FetchTeamData(p2pTeamID string, ctx context.Context) (TeamData, error) {
+	var result TeamData
+	var wg gosync.WaitGroup
+	var teamData gjson.Result
+	var membersData []gjson.Result
+	var errTeam, errMembers error
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		teamData, errTeam = f.fetchFunraisinData(fmt.Sprintf("/v1/teams/%s", p2pTeamID), ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		membersData, errMembers = f.fetchFunraisinList(fmt.Sprintf("/v1/teams/%s/members", p2pTeamID), ctx)
+	}()
+	wg.Wait()
+
+	if err := errors.Join(errTeam, errMembers); err != nil {
+		return result, fmt.Errorf("funraisin errors: %w", err)
+	}
+
+	teamPage, err := funraisinFundraisingPage(teamData, "GROUP", "team_id")
+	if err != nil {
+		return result, err
+	}
+	result.TeamPage = teamPage
+
+	for _, m := range membersData {
+		page, err := funraisinFundraisingPage(m, "INDIVIDUAL", "member_id")
+		if err != nil {
+			return result, err
+		}
+		p2pID, _ := page.Source.StringForPath("uuid")
+		result.Team.TeamMembers = append(result.Team.TeamMembers, TeamMember{P2PID: p2pID})
+		result.MemberPages = append(result.MemberPages, page)
+	}
+
+	return result, nil
+}
+
+// funraisinFundraisingPage adds the Raisely-shaped keys documented on
+// [FunraisinFetcher] to a Funraisin member or team record.
+func funraisinFundraisingPage(data gjson.Result, profileType string, idKey string) (FundraisingPage, error) {
+	if !data.IsObject() {
+		return FundraisingPage{}, errors.New("funraisin response is missing data")
+	}
+
+	json := data.Raw
+	var err error
+	set := func(path string, value interface{}) {
+		if err == nil {
+			json, err = sjson.Set(json, path, value)
+		}
+	}
+
+	set("uuid", data.Get(idKey).String())
+	set("type", profileType)
+	if team := data.Get("team_id").String(); profileType == "INDIVIDUAL" && team != "" && team != "0" {
+		set("parent.uuid", team)
+		set("parent.type", "GROUP")
+	}
+	if user := data.Get("user_id"); user.Exists() {
+		set("user.uuid", user.String())
+	}
+	if total := data.Get("total_raised"); total.Exists() {
+		set("total", int64(math.Round(total.Float()*100)))
+	}
+	if distance := data.Get("total_distance"); distance.Exists() {
+		set("exerciseTotal", int64(math.Round(distance.Float()*1000)))
+	}
+	if created := funraisinTimestamp(data.Get("date_created").String()); created != "" {
+		set("createdAt", created)
+	}
+	if err != nil {
+		return FundraisingPage{}, fmt.Errorf("failed to normalise funraisin %s: %w", idKey, err)
+	}
+
+	return FundraisingPage{Source: Source{data: gjson.Parse(json)}}, nil
+}
+
+// funraisinTimestamp converts a Funraisin timestamp to RFC3339, returning
+// "" if it cannot be parsed.
+func funraisinTimestamp(s string) string {
+	t, err := time.ParseInLocation(FunraisinTimestampFormat, s, time.UTC)
+	if err != nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// funraisinTimestampToOrtto backs the @timestamp:FUNRAISIN modifier.
+func funraisinTimestampToOrtto(res gjson.Result) string {
+	ts := funraisinTimestamp(res.String())
+	if ts == "" {
+		return ""
+	}
+	return fmt.Sprintf(`"%s"`, ts)
+}
+
+// funraisinDecimalToOrtto backs the @currency:FUNRAISIN_DECIMAL and
+// @distance:FUNRAISIN_KM modifiers: Funraisin stores both as decimals in
+// the larger unit and ortto expects decimals multiplied by 1000.
+func funraisinDecimalToOrtto(res gjson.Result) string {
+	return fmt.Sprintf("%d", int64(math.Round(res.Float()*1000)))
+}
@@ -0,0 +1,220 @@
+package sync
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+// newTestFunraisinServer serves canned Funraisin responses keyed by
+// request path and records the Authorization header of the last call.
+func newTestFunraisinServer(t *testing.T, responses map[string]string) (*httptest.Server, *string) {
+	t.Helper()
+	var gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &gotAuth
+}
+
+func newTestFunraisinFetcher(endpoint string) *FunraisinFetcher {
+	config := Config{}
+	config.API.Keys.Funraisin = "test-key"
+	config.API.Endpoints.Funraisin = endpoint
+	return &FunraisinFetcher{SyncContext: &SyncContext{Config: config, Campaign: "test-event"}}
+}
+
+func TestFunraisinFetcher_FetchFundraisingPage(t *testing.T) {
+	t.Parallel()
+	srv, gotAuth := newTestFunraisinServer(t, map[string]string{
+		"/v1/members/123": `{"data":{"member_id":123,"user_id":"u-1","team_id":"45","first_name":"Ada","total_raised":"12.50","total_distance":"3.2","date_created":"2026-03-01 09:30:00"}}`,
+	})
+
+	page, err := newTestFunraisinFetcher(srv.URL).FetchFundraisingPage("123", t.Context())
+	if err != nil {
+		t.Fatalf("FetchFundraisingPage returned unexpected error: %v", err)
+	}
+	if *gotAuth != "Bearer test-key" {
+		t.Errorf("Authorization = %q, want %q", *gotAuth, "Bearer test-key")
+	}
+
+	paths := map[string]string{
+		"uuid":        "123",
+		"type":        "INDIVIDUAL",
+		"parent.uuid": "45",
+		"parent.type": "GROUP",
+		"user.uuid":   "u-1",
+		"createdAt":   "2026-03-01T09:30:00Z",
+		"first_name":  "Ada",
+	}
+	for path, want := range paths {
+		if got, _ := page.Source.StringForPath(path); got != want {
+			t.Errorf("%s = %q, want %q", path, got, want)
+		}
+	}
+	if got, _ := page.Source.IntForPath("total"); got != 1250 {
+		t.Errorf("total = %d, want 1250", got)
+	}
+	if got, _ := page.Source.IntForPath("exerciseTotal"); got != 3200 {
+		t.Errorf("exerciseTotal = %d, want 3200", got)
+	}
+}
+
+func TestFunraisinFetcher_FetchFundraisingPage_NoTeam(t *testing.T) {
+	t.Parallel()
+	srv, _ := newTestFunraisinServer(t, map[string]string{
+		"/v1/members/123": `{"data":{"member_id":123,"team_id":"0"}}`,
+	})
+
+	page, err := newTestFunraisinFetcher(srv.URL).FetchFundraisingPage("123", t.Context())
+	if err != nil {
+		t.Fatalf("FetchFundraisingPage returned unexpected error: %v", err)
+	}
+	if _, ok := page.Source.StringForPath("parent.type"); ok {
+		t.Error("parent.type should not be set for a member without a team")
+	}
+}
+
+func TestFunraisinFetcher_FetchFundraiserData(t *testing.T) {
+	t.Parallel()
+	srv, _ := newTestFunraisinServer(t, map[string]string{
+		"/v1/members/123":            `{"data":{"member_id":123}}`,
+		"/v1/members/123/activities": `{"data":[{"activity_type":"run","activity_date":"2026-03-02 07:00:00","distance":"5.25"}]}`,
+		"/v1/members/123/donations":  `{"data":[{"user_id":"d-1","amount":"20","date_paid":"2026-03-03 10:00:00","payment_type":"offline"}]}`,
+	})
+
+	fetcher := newTestFunraisinFetcher(srv.URL)
+	fetcher.Config.FundraiserExtensions.Streaks.Activity.Days = []int{3}
+	fetcher.Config.FundraiserExtensions.Streaks.Donation.Days = []int{3}
+
+	data, err := fetcher.FetchFundraiserData("123", t.Context())
+	if err != nil {
+		t.Fatalf("FetchFundraiserData returned unexpected error: %v", err)
+	}
+
+	if len(data.ExerciseLogs.ExerciseLogs) != 1 {
+		t.Fatalf("expected 1 exercise log, got %d", len(data.ExerciseLogs.ExerciseLogs))
+	}
+	entry := data.ExerciseLogs.ExerciseLogs[0]
+	if entry.Activity != "run" || entry.Date != "2026-03-02T07:00:00Z" || entry.Distance != 5250 {
+		t.Errorf("unexpected exercise log %+v", entry)
+	}
+
+	if len(data.Donations.Donations) != 1 {
+		t.Fatalf("expected 1 donation, got %d", len(data.Donations.Donations))
+	}
+	donation := data.Donations.Donations[0]
+	if donation.User.Uuid != "d-1" || donation.Type != "OFFLINE" || donation.Amount != 2000 || donation.TimestampForStreak() != "2026-03-03T10:00:00Z" {
+		t.Errorf("unexpected donation %+v", donation)
+	}
+}
+
+func TestFunraisinFetcher_FetchFundraiserData_Paginates(t *testing.T) {
+	t.Parallel()
+	pageSize, _ := strconv.Atoi(FunraisinListLimit)
+	total := pageSize + 5
+	var offsets []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/members/123" {
+			_, _ = w.Write([]byte(`{"data":{"member_id":123}}`))
+			return
+		}
+		offsets = append(offsets, r.URL.Query().Get("offset"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		var entries []string
+		for i := offset; i < total && i < offset+pageSize; i++ {
+			entries = append(entries, fmt.Sprintf(`{"activity_type":"run","activity_date":"2026-03-02 07:00:00","distance":"%d"}`, i))
+		}
+		_, _ = fmt.Fprintf(w, `{"data":[%s]}`, strings.Join(entries, ","))
+	}))
+	t.Cleanup(srv.Close)
+
+	fetcher := newTestFunraisinFetcher(srv.URL)
+	fetcher.Config.FundraiserExtensions.Streaks.Activity.Days = []int{3}
+
+	data, err := fetcher.FetchFundraiserData("123", t.Context())
+	if err != nil {
+		t.Fatalf("FetchFundraiserData returned unexpected error: %v", err)
+	}
+	if len(data.ExerciseLogs.ExerciseLogs) != total {
+		t.Errorf("expected %d exercise logs, got %d", total, len(data.ExerciseLogs.ExerciseLogs))
+	}
+	if want := []string{"", strconv.Itoa(pageSize)}; !slices.Equal(offsets, want) {
+		t.Errorf("offsets = %q, want %q", offsets, want)
+	}
+}
+
+func TestFunraisinFetcher_FetchTeamData(t *testing.T) {
+	t.Parallel()
+	srv, _ := newTestFunraisinServer(t, map[string]string{
+		"/v1/teams/45":         `{"data":{"team_id":45,"user_id":"u-1","team_name":"Fast"}}`,
+		"/v1/teams/45/members": `{"data":[{"member_id":1,"user_id":"u-1","team_id":45},{"member_id":2,"user_id":"u-2","team_id":45}]}`,
+	})
+
+	data, err := newTestFunraisinFetcher(srv.URL).FetchTeamData("45", t.Context())
+	if err != nil {
+		t.Fatalf("FetchTeamData returned unexpected error: %v", err)
+	}
+
+	if got, _ := data.TeamPage.Source.StringForPath("type"); got != "GROUP" {
+		t.Errorf("team type = %q, want GROUP", got)
+	}
+	if _, ok := data.TeamPage.Source.StringForPath("parent.uuid"); ok {
+		t.Error("team page should not have a parent")
+	}
+	if len(data.MemberPages) != 2 || len(data.Team.TeamMembers) != 2 {
+		t.Fatalf("expected 2 members, got %d pages / %d members", len(data.MemberPages), len(data.Team.TeamMembers))
+	}
+	if data.Team.TeamMembers[1].P2PID != "2" {
+		t.Errorf("TeamMembers[1] = %q, want 2", data.Team.TeamMembers[1].P2PID)
+	}
+	captain, err := data.MemberPages[0].HasSameOwnerAs(data.TeamPage)
+	if err != nil || !captain {
+		t.Errorf("expected member 1 to own the team page (captain=%v, err=%v)", captain, err)
+	}
+}
+
+func TestFunraisinFetcher_FetchError(t *testing.T) {
+	t.Parallel()
+	srv, _ := newTestFunraisinServer(t, map[string]string{})
+
+	if _, err := newTestFunraisinFetcher(srv.URL).FetchFundraisingPage("404", t.Context()); err == nil {
+		t.Fatal("expected an error for a missing member")
+	}
+}
+
+func TestFunraisinModifierConversions(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"currency string", funraisinDecimalToOrtto(gjson.Parse(`"12.50"`)), "12500"},
+		{"currency number", funraisinDecimalToOrtto(gjson.Parse(`7`)), "7000"},
+		{"distance", funraisinDecimalToOrtto(gjson.Parse(`"3.2"`)), "3200"},
+		{"timestamp", funraisinTimestampToOrtto(gjson.Parse(`"2026-03-01 09:30:00"`)), `"2026-03-01T09:30:00Z"`},
+		{"invalid timestamp", funraisinTimestampToOrtto(gjson.Parse(`"yesterday"`)), ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.got != tc.want {
+				t.Errorf("got %s, want %s", tc.got, tc.want)
+			}
+		})
+	}
+}
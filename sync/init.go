@@ -1,7 +1,7 @@
 package sync
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/url"
@@ -19,34 +19,95 @@ import (
 // A nil value means Init has not been called.
 var initialisedFlavour *Flavour
 
-// mustBeInitialised panics if Init has not been called.
-// This should be called at the entry points of the library
-// to catch programming errors early.
-func mustBeInitialised() Flavour {
+// ErrNotInitialised is returned by the entry points of the library when
+// Init has not been called.
+var ErrNotInitialised = errors.New("sync: Init() must be called before using this package")
+
+// initialised returns the flavour set by Init, or ErrNotInitialised.
+// This should be called at the entry points of the library to catch
+// programming errors early.
+func initialised() (Flavour, error) {
 	if initialisedFlavour == nil {
-		panic("sync: Init() must be called before using this package")
+		return 0, ErrNotInitialised
 	}
-	return *initialisedFlavour
+	return *initialisedFlavour, nil
+}
+
+// isInitialisedAs reports whether Init has been called with flavour.
+func isInitialisedAs(flavour Flavour) bool {
+	return initialisedFlavour != nil && *initialisedFlavour == flavour
 }
 
 // GetInitialisedFlavour returns the flavour set by Init.
 // Panics if Init has not been called.
 func GetInitialisedFlavour() Flavour {
-	return mustBeInitialised()
+	flavour, err := initialised()
+	if err != nil {
+		panic(err)
+	}
+	return flavour
 }
 
-func Init(flavour Flavour) {
+// initOptions holds optional configuration for InitE.
+type initOptions struct {
+	quarantine bool
+}
 
-	f := flavour
-	initialisedFlavour = &f
+// InitOption is a functional option for configuring InitE.
+type InitOption func(*initOptions)
 
-	// Validate no duplicate campaign UUIDs across env vars
-	validateNoDuplicateCampaignUUIDs()
+// InitWithQuarantine leaves campaign env vars with problems out of the
+// [CampaignRegistry] (logging each, see [CampaignRegistry.Quarantined])
+// so the remaining campaigns keep serving, instead of failing InitE.
+func InitWithQuarantine() InitOption {
+	return func(o *initOptions) {
+		o.quarantine = true
+	}
+}
 
-	// Validate env var names match org prefix from MAPPING_PATH
-	validateEnvVarOrgPrefix()
+// Init initialises the package for flavour, exiting the process if any
+// campaign env var is invalid. Use InitE to handle the error instead.
+func Init(flavour Flavour) {
+	if err := InitE(flavour); err != nil {
+		log.Fatalf("failed to initialise: %v", err)
+	}
+}
 
-	if flavour == Raisely2Ortto { // currently the only flavour, but structure allows for easy addition of new flavours in the future
+// InitE initialises the package for flavour: it indexes the campaign
+// env vars into the [CampaignRegistry], validating there are no
+// duplicate campaign UUIDs and that env var names match the org prefix
+// from their MAPPING_PATH, and registers the gjson modifiers used by
+// mapping files. Every env var problem is returned together as a
+// *CampaignEnvVarsError, and the package is left uninitialised.
+func InitE(flavour Flavour, opts ...InitOption) error {
+	var options initOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	registry, err := newCampaignRegistry(flavour, os.Environ(), options.quarantine)
+	if err != nil {
+		return err
+	}
+
+	f := flavour
+	initialisedFlavour = &f
+	campaignRegistry = registry
+
+	// gjson keeps a single process-wide modifier table (AddModifier has
+	// no per-parser equivalent), and mapping expressions are evaluated
+	// with the package-level gjson functions, so the modifiers have to be
+	// registered globally. That is safe because they are pure functions
+	// of their input and argument: flavour-specific conversions are
+	// selected by the argument rather than by which modifiers are
+	// registered, and the only flavour-only modifier (@timestamp) is
+	// ignored by Raisely mapping files. Registering is not safe while
+	// mapping, which is why it happens here, before anything is served.
+	//
+	// Both flavours sync to Ortto, so they share the Ortto-format modifiers.
+	// Source-specific conversions are selected by the modifier argument
+	// (e.g. @currency:RAISELY_2DP vs @currency:FUNRAISIN_DECIMAL).
+	if flavour == Raisely2Ortto || flavour == Funraisin2Ortto {
 
 		gjson.AddModifier("pathJoinURL", func(json, arg string) string {
 			var result string
@@ -75,6 +136,11 @@ func Init(flavour Flavour) {
 				d := i * 10
 				return fmt.Sprintf("%d", d)
 			}
+			if arg == "FUNRAISIN_DECIMAL" {
+				// funraisin stores currency as a decimal in the larger unit (e.g. 12.50)
+				// and ortto expects decimals to be sent as an integer multiplied by 1000
+				return funraisinDecimalToOrtto(res)
+			}
 			return json
 		})
 
@@ -90,6 +156,11 @@ func Init(flavour Flavour) {
 				// so we don't need to do anything for this conversion :)
 				return fmt.Sprintf("%d", i)
 			}
+			if arg == "FUNRAISIN_KM" {
+				// funraisin stores kilometres as a decimal value
+				// and ortto expects decimals to be sent as an integer multiplied by 1000
+				return funraisinDecimalToOrtto(res)
+			}
 			return json
 		})
 
@@ -180,76 +251,20 @@ func Init(flavour Flavour) {
 
 	}
 
-}
+	if flavour == Funraisin2Ortto {
 
-// validateEnvVarOrgPrefix scans all environment variables for JSON values
-// containing a MAPPING_PATH and validates that the env var name starts with
-// the org prefix from the path (the portion before the "/").
-func validateEnvVarOrgPrefix() {
-	for _, env := range os.Environ() {
-		parts := strings.SplitN(env, "=", 2)
-		if len(parts) != 2 {
-			continue
-		}
-		name, value := parts[0], parts[1]
-
-		var m map[string]string
-		// Most env vars are plain strings (e.g. PATH), not JSON — skip those silently
-		if err := json.Unmarshal([]byte(value), &m); err != nil {
-			continue
-		}
-
-		mappingPath, ok := m["MAPPING_PATH"]
-		if !ok {
-			continue
-		}
-
-		index := strings.Index(mappingPath, "/")
-		if index == -1 {
-			log.Fatalf("MAPPING_PATH %q in env var %q must contain org directory (e.g. ORG/LABEL)", mappingPath, name)
-		}
-
-		org := mappingPath[:index]
-		nameWithoutPrefix := strings.TrimPrefix(name, CampaignEnvVarPrefix)
-		if !strings.HasPrefix(nameWithoutPrefix, org+"_") {
-			log.Fatalf("env var name %q must start with %s%s (from MAPPING_PATH %q)", name, CampaignEnvVarPrefix, org+"_", mappingPath)
-		}
-	}
-}
+		gjson.AddModifier("timestamp", func(json, arg string) string {
+			res := gjson.Parse(json)
+			if !res.Exists() {
+				return ""
+			}
+			if arg == "FUNRAISIN" {
+				return funraisinTimestampToOrtto(res)
+			}
+			return json
+		})
 
-// validateNoDuplicateCampaignUUIDs scans all environment variables for JSON values
-// containing the campaign UUID key for the initialised flavour
-// and fatals if any UUID appears in more than one env var.
-func validateNoDuplicateCampaignUUIDs() {
-	campaignUUIDKey, err := campaignUUIDKeyForFlavour(GetInitialisedFlavour())
-	if err != nil {
-		log.Fatalf("failed to validate campaign UUIDs: %v", err)
 	}
 
-	// map of UUID -> env var name
-	seen := make(map[string]string)
-
-	for _, env := range os.Environ() {
-		parts := strings.SplitN(env, "=", 2)
-		if len(parts) != 2 {
-			continue
-		}
-		name, value := parts[0], parts[1]
-
-		var m map[string]string
-		if err := json.Unmarshal([]byte(value), &m); err != nil {
-			// Most env vars are plain strings (e.g. PATH), not JSON — skip those silently
-			continue
-		}
-
-		uuid, ok := m[campaignUUIDKey]
-		if !ok {
-			continue
-		}
-
-		if existing, found := seen[uuid]; found {
-			log.Fatalf("duplicate %s %q found in env vars %q and %q", campaignUUIDKey, uuid, existing, name)
-		}
-		seen[uuid] = name
-	}
+	return nil
 }
@@ -2,6 +2,7 @@
 package sync
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/tidwall/gjson"
@@ -40,3 +41,17 @@ func TestDefaultModifier(t *testing.T) {
 	})
 
 }
+
+func TestEntryPointsReturnErrNotInitialised(t *testing.T) {
+	t.Parallel()
+	// The test binary never calls Init.
+	if _, err := NewService(Config{}, "campaign", TriggerInfo{}); !errors.Is(err, ErrNotInitialised) {
+		t.Errorf("NewService err = %v, want ErrNotInitialised", err)
+	}
+	if _, err := Campaigns(); !errors.Is(err, ErrNotInitialised) {
+		t.Errorf("Campaigns err = %v, want ErrNotInitialised", err)
+	}
+	if _, err := LoadCampaignConfigFromJSON(EmbeddedMappings{}, map[string]string{"MAPPING_PATH": "ACME/SPRING"}); !errors.Is(err, ErrNotInitialised) {
+		t.Errorf("LoadCampaignConfigFromJSON err = %v, want ErrNotInitialised", err)
+	}
+}
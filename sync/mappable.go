@@ -9,9 +9,15 @@ type Mappable interface {
 }
 
 // MapFields maps fields from a source to a destination using the provided mappings.
+// Mapping values are gjson paths, `backtick` literals (strings only) or
+// expressions starting with '=' (see mapping_expr.go).
 func MapFields(mappings FieldMappings, source Source, destination Mappable) {
 	if mappings.Strings != nil {
 		for field, path := range mappings.Strings {
+			if isMappingExpr(path) {
+				destination.SetField(field, evalMappingExpr(path, String, source, field))
+				continue
+			}
 			// handle static strings as well as dynamic paths
 			// escaping the value in backticks allows us to distinguish between the two
 			if len(path) >= 2 && path[0] == '`' && path[len(path)-1] == '`' {
@@ -27,6 +33,10 @@ func MapFields(mappings FieldMappings, source Source, destination Mappable) {
 	}
 	if mappings.Texts != nil {
 		for field, path := range mappings.Texts {
+			if isMappingExpr(path) {
+				destination.SetField(field, evalMappingExpr(path, Text, source, field))
+				continue
+			}
 			if result, exists := source.StringForPath(path); exists {
 				destination.SetField(field, result)
 			} else {
@@ -36,6 +46,10 @@ func MapFields(mappings FieldMappings, source Source, destination Mappable) {
 	}
 	if mappings.Decimals != nil {
 		for field, path := range mappings.Decimals {
+			if isMappingExpr(path) {
+				destination.SetField(field, evalMappingExpr(path, Decimal, source, field))
+				continue
+			}
 			if result, exists := source.IntForPath(path); exists {
 				destination.SetField(field, result)
 			} else {
@@ -45,6 +59,10 @@ func MapFields(mappings FieldMappings, source Source, destination Mappable) {
 	}
 	if mappings.Booleans != nil {
 		for field, path := range mappings.Booleans {
+			if isMappingExpr(path) {
+				destination.SetField(field, evalMappingExpr(path, Boolean, source, field))
+				continue
+			}
 			if result, exists := source.BoolForPath(path); exists {
 				destination.SetField(field, result)
 			} else {
@@ -54,6 +72,10 @@ func MapFields(mappings FieldMappings, source Source, destination Mappable) {
 	}
 	if mappings.Timestamps != nil {
 		for field, path := range mappings.Timestamps {
+			if isMappingExpr(path) {
+				destination.SetField(field, evalMappingExpr(path, Timestamp, source, field))
+				continue
+			}
 			if result, exists := source.StringForPath(path); exists {
 				destination.SetField(field, result)
 			} else {
@@ -66,7 +88,11 @@ func MapFields(mappings FieldMappings, source Source, destination Mappable) {
 			phoneObject := make(map[string]string)
 			isEmptyObject := true
 			for phoneField, path := range v {
-				phoneObject[phoneField], _ = source.StringForPath(path)
+				if isMappingExpr(path) {
+					phoneObject[phoneField], _ = evalMappingExpr(path, String, source, field+"."+phoneField).(string)
+				} else {
+					phoneObject[phoneField], _ = source.StringForPath(path)
+				}
 				if isEmptyObject && phoneObject[phoneField] != "" {
 					isEmptyObject = false
 				}
@@ -83,7 +109,11 @@ func MapFields(mappings FieldMappings, source Source, destination Mappable) {
 			geoObject := make(map[string]string)
 			isEmptyObject := true
 			for geoField, path := range v {
-				geoObject[geoField], _ = source.StringForPath(path)
+				if isMappingExpr(path) {
+					geoObject[geoField], _ = evalMappingExpr(path, String, source, field+"."+geoField).(string)
+				} else {
+					geoObject[geoField], _ = source.StringForPath(path)
+				}
 				if isEmptyObject && geoObject[geoField] != "" {
 					isEmptyObject = false
 				}
@@ -97,6 +127,10 @@ func MapFields(mappings FieldMappings, source Source, destination Mappable) {
 	}
 	if mappings.Integers != nil {
 		for field, path := range mappings.Integers {
+			if isMappingExpr(path) {
+				destination.SetField(field, evalMappingExpr(path, Integer, source, field))
+				continue
+			}
 			if result, exists := source.IntForPath(path); exists {
 				destination.SetField(field, result)
 			} else {
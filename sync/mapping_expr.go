@@ -0,0 +1,857 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	gosync "sync"
+
+	"github.com/tidwall/gjson"
+)
+
+// Mapping expressions
+//
+// A FieldMappings value starting with '=' is an expression evaluated
+// against the source, rather than a single gjson path:
+//
+//	"= if(private.teamRole == 'captain', 'Captain', 'Member')"
+//	"= coalesce(public.nickname, user.firstName)"
+//	"= user.firstName + ' ' + user.lastName"
+//	"= (^.sumTotal - sumTotal) / 100"
+//
+// Operands are source paths (gjson paths made of letters, digits, '_',
+// '.' and '#', optionally prefixed with ^. for the parent source or
+// @team. for team aggregates; modifiers are not supported), 'single' or "double" quoted strings,
+// numbers, true, false and null. Operators, loosest binding first:
+//
+//	||  &&  (either operand decides, as in Go)
+//	==  !=  <  <=  >  >=
+//	+  -    (+ concatenates if either side is a string)
+//	*  /  %
+//	!  -    (unary)
+//
+// Functions are if(cond, then, else) and coalesce(a, b, ...), which
+// returns its first argument that is neither null nor "". Missing paths
+// are null; arithmetic on null is null and concatenation treats it as
+// "". Conditions treat null, false, "" and 0 as false.
+//
+// Results for integers fields are rounded to the nearest whole number.
+// Results for decimals fields are in the field's own unit and are sent
+// in Ortto's decimal encoding (multiplied by 1000, as @currency does),
+// so "= (^.sumTotal - sumTotal) / 100" maps a gap held in cents to a
+// decimals field in dollars.
+//
+// Expressions are parsed and type-checked against the target Ortto field
+// type when the config is loaded (see ValidateFieldMappingExpressions).
+// Evaluation can't run arbitrary code, and a runtime error (e.g. a path
+// holding an unexpected type) logs a warning and maps the field to null.
+
+// isMappingExpr reports whether a mapping value is an expression.
+func isMappingExpr(value string) bool {
+	return strings.HasPrefix(value, "=")
+}
+
+// exprKind is the static type of an expression.
+type exprKind int
+
+const (
+	kindAny exprKind = iota // a source path, whose type is only known at runtime
+	kindNull
+	kindBool
+	kindNumber
+	kindString
+)
+
+func (k exprKind) String() string {
+	switch k {
+	case kindNull:
+		return "null"
+	case kindBool:
+		return "boolean"
+	case kindNumber:
+		return "number"
+	case kindString:
+		return "string"
+	default:
+		return "any"
+	}
+}
+
+// fieldTypeKind returns the expression kind a field type needs.
+func fieldTypeKind(fieldType SimpleFieldType) exprKind {
+	switch fieldType {
+	case Decimal, Integer:
+		return kindNumber
+	case Boolean:
+		return kindBool
+	default:
+		return kindString
+	}
+}
+
+// mappingExpr is a compiled mapping expression.
+type mappingExpr struct {
+	root exprNode
+	kind exprKind
+}
+
+// compiledExprs caches compileMappingExpr results by mapping value.
+var compiledExprs gosync.Map
+
+type compiledExpr struct {
+	expr *mappingExpr
+	err  error
+}
+
+// compileMappingExpr parses and type-checks a mapping value starting
+// with '='.
+func compileMappingExpr(value string) (*mappingExpr, error) {
+	if c, ok := compiledExprs.Load(value); ok {
+		return c.(compiledExpr).expr, c.(compiledExpr).err
+	}
+	expr, err := parseMappingExpr(strings.TrimPrefix(value, "="))
+	compiledExprs.Store(value, compiledExpr{expr, err})
+	return expr, err
+}
+
+func parseMappingExpr(src string) (*mappingExpr, error) {
+	tokens, err := lexExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+	}
+	kind, err := root.check()
+	if err != nil {
+		return nil, err
+	}
+	return &mappingExpr{root: root, kind: kind}, nil
+}
+
+// checkTarget reports whether the expression can produce a value for a
+// field of the given type. Numbers are accepted for string fields.
+func (e *mappingExpr) checkTarget(fieldType SimpleFieldType) error {
+	want := fieldTypeKind(fieldType)
+	if e.kind == want || e.kind == kindAny || e.kind == kindNull {
+		return nil
+	}
+	if want == kindString && e.kind == kindNumber {
+		return nil
+	}
+	return fmt.Errorf("expression is a %s, field needs a %s", e.kind, want)
+}
+
+// evalMappingExpr evaluates a mapping expression for field and converts
+// the result for the field type, returning nil (after logging a warning)
+// on error.
+func evalMappingExpr(value string, fieldType SimpleFieldType, source Source, field string) interface{} {
+	expr, err := compileMappingExpr(value)
+	if err == nil {
+		var result interface{}
+		result, err = expr.root.eval(source)
+		if err == nil {
+			result, err = convertExprResult(result, fieldType)
+		}
+		if err == nil {
+			return result
+		}
+	}
+	log.Printf("Warning: mapping expression for %s failed: %v", field, err)
+	return nil
+}
+
+// convertExprResult converts an evaluated value to the Go type MapFields
+// uses for the field type.
+func convertExprResult(v interface{}, fieldType SimpleFieldType) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	switch fieldTypeKind(fieldType) {
+	case kindNumber:
+		var f float64
+		switch x := v.(type) {
+		case float64:
+			f = x
+		case string:
+			var err error
+			if f, err = strconv.ParseFloat(x, 64); err != nil {
+				return nil, fmt.Errorf("%q is not a number", x)
+			}
+		default:
+			return nil, fmt.Errorf("%v can't be mapped to a %s field", v, kindNumber)
+		}
+		if fieldType == Decimal {
+			f *= 1000 // Ortto decimals are sent as an integer multiplied by 1000
+		}
+		return int64(math.Round(f)), nil
+	case kindBool:
+		switch x := v.(type) {
+		case bool:
+			return x, nil
+		case string:
+			b, err := strconv.ParseBool(x)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a boolean", x)
+			}
+			return b, nil
+		}
+	default:
+		switch x := v.(type) {
+		case string:
+			return x, nil
+		case float64:
+			return formatExprNumber(x), nil
+		}
+	}
+	return nil, fmt.Errorf("%v can't be mapped to a %s field", v, fieldTypeKind(fieldType))
+}
+
+func formatExprNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// ValidateFieldMappingExpressions parses and type-checks every mapping
+// expression in mappings, returning all problems joined.
+func ValidateFieldMappingExpressions(mappings FieldMappings) error {
+	var errs []error
+	simple := []struct {
+		fieldType SimpleFieldType
+		values    map[string]string
+	}{
+		{String, mappings.Strings},
+		{Text, mappings.Texts},
+		{Decimal, mappings.Decimals},
+		{Boolean, mappings.Booleans},
+		{Timestamp, mappings.Timestamps},
+		{Integer, mappings.Integers},
+	}
+	for _, s := range simple {
+		for field, value := range s.values {
+			errs = append(errs, validateMappingExpr(field, value, s.fieldType))
+		}
+	}
+	for _, nested := range []map[string]map[string]string{mappings.Phones, mappings.Geos} {
+		for field, values := range nested {
+			for sub, value := range values {
+				errs = append(errs, validateMappingExpr(field+"."+sub, value, String))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validateMappingExpr(field, value string, fieldType SimpleFieldType) error {
+	if !isMappingExpr(value) {
+		return nil
+	}
+	expr, err := compileMappingExpr(value)
+	if err == nil {
+		err = expr.checkTarget(fieldType)
+	}
+	if err != nil {
+		return fmt.Errorf("field %s: invalid expression %q: %w", field, value, err)
+	}
+	return nil
+}
+
+// --- Lexer ---
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent // a path, keyword or function name
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string // for tokString, the unquoted value
+	pos  int
+}
+
+// exprOps lists the operators and punctuation, longest first.
+var exprOps = []string{"||", "&&", "==", "!=", "<=", ">=", "<", ">", "+", "-", "*", "/", "%", "!", "(", ")", ","}
+
+func isPathStart(c byte) bool {
+	return c == '_' || c == '^' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isPathChar(c byte) bool {
+	return isPathStart(c) || c == '.' || c == '#' || (c >= '0' && c <= '9')
+}
+
+func lexExpr(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(src[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			tokens = append(tokens, token{tokString, src[i+1 : i+1+end], i})
+			i += end + 2
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokNumber, src[start:i], start})
+		case isPathStart(c) || strings.HasPrefix(src[i:], teamAggregatesPrefix):
+			start := i
+			i++
+			for i < len(src) && isPathChar(src[i]) {
+				i++
+			}
+			tokens = append(tokens, token{tokIdent, src[start:i], start})
+		default:
+			matched := false
+			for _, op := range exprOps {
+				if strings.HasPrefix(src[i:], op) {
+					tokens = append(tokens, token{tokOp, op, i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected %q at offset %d", c, i)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+// --- Parser ---
+
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) peek() token { return p.tokens[p.pos] }
+
+func (p *exprParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// acceptOp consumes the next token if it is one of ops.
+func (p *exprParser) acceptOp(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokOp {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) expectOp(op string) error {
+	if _, ok := p.acceptOp(op); !ok {
+		t := p.peek()
+		if t.kind == tokEOF {
+			return fmt.Errorf("expected %q at end of expression", op)
+		}
+		return fmt.Errorf("expected %q at offset %d, got %q", op, t.pos, t.text)
+	}
+	return nil
+}
+
+// binaryLevel parses a left-associative chain of ops over operands
+// parsed by next.
+func (p *exprParser) binaryLevel(next func() (exprNode, error), ops ...string) (exprNode, error) {
+	x, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.acceptOp(ops...)
+		if !ok {
+			return x, nil
+		}
+		y, err := next()
+		if err != nil {
+			return nil, err
+		}
+		x = &binaryNode{op: op, x: x, y: y}
+	}
+}
+
+func (p *exprParser) parseOr() (exprNode, error) { return p.binaryLevel(p.parseAnd, "||") }
+
+func (p *exprParser) parseAnd() (exprNode, error) { return p.binaryLevel(p.parseCompare, "&&") }
+
+func (p *exprParser) parseCompare() (exprNode, error) {
+	x, err := p.parseAdd()
+	if err != nil {
+		return nil, err
+	}
+	if op, ok := p.acceptOp("==", "!=", "<=", ">=", "<", ">"); ok {
+		y, err := p.parseAdd()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{op: op, x: x, y: y}, nil
+	}
+	return x, nil
+}
+
+func (p *exprParser) parseAdd() (exprNode, error) { return p.binaryLevel(p.parseMul, "+", "-") }
+
+func (p *exprParser) parseMul() (exprNode, error) { return p.binaryLevel(p.parseUnary, "*", "/", "%") }
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if op, ok := p.acceptOp("!", "-"); ok {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %d", t.text, t.pos)
+		}
+		return &literalNode{value: f}, nil
+	case tokString:
+		return &literalNode{value: t.text}, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+		if _, ok := p.acceptOp("("); ok {
+			return p.parseCall(t)
+		}
+		if strings.HasPrefix(t.text, "^") && !strings.HasPrefix(t.text, "^.") {
+			return nil, fmt.Errorf("invalid path %q at offset %d", t.text, t.pos)
+		}
+		return &pathNode{path: t.text}, nil
+	case tokOp:
+		if t.text == "(" {
+			x, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expectOp(")")
+		}
+		return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+	default:
+		return nil, errors.New("unexpected end of expression")
+	}
+}
+
+func (p *exprParser) parseCall(name token) (exprNode, error) {
+	call := &callNode{name: name.text}
+	if _, ok := p.acceptOp(")"); !ok {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			if _, ok := p.acceptOp(","); !ok {
+				break
+			}
+		}
+		if err := p.expectOp(")"); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case call.name == "if" && len(call.args) != 3:
+		return nil, fmt.Errorf("if takes 3 arguments, got %d", len(call.args))
+	case call.name == "coalesce" && len(call.args) == 0:
+		return nil, errors.New("coalesce takes at least 1 argument")
+	case call.name != "if" && call.name != "coalesce":
+		return nil, fmt.Errorf("unknown function %q at offset %d", call.name, name.pos)
+	}
+	return call, nil
+}
+
+// --- Evaluation ---
+
+type exprNode interface {
+	eval(source Source) (interface{}, error)
+	check() (exprKind, error)
+}
+
+type literalNode struct{ value interface{} }
+
+func (n *literalNode) eval(Source) (interface{}, error) { return n.value, nil }
+
+func (n *literalNode) check() (exprKind, error) { return kindOf(n.value), nil }
+
+func kindOf(v interface{}) exprKind {
+	switch v.(type) {
+	case bool:
+		return kindBool
+	case float64:
+		return kindNumber
+	case string:
+		return kindString
+	default:
+		return kindNull
+	}
+}
+
+type pathNode struct{ path string }
+
+func (n *pathNode) eval(source Source) (interface{}, error) {
+	src, p := source.resolve(n.path)
+	result := src.data.Get(p)
+	if !result.Exists() {
+		return nil, nil
+	}
+	switch result.Type {
+	case gjson.String:
+		return result.Str, nil
+	case gjson.Number:
+		return result.Num, nil
+	case gjson.True, gjson.False:
+		return result.Bool(), nil
+	case gjson.JSON:
+		return result.Raw, nil
+	default:
+		return nil, nil
+	}
+}
+
+func (n *pathNode) check() (exprKind, error) { return kindAny, nil }
+
+type unaryNode struct {
+	op string
+	x  exprNode
+}
+
+func (n *unaryNode) eval(source Source) (interface{}, error) {
+	x, err := n.x.eval(source)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		return !truthy(x), nil
+	}
+	switch v := x.(type) {
+	case nil:
+		return nil, nil
+	case float64:
+		return -v, nil
+	}
+	return nil, fmt.Errorf("can't negate %v", x)
+}
+
+func (n *unaryNode) check() (exprKind, error) {
+	k, err := n.x.check()
+	if err != nil {
+		return k, err
+	}
+	if n.op == "!" {
+		if k != kindBool && k != kindAny {
+			return k, fmt.Errorf("! needs a boolean, got %s", k)
+		}
+		return kindBool, nil
+	}
+	if k != kindNumber && k != kindAny && k != kindNull {
+		return k, fmt.Errorf("unary - needs a number, got %s", k)
+	}
+	return kindNumber, nil
+}
+
+type binaryNode struct {
+	op   string
+	x, y exprNode
+}
+
+func (n *binaryNode) eval(source Source) (interface{}, error) {
+	x, err := n.x.eval(source)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "&&":
+		if !truthy(x) {
+			return false, nil
+		}
+	case "||":
+		if truthy(x) {
+			return true, nil
+		}
+	}
+	y, err := n.y.eval(source)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "&&", "||":
+		return truthy(y), nil
+	case "==":
+		return x == y, nil
+	case "!=":
+		return x != y, nil
+	case "<", "<=", ">", ">=":
+		return compareExprValues(n.op, x, y)
+	case "+":
+		xs, xIsString := x.(string)
+		ys, yIsString := y.(string)
+		if xIsString || yIsString {
+			if !xIsString {
+				xs = concatOperand(x)
+			}
+			if !yIsString {
+				ys = concatOperand(y)
+			}
+			return xs + ys, nil
+		}
+	}
+
+	if x == nil || y == nil {
+		return nil, nil
+	}
+	xf, xok := x.(float64)
+	yf, yok := y.(float64)
+	if !xok || !yok {
+		return nil, fmt.Errorf("%s needs numbers, got %v and %v", n.op, x, y)
+	}
+	switch n.op {
+	case "+":
+		return xf + yf, nil
+	case "-":
+		return xf - yf, nil
+	case "*":
+		return xf * yf, nil
+	case "/":
+		if yf == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return xf / yf, nil
+	case "%":
+		if yf == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return math.Mod(xf, yf), nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", n.op)
+}
+
+func (n *binaryNode) check() (exprKind, error) {
+	x, err := n.x.check()
+	if err != nil {
+		return x, err
+	}
+	y, err := n.y.check()
+	if err != nil {
+		return y, err
+	}
+	concrete := func(k exprKind) bool { return k != kindAny && k != kindNull }
+
+	switch n.op {
+	case "&&", "||":
+		for _, k := range []exprKind{x, y} {
+			if k != kindBool && k != kindAny {
+				return k, fmt.Errorf("%s needs booleans, got %s", n.op, k)
+			}
+		}
+		return kindBool, nil
+	case "==", "!=":
+		if concrete(x) && concrete(y) && x != y {
+			return x, fmt.Errorf("%s compares a %s with a %s", n.op, x, y)
+		}
+		return kindBool, nil
+	case "<", "<=", ">", ">=":
+		for _, k := range []exprKind{x, y} {
+			if k == kindBool {
+				return k, fmt.Errorf("%s can't compare booleans", n.op)
+			}
+		}
+		if concrete(x) && concrete(y) && x != y {
+			return x, fmt.Errorf("%s compares a %s with a %s", n.op, x, y)
+		}
+		return kindBool, nil
+	case "+":
+		if x == kindString || y == kindString {
+			return kindString, nil
+		}
+	}
+	for _, k := range []exprKind{x, y} {
+		if concrete(k) && k != kindNumber {
+			return k, fmt.Errorf("%s needs numbers, got %s", n.op, k)
+		}
+	}
+	if x == kindNumber && y == kindNumber {
+		return kindNumber, nil
+	}
+	if n.op == "+" {
+		// a path + a path may be a concatenation or a sum
+		return kindAny, nil
+	}
+	return kindNumber, nil
+}
+
+func compareExprValues(op string, x, y interface{}) (bool, error) {
+	var c int
+	switch xv := x.(type) {
+	case nil:
+		return false, nil
+	case float64:
+		yv, ok := y.(float64)
+		if !ok {
+			if y == nil {
+				return false, nil
+			}
+			return false, fmt.Errorf("%s compares %v with %v", op, x, y)
+		}
+		c = compareOrdered(xv, yv)
+	case string:
+		yv, ok := y.(string)
+		if !ok {
+			if y == nil {
+				return false, nil
+			}
+			return false, fmt.Errorf("%s compares %v with %v", op, x, y)
+		}
+		c = strings.Compare(xv, yv)
+	default:
+		return false, fmt.Errorf("%s can't compare %v", op, x)
+	}
+	switch op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+func compareOrdered(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+func concatOperand(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return formatExprNumber(x)
+	default:
+		return fmt.Sprintf("%v", x)
+	}
+}
+
+func truthy(v interface{}) bool {
+	switch x := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return x
+	case string:
+		return x != ""
+	case float64:
+		return x != 0
+	}
+	return true
+}
+
+type callNode struct {
+	name string
+	args []exprNode
+}
+
+func (n *callNode) eval(source Source) (interface{}, error) {
+	if n.name == "if" {
+		cond, err := n.args[0].eval(source)
+		if err != nil {
+			return nil, err
+		}
+		if truthy(cond) {
+			return n.args[1].eval(source)
+		}
+		return n.args[2].eval(source)
+	}
+	// coalesce
+	for _, arg := range n.args {
+		v, err := arg.eval(source)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil && v != "" {
+			return v, nil
+		}
+	}
+	return nil, nil
+}
+
+func (n *callNode) check() (exprKind, error) {
+	kinds := make([]exprKind, len(n.args))
+	for i, arg := range n.args {
+		k, err := arg.check()
+		if err != nil {
+			return k, err
+		}
+		kinds[i] = k
+	}
+	if n.name == "if" {
+		if kinds[0] != kindBool && kinds[0] != kindAny {
+			return kinds[0], fmt.Errorf("if condition needs a boolean, got %s", kinds[0])
+		}
+		kinds = kinds[1:]
+	}
+	result := kindNull
+	for _, k := range kinds {
+		switch {
+		case k == kindNull || k == result:
+		case result == kindNull:
+			result = k
+		case k == kindAny || result == kindAny:
+			result = kindAny
+		default:
+			return k, fmt.Errorf("%s mixes %s and %s results", n.name, result, k)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,149 @@
+package sync
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func testExprSource() Source {
+	parent := Source{data: gjson.Parse(`{"sumTotal": 5000, "name": "Team Red"}`)}
+	return Source{
+		data: gjson.Parse(`{
+			"sumTotal": 1250,
+			"goal": 0,
+			"user": {"firstName": "Ann", "lastName": "Lee"},
+			"public": {"nickname": ""},
+			"private": {"teamRole": "captain", "optIn": true}
+		}`),
+		parent: &parent,
+	}
+}
+
+func TestMappingExpr_Eval(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		expr      string
+		fieldType SimpleFieldType
+		want      interface{}
+	}{
+		{"= if(private.teamRole == 'captain', 'Captain', 'Member')", String, "Captain"},
+		{`= if(private.teamRole != "captain", 'Captain', 'Member')`, String, "Member"},
+		{"= coalesce(public.nickname, public.missing, user.firstName)", String, "Ann"},
+		{"= user.firstName + ' ' + user.lastName", String, "Ann Lee"},
+		{"= 'Total: ' + sumTotal / 100", String, "Total: 12.5"},
+		{"= (^.sumTotal - sumTotal) / 100", Integer, int64(38)},
+		{"= (^.sumTotal - sumTotal) / 100", Decimal, int64(37500)},
+		{"= sumTotal * 2 % 1000", Decimal, int64(500000)},
+		{"= sumTotal / 3", Decimal, int64(416667)},
+		{"= -sumTotal / 100", Integer, int64(-13)},
+		{"= -sumTotal + 1", Integer, int64(-1249)},
+		{"= ^.name", Text, "Team Red"},
+		{"= private.optIn && sumTotal >= 1000", Boolean, true},
+		{"= !private.optIn || user.firstName < 'B'", Boolean, true},
+		{"= missing + 1", Integer, nil},
+		{"= user.firstName + missing", String, "Ann"},
+		{"= goal > 0 && sumTotal / goal > 1", Boolean, false}, // short-circuits before dividing by zero
+		{"= if(goal, 'has goal', null)", String, nil},
+	}
+	source := testExprSource()
+	for _, tc := range cases {
+		t.Run(tc.expr, func(t *testing.T) {
+			t.Parallel()
+			if err := validateMappingExpr("f", tc.expr, tc.fieldType); err != nil {
+				t.Fatalf("validateMappingExpr returned unexpected error: %v", err)
+			}
+			if got := evalMappingExpr(tc.expr, tc.fieldType, source, "f"); got != tc.want {
+				t.Errorf("got %#v, want %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMappingExpr_RuntimeErrorMapsToNull(t *testing.T) {
+	t.Parallel()
+	// user is an object, so arithmetic on it fails at runtime.
+	if got := evalMappingExpr("= user * 2", Integer, testExprSource(), "f"); got != nil {
+		t.Errorf("got %#v, want nil", got)
+	}
+	if got := evalMappingExpr("= sumTotal / goal", Integer, testExprSource(), "f"); got != nil {
+		t.Errorf("division by zero: got %#v, want nil", got)
+	}
+}
+
+func TestMappingExpr_Invalid(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		expr      string
+		fieldType SimpleFieldType
+		wantErr   string
+	}{
+		{"= if(a, 'x')", String, "if takes 3 arguments"},
+		{"= upper(name)", String, "unknown function"},
+		{"= 'unterminated", String, "unterminated string"},
+		{"= (a + b", String, `expected ")"`},
+		{"= a b", String, "unexpected"},
+		{"= a $ b", String, "unexpected"},
+		{"= 1 + true", Integer, "needs numbers"},
+		{"= 'a' == 1", Boolean, "compares a string with a number"},
+		{"= 'yes' && a", Boolean, "needs booleans"},
+		{"= if(a, 'x', 1)", String, "mixes string and number"},
+		{"= 'x' + a", Integer, "field needs a number"},
+		{"= a > 1", String, "field needs a string"},
+		{"= ^name", String, "invalid path"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.expr, func(t *testing.T) {
+			t.Parallel()
+			err := validateMappingExpr("f", tc.expr, tc.fieldType)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("err = %v, want it to contain %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestMapFields_Expressions(t *testing.T) {
+	t.Parallel()
+	mappings := FieldMappings{
+		Strings:  map[string]string{"role": "= if(private.teamRole == 'captain', 'Captain', 'Member')", "first": "user.firstName"},
+		Integers: map[string]string{"raised": "= sumTotal / 100"},
+		Booleans: map[string]string{"big": "= sumTotal > 1000"},
+		Geos:     map[string]map[string]string{"address": {"city": "= coalesce(public.city, 'Unknown')"}},
+	}
+	contact := OrttoContact{Fields: map[string]interface{}{}}
+	MapFields(mappings, testExprSource(), &contact)
+
+	want := map[string]interface{}{"role": "Captain", "first": "Ann", "raised": int64(13), "big": true} // 12.5 rounds up
+	for k, v := range want {
+		if contact.Fields[k] != v {
+			t.Errorf("%s = %#v, want %#v", k, contact.Fields[k], v)
+		}
+	}
+	if geo, _ := contact.Fields["address"].(map[string]string); geo["city"] != "Unknown" {
+		t.Errorf("address = %v, want city Unknown", contact.Fields["address"])
+	}
+}
+
+func TestYAMLConfigUnmarshaler_RejectsInvalidExpressions(t *testing.T) {
+	t.Parallel()
+	yamlBody := `
+fundraiserFieldMappings:
+  custom:
+    booleans:
+      "is-captain": "= private.teamRole == 'captain'"
+teamFieldMappings:
+  custom:
+    integers:
+      "team-total": "= 'total' + sumTotal"
+`
+	file := MappingFile{Name: "test.yaml", Reader: strings.NewReader(yamlBody), Length: len(yamlBody)}
+	_, err := YAMLConfigUnmarshaler{}.Unmarshal(JSONCompositeEnvVar{}, file)
+	if err == nil {
+		t.Fatal("expected an error for a mistyped expression")
+	}
+	if !strings.Contains(err.Error(), "teamFieldMappings.custom") || !strings.Contains(err.Error(), "team-total") {
+		t.Errorf("error should name the section and field, got %v", err)
+	}
+}
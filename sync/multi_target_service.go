@@ -0,0 +1,250 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// MultiTargetService runs a campaign against every Ortto target it has a
+// mapping file for (e.g. both LABEL.ortto-contacts.yaml and
+// LABEL.ortto-activities.yaml while migrating between targets). Raisely
+// (or Funraisin) data is fetched once, covering what every target's
+// config maps, and each mapped profile is fanned out to one [Service] per
+// target, with results and errors reported per target.
+//
+// Usage:
+//
+//	configs, _ := sync.LoadCampaignConfigsFromEnvironment(mappings, campaignID)
+//	svc, _ := sync.NewMultiTargetService(configs, campaignID, trigger)
+//	svc.FetchCampaign(false, ctx)
+//	results, ref, err := svc.MapFundraisingProfile(profileID, ctx)
+//	results = svc.SendRequests(results, ctx)
+//	if err := sync.TargetResultsErr(results); err != nil { ... }
+//	if ref != nil { svc.ProcessReferrals(ref, ctx) }
+type MultiTargetService struct {
+	services []*Service
+}
+
+// TargetResult is the outcome of mapping (and, after SendRequests,
+// sending) for one target. Request is nil when the mapping failed or
+// there was nothing to send; Response is set once sent.
+// FailedMembers lists the members of a team that could not be fetched,
+// so are missing from Request and can be retried.
+type TargetResult struct {
+	Target        string
+	Request       OrttoRequest
+	Response      OrttoResponse
+	Err           error
+	FailedMembers []TeamMemberError
+}
+
+// NewMultiTargetService creates a Service for each config (one per
+// target, as returned by LoadCampaignConfigsFromEnvironment) with the
+// same campaign, trigger and options.
+// Returns ErrNotInitialised if Init has not been called.
+func NewMultiTargetService(configs []Config, campaignID string, trigger TriggerInfo, opts ...ServiceOption) (*MultiTargetService, error) {
+	m := &MultiTargetService{}
+	for _, config := range configs {
+		s, err := NewService(config, campaignID, trigger, opts...)
+		if err != nil {
+			return nil, err
+		}
+		m.services = append(m.services, s)
+	}
+	m.shareFetchNeeds()
+	return m, nil
+}
+
+// shareFetchNeeds has the primary Service fetch what every target maps
+// (activity logs, donations, team exercise totals), and map whole teams
+// when any target maps team aggregates.
+func (m *MultiTargetService) shareFetchNeeds() {
+	if len(m.services) < 2 {
+		return
+	}
+	needs := m.services[0].sc.Config.fetchNeeds()
+	for _, s := range m.services[1:] {
+		needs = needs.union(s.sc.Config.fetchNeeds())
+	}
+	m.services[0].sc.sharedFetchNeeds = &needs
+}
+
+// Services returns the per-target Services, in config order. Use them
+// for operations not covered by MultiTargetService (e.g. CheckOrttoFields).
+func (m *MultiTargetService) Services() []*Service {
+	return m.services
+}
+
+// Targets returns the config target of each Service, in config order.
+func (m *MultiTargetService) Targets() []string {
+	targets := make([]string, len(m.services))
+	for i, s := range m.services {
+		targets[i] = s.Target()
+	}
+	return targets
+}
+
+// primary returns the Service used for fetching, which is shared by all
+// targets and fetches for all of them (see shareFetchNeeds).
+func (m *MultiTargetService) primary() (*Service, error) {
+	if len(m.services) == 0 {
+		return nil, errors.New("no targets configured")
+	}
+	return m.services[0], nil
+}
+
+// FetchCampaign fetches the campaign once and shares it with every
+// target. Must be called before Map and Send operations.
+func (m *MultiTargetService) FetchCampaign(refresh bool, ctx context.Context) (*FundraisingCampaign, error) {
+	primary, err := m.primary()
+	if err != nil {
+		return nil, err
+	}
+	fc, err := primary.FetchCampaign(refresh, ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range m.services[1:] {
+		if err := s.setCampaign(fc); err != nil {
+			return nil, err
+		}
+	}
+	return fc, nil
+}
+
+// MapFundraisingProfile fetches a profile as for
+// [Service.MapFundraisingProfile] and maps it for every target. The
+// returned error is for the shared fetch; mapping errors are reported
+// per target. Referrals are sent to Raisely rather than Ortto, so they
+// are mapped only for the first target whose config sets
+// raiselyFundraiserReferralsField.
+// FetchCampaign must be called first.
+func (m *MultiTargetService) MapFundraisingProfile(profileID string, ctx context.Context) ([]TargetResult, *ReferralBatch, error) {
+	primary, err := m.primary()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := primary.requireMapper(); err != nil {
+		return nil, nil, err
+	}
+	data, err := primary.fetchProfileData(profileID, ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	results, batch := m.mapProfileData(data)
+	return results, batch, nil
+}
+
+// MapByWebhookModel is [Service.MapByWebhookModel] for every target,
+// reporting results as for MapFundraisingProfile.
+// FetchCampaign must be called first.
+func (m *MultiTargetService) MapByWebhookModel(modelType, modelID, parentType, parentID string, parentIsCampaignProfile bool, eventType string, ctx context.Context) ([]TargetResult, *ReferralBatch, error) {
+	primary, err := m.primary()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := primary.requireMapper(); err != nil {
+		return nil, nil, err
+	}
+	data, err := primary.fetchWebhookModelData(modelType, modelID, parentType, parentID, parentIsCampaignProfile, eventType, ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	results, batch := m.mapProfileData(data)
+	return results, batch, nil
+}
+
+func (m *MultiTargetService) mapProfileData(data profileData) ([]TargetResult, *ReferralBatch) {
+	referrals := m.referralsService()
+	results := make([]TargetResult, len(m.services))
+	var batch *ReferralBatch
+	for i, s := range m.services {
+		targetData := data
+		targetData.includeReferrals = data.includeReferrals && s == referrals
+		req, b, err := s.mapProfileData(targetData)
+		results[i] = TargetResult{Target: s.Target(), Request: req, Err: err}
+		if data.team != nil {
+			results[i].FailedMembers = data.team.FailedMembers
+		}
+		if b != nil {
+			batch = b
+		}
+	}
+	return results, batch
+}
+
+// referralsService returns the Service that handles referrals: the first
+// whose config sets raiselyFundraiserReferralsField, or nil if none do.
+func (m *MultiTargetService) referralsService() *Service {
+	for _, s := range m.services {
+		if s.sc.Config.API.Settings.RaiselyFundraiserReferralsField != "" {
+			return s
+		}
+	}
+	return nil
+}
+
+// MapTrackingData maps tracking key-value pairs for every target.
+// FetchCampaign must be called first.
+func (m *MultiTargetService) MapTrackingData(data map[string]string, ctx context.Context) []TargetResult {
+	results := make([]TargetResult, len(m.services))
+	for i, s := range m.services {
+		req, err := s.MapTrackingData(data, ctx)
+		results[i] = TargetResult{Target: s.Target(), Request: req, Err: err}
+	}
+	return results
+}
+
+// SendRequests sends each target's mapped request with that target's
+// [Service.SendRequest], returning the results with Response or Err
+// set. Results that already have an error or no request are passed
+// through unchanged, and a failure for one target does not stop the
+// others.
+// FetchCampaign must be called first.
+func (m *MultiTargetService) SendRequests(results []TargetResult, ctx context.Context) []TargetResult {
+	byTarget := make(map[string]*Service, len(m.services))
+	for _, s := range m.services {
+		byTarget[s.Target()] = s
+	}
+	sent := make([]TargetResult, len(results))
+	for i, r := range results {
+		sent[i] = r
+		if r.Err != nil || r.Request == nil {
+			continue
+		}
+		s, ok := byTarget[r.Target]
+		if !ok {
+			sent[i].Err = fmt.Errorf("no service for target %q", r.Target)
+			continue
+		}
+		sent[i].Response, sent[i].Err = s.SendRequest(r.Request, ctx)
+	}
+	return sent
+}
+
+// ProcessReferrals processes a ReferralBatch from MapFundraisingProfile
+// or MapByWebhookModel with the Service of the target that mapped it
+// (see [Service.ProcessReferrals]).
+func (m *MultiTargetService) ProcessReferrals(batch *ReferralBatch, ctx context.Context) error {
+	if batch == nil {
+		return nil
+	}
+	s := m.referralsService()
+	if s == nil {
+		return errors.New("no target is configured for referrals")
+	}
+	return s.ProcessReferrals(batch, ctx)
+}
+
+// TargetResultsErr joins the errors in results, each prefixed with its
+// target, or returns nil if every target succeeded.
+func TargetResultsErr(results []TargetResult) error {
+	var errs []error
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("target %q: %w", r.Target, r.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,274 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFindCampaignMappingFilesWithTargetsByPath(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		files       []string
+		wantTargets []string
+		wantErr     string
+	}{
+		{
+			name:        "both targets",
+			files:       []string{"LABEL.ortto-activities.yaml", "LABEL.ortto-contacts.yaml", "LABEL.ortto-activities.referrals.yaml"},
+			wantTargets: []string{"ortto-activities", "ortto-contacts"},
+		},
+		{
+			name:        "legacy and activities",
+			files:       []string{"LABEL.yaml", "LABEL.ortto-activities.yaml"},
+			wantTargets: []string{"ortto-activities", ""},
+		},
+		{
+			name:    "legacy and contacts",
+			files:   []string{"LABEL.yaml", "LABEL.ortto-contacts.yaml"},
+			wantErr: "found multiple mapping files for target ortto-contacts",
+		},
+		{
+			name:    "none",
+			files:   []string{"OTHER.yaml"},
+			wantErr: "failed to find mapping file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			files := make(map[string]string)
+			for _, f := range tt.files {
+				files["mappings/ORG/"+f] = "campaignPrefix: x\n"
+			}
+			em := memMappings(t, "mappings", files)
+
+			results, targets, err := em.FindCampaignMappingFilesWithTargetsByPath("ORG/LABEL")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(targets, ",") != strings.Join(tt.wantTargets, ",") {
+				t.Errorf("targets = %q, want %q", targets, tt.wantTargets)
+			}
+			if len(results) != len(targets) {
+				t.Fatalf("got %d files for %d targets", len(results), len(targets))
+			}
+			for i, target := range targets {
+				if !strings.HasSuffix(results[i].Name, "LABEL."+target+".yaml") && !(target == "" && strings.HasSuffix(results[i].Name, "LABEL.yaml")) {
+					t.Errorf("file %s does not match target %q", results[i].Name, target)
+				}
+			}
+		})
+	}
+}
+
+func TestMustFindFirstCampaignMappingFileWithTargetByPath_MultipleTargets(t *testing.T) {
+	t.Parallel()
+	em := memMappings(t, "mappings", map[string]string{
+		"mappings/ORG/LABEL.ortto-contacts.yaml":   "campaignPrefix: x\n",
+		"mappings/ORG/LABEL.ortto-activities.yaml": "campaignPrefix: x\n",
+	})
+	_, _, err := em.MustFindFirstCampaignMappingFileWithTargetByPath("ORG/LABEL")
+	if err == nil || !strings.Contains(err.Error(), "found multiple mapping files") {
+		t.Errorf("err = %v, want a multiple mapping files error", err)
+	}
+}
+
+func TestLoadCampaignConfigs(t *testing.T) {
+	t.Parallel()
+	em := memMappings(t, "mappings", map[string]string{
+		"mappings/required.yaml":                   "api:\n  keys:\n    raisely: ${RAISELY_API_KEY}\n",
+		"mappings/defaults.yaml":                   "campaignPrefix: contacts-default\n",
+		"mappings/required.ortto-activities.yaml":  "api:\n  keys:\n    raisely: ${RAISELY_API_KEY}\n",
+		"mappings/defaults.ortto-activities.yaml":  "campaignPrefix: activities-default\n",
+		"mappings/ORG/LABEL.ortto-activities.yaml": "api:\n  settings:\n    orttoActivityId: act:cm:fundraising\n",
+		"mappings/ORG/LABEL.ortto-contacts.yaml":   "api:\n  settings:\n    raiselyWebhookEvents: [profile.updated]\n",
+	})
+	envVar := CampaignEnvVar{Path: "ORG/LABEL", Config: map[string]string{"RAISELY_API_KEY": "k"}}
+
+	configs, err := loadCampaignConfigs(em, envVar, MapCompositeEnvVar{Values: envVar.Config}, configOptions{})
+	if err != nil {
+		t.Fatalf("loadCampaignConfigs returned unexpected error: %v", err)
+	}
+	if len(configs) != 2 {
+		t.Fatalf("got %d configs, want 2", len(configs))
+	}
+	activities, contacts := configs[0], configs[1]
+	if activities.Target != "ortto-activities" || activities.CampaignPrefix != "activities-default" || activities.API.Settings.OrttoActivityID != "act:cm:fundraising" {
+		t.Errorf("unexpected activities config %q %q %q", activities.Target, activities.CampaignPrefix, activities.API.Settings.OrttoActivityID)
+	}
+	if contacts.Target != "ortto-contacts" || contacts.CampaignPrefix != "contacts-default" || contacts.API.Settings.OrttoActivityID != "" {
+		t.Errorf("unexpected contacts config %q %q %q", contacts.Target, contacts.CampaignPrefix, contacts.API.Settings.OrttoActivityID)
+	}
+	for _, c := range configs {
+		if string(c.API.Keys.Raisely) != "k" || c.EnvVar.Path != "ORG/LABEL" {
+			t.Errorf("target %q: unexpected shared values %+v", c.Target, c.EnvVar)
+		}
+	}
+
+	if _, err := loadCampaignConfig(em, envVar, MapCompositeEnvVar{Values: envVar.Config}, configOptions{}); err == nil {
+		t.Error("expected loadCampaignConfig to reject a campaign with two targets")
+	}
+}
+
+func newTestMultiTargetService(endpoint string, mappers map[string]OrttoMapper, targets ...string) *MultiTargetService {
+	m := &MultiTargetService{}
+	for _, target := range targets {
+		s := newTestSyncCampaignService(endpoint, mappers[target], nil)
+		s.sc.Config.Target = target
+		m.services = append(m.services, s)
+	}
+	m.shareFetchNeeds()
+	return m
+}
+
+func TestMultiTargetService_FansOutPerTarget(t *testing.T) {
+	t.Parallel()
+	srv := newTestRaiselyCampaignServer(t, testCampaignProfiles())
+	contacts := &recordingOrttoMapper{}
+	activities := &recordingOrttoMapper{sendErr: errors.New("ortto unavailable")}
+	m := newTestMultiTargetService(srv.URL, map[string]OrttoMapper{
+		"ortto-contacts":   contacts,
+		"ortto-activities": activities,
+	}, "ortto-contacts", "ortto-activities")
+
+	results, batch, err := m.MapByWebhookModel("INDIVIDUAL", "p-1", "CAMPAIGN", "campaign-profile", true, "profile.updated", t.Context())
+	if err != nil {
+		t.Fatalf("MapByWebhookModel returned unexpected error: %v", err)
+	}
+	if batch != nil {
+		t.Errorf("expected no referral batch, got %+v", batch)
+	}
+	if len(results) != 2 || results[0].Target != "ortto-contacts" || results[1].Target != "ortto-activities" {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	for _, r := range results {
+		if r.Err != nil || r.Request == nil || r.Request.ItemCount() != 1 {
+			t.Errorf("target %q: unexpected mapping result %+v", r.Target, r)
+		}
+	}
+
+	results = m.SendRequests(results, t.Context())
+	if results[0].Err != nil || results[0].Response == nil || len(contacts.sent) != 1 {
+		t.Errorf("expected ortto-contacts to be sent, got %+v", results[0])
+	}
+	if results[1].Err == nil || results[1].Response != nil {
+		t.Errorf("expected ortto-activities to fail, got %+v", results[1])
+	}
+
+	err = TargetResultsErr(results)
+	if err == nil || !strings.Contains(err.Error(), `target "ortto-activities": ortto unavailable`) || strings.Contains(err.Error(), "ortto-contacts") {
+		t.Errorf("TargetResultsErr = %v", err)
+	}
+}
+
+func TestMultiTargetService_RequiresFetchCampaign(t *testing.T) {
+	t.Parallel()
+	m := newTestMultiTargetService("http://unused", nil, "ortto-contacts", "ortto-activities")
+	for _, s := range m.services {
+		s.mapper = nil
+	}
+	if _, _, err := m.MapFundraisingProfile("p-1", t.Context()); err == nil {
+		t.Error("expected an error before FetchCampaign")
+	}
+	if _, _, err := (&MultiTargetService{}).MapFundraisingProfile("p-1", t.Context()); err == nil {
+		t.Error("expected an error with no targets")
+	}
+}
+
+// dataRecordingOrttoMapper records the data each profile is mapped from.
+type dataRecordingOrttoMapper struct {
+	recordingOrttoMapper
+	fundraiser FundraiserData
+	team       TeamData
+}
+
+func (m *dataRecordingOrttoMapper) MapFundraisingPage(campaign *FundraisingCampaign, data FundraiserData) (OrttoRequest, error) {
+	m.fundraiser = data
+	return m.recordingOrttoMapper.MapFundraisingPage(campaign, data)
+}
+
+func (m *dataRecordingOrttoMapper) MapTeamFundraisingPage(campaign *FundraisingCampaign, data TeamData) (OrttoRequest, error) {
+	m.team = data
+	return m.recordingOrttoMapper.MapTeamFundraisingPage(campaign, data)
+}
+
+func TestMultiTargetService_FetchesForEveryTarget(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch path := r.URL.Path; {
+		case strings.HasSuffix(path, "/donations"):
+			_, _ = w.Write([]byte(`{"data":[{"uuid":"d-1","createdAt":"2026-03-01T00:00:00Z"}]}`))
+		case strings.HasSuffix(path, "/exercise-logs"):
+			_, _ = w.Write([]byte(`{"data":[{"uuid":"e-1","activity":"RUN","date":"2026-03-01T00:00:00Z","distance":1000}]}`))
+		case path == "/v3/profiles/t-1/members":
+			_, _ = w.Write([]byte(`{"data":[{"uuid":"m-1"},{"uuid":"m-2"}]}`))
+		case path == "/v3/profiles/t-1":
+			_, _ = w.Write([]byte(`{"data":{"uuid":"t-1","type":"GROUP"}}`))
+		default:
+			_, _ = fmt.Fprintf(w, `{"data":{"uuid":%q,"type":"INDIVIDUAL"}}`, strings.TrimPrefix(path, "/v3/profiles/"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	contacts := &dataRecordingOrttoMapper{}
+	activities := &dataRecordingOrttoMapper{}
+	m := newTestMultiTargetService(srv.URL, map[string]OrttoMapper{
+		"ortto-contacts":   contacts,
+		"ortto-activities": activities,
+	}, "ortto-contacts", "ortto-activities")
+	m.services[0].incrementalTeams = true
+	second := &m.services[1].sc.Config
+	second.FundraiserExtensions.Streaks.Activity.Days = []int{7}
+	second.FundraiserExtensions.Streaks.Donation.Days = []int{7}
+	second.TeamFieldMappings.Custom.Integers = map[string]string{"int:cm:team-run": "@team.exerciseTotals.RUN"}
+	m.shareFetchNeeds()
+
+	if _, _, err := m.MapByWebhookModel("INDIVIDUAL", "p-1", "CAMPAIGN", "campaign-profile", true, "profile.updated", t.Context()); err != nil {
+		t.Fatalf("MapByWebhookModel returned unexpected error: %v", err)
+	}
+	if got := activities.fundraiser; len(got.Donations.Donations) != 1 || len(got.ExerciseLogs.ExerciseLogs) != 1 {
+		t.Errorf("expected the second target's donations and exercise logs to be fetched, got %+v", got)
+	}
+
+	if _, _, err := m.MapByWebhookModel("INDIVIDUAL", "m-1", "GROUP", "t-1", false, "profile.updated", t.Context()); err != nil {
+		t.Fatalf("MapByWebhookModel returned unexpected error: %v", err)
+	}
+	if got := activities.team; len(got.MemberPages) != 2 || len(got.MemberExerciseLogs["m-2"]) != 1 {
+		t.Errorf("expected the whole team with exercise logs for the second target, got %d members and logs %v", len(got.MemberPages), got.MemberExerciseLogs)
+	}
+}
+
+func TestMultiTargetService_ReportsFailedTeamMembers(t *testing.T) {
+	t.Parallel()
+	srv, _ := newTestRaiselyMembersServer(t, []string{"m-1", "m-2"}, map[string]bool{"m-2": true})
+	contacts := &recordingOrttoMapper{}
+	m := newTestMultiTargetService(srv.URL, map[string]OrttoMapper{"ortto-contacts": contacts}, "ortto-contacts")
+
+	results, _, err := m.MapByWebhookModel("GROUP", "t-1", "CAMPAIGN", "campaign-profile", false, "profile.updated", t.Context())
+	if err != nil {
+		t.Fatalf("MapByWebhookModel returned unexpected error: %v", err)
+	}
+	r := results[0]
+	if r.Err != nil || r.Request == nil || r.Request.ItemCount() != 1 {
+		t.Fatalf("expected the members that succeeded to be mapped without error, got %+v", r)
+	}
+	if len(r.FailedMembers) != 1 || r.FailedMembers[0].P2PID != "m-2" {
+		t.Errorf("FailedMembers = %v, want m-2", r.FailedMembers)
+	}
+
+	results = m.SendRequests(append(results, TargetResult{Target: "ortto-contacts", Request: r.Request, Err: errors.New("mapping failed")}), t.Context())
+	if len(contacts.sent) != 1 || results[0].Response == nil || results[1].Response != nil {
+		t.Errorf("expected only the result without an error to be sent, sent %d", len(contacts.sent))
+	}
+}
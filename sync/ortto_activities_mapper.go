@@ -135,7 +135,7 @@ func (o *OrttoActivitiesMapper) MapFundraisingPage(campaign *FundraisingCampaign
 	}
 
 	o.RaiselyMapper.MapFundraiserFields(data.Page.Source, &activity)
-	if err := o.RaiselyMapper.ApplyFundraiserTransforms(&activity, campaign); err != nil {
+	if err := o.RaiselyMapper.ApplyFundraiserPageTransforms(data.Page, nil, &activity, campaign); err != nil {
 		return orttoRequest, err
 	}
 	// To support people leaving teams we also need to set any team field mappings to empty
@@ -173,7 +173,11 @@ func (o *OrttoActivitiesMapper) MapTeamFundraisingPage(campaign *FundraisingCamp
 		MergeStrategy: 2, // Overwrite existing
 	}
 
-	for _, page := range data.MemberPages {
+	teamPages := data.MemberTeamPages()
+	for i, page := range data.MemberPages {
+		if !data.MapsMember(page) {
+			continue
+		}
 		activity := OrttoActivity{
 			ActivityID: o.Config.API.Settings.OrttoActivityID,
 			Fields:     make(map[string]interface{}),
@@ -181,11 +185,11 @@ func (o *OrttoActivitiesMapper) MapTeamFundraisingPage(campaign *FundraisingCamp
 		}
 
 		o.RaiselyMapper.MapFundraiserFields(page.Source, &activity)
-		o.RaiselyMapper.MapTeamFields(data.TeamPage.Source, &activity)
-		if err := o.RaiselyMapper.ApplyFundraiserTransforms(&activity, campaign); err != nil {
+		o.RaiselyMapper.MapTeamFields(teamPages[i].Source, &activity)
+		if err := o.RaiselyMapper.ApplyFundraiserPageTransforms(page, &teamPages[i], &activity, campaign); err != nil {
 			return result, err
 		}
-		if err := o.RaiselyMapper.ApplyTeamTransforms(page, data.TeamPage, &activity); err != nil {
+		if err := o.RaiselyMapper.ApplyTeamPageTransforms(page, teamPages[i], &activity, campaign); err != nil {
 			return result, err
 		}
 
@@ -242,7 +246,7 @@ func (o *OrttoActivitiesMapper) MapTrackingData(campaign *FundraisingCampaign, d
 	}
 
 	o.RaiselyMapper.MapFundraiserFields(source, &activity)
-	if err := o.RaiselyMapper.ApplyFundraiserTransforms(&activity, campaign); err != nil {
+	if err := o.RaiselyMapper.ApplyFundraiserPageTransforms(FundraisingPage{Source: source}, nil, &activity, campaign); err != nil {
 		return result, err
 	}
 
@@ -0,0 +1,186 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// OrttoMaxItemsPerRequest is the most people (or activities) Ortto
+// accepts in a single merge call.
+const OrttoMaxItemsPerRequest = 100
+
+// OrttoRequestResult is the outcome of one request passed to
+// [Service.SendRequests].
+//
+// Response has the same concrete type SendRequest would have returned
+// for the request (OrttoContactsResponse or OrttoActivitiesResponse), but
+// holds only the per-item results for this request's items, in order.
+// When the batch the request was sent in fails, Err is set and Response
+// carries the batch's Error. Requests with no items are not sent and
+// have a nil Response and Err.
+type OrttoRequestResult struct {
+	Response OrttoResponse
+	Err      error
+}
+
+// SendRequests sends mapped requests to Ortto in as few calls as
+// possible. Requests that Ortto would process identically (same type,
+// Async, MergeBy, MergeStrategy and, for contacts, FindStrategy) are
+// merged, up to [OrttoMaxItemsPerRequest] items per call, and the
+// per-item results are split back out so results[i] corresponds to
+// reqs[i].
+//
+// Every batch is attempted even after a failure. The returned error
+// (errors.Join of per-batch errors) is non-nil if any batch failed; the
+// affected requests carry the error in their result. If Ortto's response
+// does not report one result per item (e.g. for an Async request), the
+// per-request responses are left without item results.
+//
+// With an [ActivityDedupeStore] configured, unchanged activities are
+// dropped first (see [Service.SendRequest]); a request whose activities
+// are all dropped is treated like any other request with no items.
+// FetchCampaign must be called first.
+func (s *Service) SendRequests(reqs []OrttoRequest, ctx context.Context) ([]OrttoRequestResult, error) {
+	if err := s.requireMapper(); err != nil {
+		return nil, err
+	}
+	results, _, err := s.sendRequests(reqs, ctx)
+	return results, err
+}
+
+// sendRequests implements SendRequests, additionally returning the number
+// of Ortto calls made.
+func (s *Service) sendRequests(reqs []OrttoRequest, ctx context.Context) ([]OrttoRequestResult, int, error) {
+	results := make([]OrttoRequestResult, len(reqs))
+	contactResults := make([][]OrttoContactsResult, len(reqs))
+	activityResults := make([][]OrttoActivityIngestResult, len(reqs))
+	var errs []error
+
+	// Unchanged activities are dropped up front, so the requests are
+	// batched (and their results split) as they will actually be sent.
+	sending := make([]OrttoRequest, len(reqs))
+	kept := make([][]dedupedActivity, len(reqs))
+	for i, req := range reqs {
+		sending[i], kept[i], _ = s.dedupeActivities(req, ctx)
+	}
+	reqs = sending
+
+	batches := batchOrttoRequests(reqs, OrttoMaxItemsPerRequest)
+	for _, batch := range batches {
+		if batch.err != nil {
+			results[batch.origins[0]].Err = batch.err
+			errs = append(errs, batch.err)
+			continue
+		}
+
+		resp, err := s.mapper.SendRequest(batch.req, ctx)
+		if err != nil {
+			err = fmt.Errorf("ortto batch of %d items: %w", batch.req.ItemCount(), err)
+			errs = append(errs, err)
+			for _, i := range batch.origins {
+				results[i] = OrttoRequestResult{Response: resp, Err: err}
+			}
+			continue
+		}
+
+		switch r := resp.(type) {
+		case OrttoContactsResponse:
+			if len(r.Results) != len(batch.origins) {
+				log.Printf("Warning: ortto returned %d results for a batch of %d contacts (not splitting results)", len(r.Results), len(batch.origins))
+				break
+			}
+			for k, i := range batch.origins {
+				contactResults[i] = append(contactResults[i], r.Results[k])
+			}
+		case OrttoActivitiesResponse:
+			if len(r.Activities) != len(batch.origins) {
+				log.Printf("Warning: ortto returned %d results for a batch of %d activities (not splitting results)", len(r.Activities), len(batch.origins))
+				break
+			}
+			for k, i := range batch.origins {
+				activityResults[i] = append(activityResults[i], r.Activities[k])
+			}
+		}
+	}
+
+	for i, req := range reqs {
+		if results[i].Err != nil || req == nil || req.ItemCount() == 0 {
+			continue
+		}
+		if _, ok := req.AsOrttoContactsRequest(); ok {
+			results[i].Response = OrttoContactsResponse{Results: contactResults[i]}
+		} else if _, ok := req.AsOrttoActivitiesRequest(); ok {
+			results[i].Response = OrttoActivitiesResponse{Activities: activityResults[i]}
+			s.recordActivities(kept[i], results[i].Response, ctx)
+		}
+	}
+
+	return results, len(batches), errors.Join(errs...)
+}
+
+// orttoBatch is one Ortto call built by batchOrttoRequests.
+type orttoBatch struct {
+	req OrttoRequest
+	// origins[k] is the index into the caller's requests of item k.
+	origins []int
+	// err is set (with a single origin) for a request that cannot be sent.
+	err error
+}
+
+// batchOrttoRequests combines requests that Ortto would process
+// identically into as few batches as possible, each holding at most
+// maxItems items. Batches are ordered by their first item; requests with
+// no items are dropped.
+func batchOrttoRequests(reqs []OrttoRequest, maxItems int) []orttoBatch {
+	var batches []orttoBatch
+	open := make(map[string]int) // envelope key → index of the batch being filled
+
+	// next returns the batch to add an item with the given envelope to,
+	// starting a new one from empty when needed.
+	next := func(key string, empty OrttoRequest) int {
+		b, ok := open[key]
+		if !ok || len(batches[b].origins) >= maxItems {
+			batches = append(batches, orttoBatch{req: empty})
+			b = len(batches) - 1
+			open[key] = b
+		}
+		return b
+	}
+
+	for i, req := range reqs {
+		if req == nil || req.ItemCount() == 0 {
+			continue
+		}
+		if contacts, ok := req.AsOrttoContactsRequest(); ok {
+			key := fmt.Sprintf("contacts|%t|%s|%d|%d", contacts.Async, strings.Join(contacts.MergeBy, ","), contacts.MergeStrategy, contacts.FindStrategy)
+			empty := contacts
+			empty.Contacts = nil
+			for _, contact := range contacts.Contacts {
+				b := next(key, empty)
+				batch, _ := batches[b].req.AsOrttoContactsRequest()
+				batch.Contacts = append(batch.Contacts, contact)
+				batches[b].req = batch
+				batches[b].origins = append(batches[b].origins, i)
+			}
+			continue
+		}
+		if activities, ok := req.AsOrttoActivitiesRequest(); ok {
+			key := fmt.Sprintf("activities|%t|%s|%d", activities.Async, strings.Join(activities.MergeBy, ","), activities.MergeStrategy)
+			empty := activities
+			empty.Activities = nil
+			for _, activity := range activities.Activities {
+				b := next(key, empty)
+				batch, _ := batches[b].req.AsOrttoActivitiesRequest()
+				batch.Activities = append(batch.Activities, activity)
+				batches[b].req = batch
+				batches[b].origins = append(batches[b].origins, i)
+			}
+			continue
+		}
+		batches = append(batches, orttoBatch{req: req, origins: []int{i}, err: fmt.Errorf("unsupported ortto request type %T", req)})
+	}
+	return batches
+}
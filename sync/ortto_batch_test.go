@@ -0,0 +1,99 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func testContactsRequest(mergeBy string, ids ...string) OrttoContactsRequest {
+	req := OrttoContactsRequest{MergeBy: []string{mergeBy}, MergeStrategy: 2}
+	for _, id := range ids {
+		req.Contacts = append(req.Contacts, OrttoContact{Fields: map[string]interface{}{"str::p2p-id": id}})
+	}
+	return req
+}
+
+func TestBatchOrttoRequests(t *testing.T) {
+	t.Parallel()
+	activities := OrttoActivitiesRequest{MergeBy: []string{"str::email"}, Activities: []OrttoActivity{{}}}
+
+	batches := batchOrttoRequests([]OrttoRequest{
+		testContactsRequest("str::email", "a", "b"),
+		activities,
+		testContactsRequest("str::email", "c", "d"),
+		testContactsRequest("str::phone", "e"),
+		testContactsRequest("str::email"),
+		nil,
+	}, 3)
+
+	// email contacts fill a batch of 3 then spill into a second.
+	want := []struct {
+		items   int
+		origins string
+	}{
+		{3, "[0 0 2]"},
+		{1, "[1]"},
+		{1, "[2]"},
+		{1, "[3]"},
+	}
+	if len(batches) != len(want) {
+		t.Fatalf("got %d batches, want %d", len(batches), len(want))
+	}
+	for i, w := range want {
+		if batches[i].req.ItemCount() != w.items || fmt.Sprint(batches[i].origins) != w.origins {
+			t.Errorf("batch %d: %d items from %v, want %d from %s", i, batches[i].req.ItemCount(), batches[i].origins, w.items, w.origins)
+		}
+	}
+	if _, ok := batches[1].req.AsOrttoActivitiesRequest(); !ok {
+		t.Error("activities request should be batched separately")
+	}
+}
+
+func TestSendRequests_SplitsResults(t *testing.T) {
+	t.Parallel()
+	mapper := &recordingOrttoMapper{}
+	svc := &Service{sc: &SyncContext{}, mapper: mapper}
+
+	var reqs []OrttoRequest
+	for i := range OrttoMaxItemsPerRequest + 1 {
+		reqs = append(reqs, testContactsRequest("str::email", fmt.Sprintf("p-%d", i)))
+	}
+	reqs = append(reqs, testContactsRequest("str::email"))
+
+	results, err := svc.SendRequests(reqs, t.Context())
+	if err != nil {
+		t.Fatalf("SendRequests returned unexpected error: %v", err)
+	}
+	if len(mapper.sent) != 2 {
+		t.Errorf("expected 2 Ortto calls for %d items, got %d", OrttoMaxItemsPerRequest+1, len(mapper.sent))
+	}
+	for i := range OrttoMaxItemsPerRequest + 1 {
+		resp, ok := results[i].Response.(OrttoContactsResponse)
+		if !ok || len(resp.Results) != 1 || resp.Results[0].PersonID != fmt.Sprintf("p-%d", i) {
+			t.Fatalf("results[%d] = %+v", i, results[i])
+		}
+	}
+	if last := results[len(results)-1]; last.Response != nil || last.Err != nil {
+		t.Errorf("empty request should not be sent, got %+v", last)
+	}
+}
+
+func TestSendRequests_BatchError(t *testing.T) {
+	t.Parallel()
+	mapper := &recordingOrttoMapper{sendErr: errors.New("ortto unavailable")}
+	svc := &Service{sc: &SyncContext{}, mapper: mapper}
+
+	results, err := svc.SendRequests([]OrttoRequest{
+		testContactsRequest("str::email", "a"),
+		testContactsRequest("str::email", "b"),
+	}, t.Context())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for i, r := range results {
+		if r.Err == nil {
+			t.Errorf("results[%d] should carry the batch error", i)
+		}
+	}
+}
@@ -1,14 +1,11 @@
 package sync
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
-	"strings"
-	"time"
 
 	"github.com/tidwall/gjson"
 )
@@ -34,7 +31,7 @@ func (o *OrttoContactsMapper) MapFundraisingPage(campaign *FundraisingCampaign,
 	var contact OrttoContact
 	contact.Fields = make(map[string]interface{})
 	o.RaiselyMapper.MapFundraiserFields(data.Page.Source, &contact)
-	if err := o.RaiselyMapper.ApplyFundraiserTransforms(&contact, campaign); err != nil {
+	if err := o.RaiselyMapper.ApplyFundraiserPageTransforms(data.Page, nil, &contact, campaign); err != nil {
 		return orttoRequest, err
 	}
 	// To support people leaving teams we also need to set any team field mappings to empty
@@ -54,15 +51,19 @@ func (o *OrttoContactsMapper) MapTeamFundraisingPage(campaign *FundraisingCampai
 		FindStrategy:  0, // Any  - first MergeBy field is prioritised, if a match is not found the second field is then used
 	}
 
-	for _, page := range data.MemberPages {
+	teamPages := data.MemberTeamPages()
+	for i, page := range data.MemberPages {
+		if !data.MapsMember(page) {
+			continue
+		}
 		var contact OrttoContact
 		contact.Fields = make(map[string]interface{})
 		o.RaiselyMapper.MapFundraiserFields(page.Source, &contact)
-		o.RaiselyMapper.MapTeamFields(data.TeamPage.Source, &contact)
-		if err := o.RaiselyMapper.ApplyFundraiserTransforms(&contact, campaign); err != nil {
+		o.RaiselyMapper.MapTeamFields(teamPages[i].Source, &contact)
+		if err := o.RaiselyMapper.ApplyFundraiserPageTransforms(page, &teamPages[i], &contact, campaign); err != nil {
 			return result, err
 		}
-		if err := o.RaiselyMapper.ApplyTeamTransforms(page, data.TeamPage, &contact); err != nil {
+		if err := o.RaiselyMapper.ApplyTeamPageTransforms(page, teamPages[i], &contact, campaign); err != nil {
 			return result, err
 		}
 		result.Contacts = append(result.Contacts, contact)
@@ -97,7 +98,7 @@ func (o *OrttoContactsMapper) MapTrackingData(campaign *FundraisingCampaign, dat
 	var contact OrttoContact
 	contact.Fields = make(map[string]interface{})
 	o.RaiselyMapper.MapFundraiserFields(source, &contact)
-	if err = o.RaiselyMapper.ApplyFundraiserTransforms(&contact, campaign); err != nil {
+	if err = o.RaiselyMapper.ApplyFundraiserPageTransforms(FundraisingPage{Source: source}, nil, &contact, campaign); err != nil {
 		return result, err
 	}
 
@@ -180,40 +181,12 @@ func (o *OrttoContactsMapper) ReconcileFundraisingPage(p2pRegistrationID string,
 		result.ID = contacts[0].ID
 
 		for k, orttoValue := range contacts[0].Fields {
-
-			sourceValue := contact.Fields[k]
-
-			// some fields need specific handling for comparison
-
-			if strings.HasPrefix(k, "geo:") { // Ortto adds an id field to geos (address fields)
-				if geoMap, ok := orttoValue.(map[string]interface{}); ok {
-					delete(geoMap, "id")
-				}
-			}
-
-			if strings.HasPrefix(k, "tme:") { // Ortto returns timestamps in ISO 8601 format
-				if sourceStr, ok := sourceValue.(string); ok {
-					t, err := time.Parse(time.RFC3339, sourceStr)
-					if err != nil {
-						return result, err
-					}
-					sourceValue = t.Format(time.RFC3339)
-				}
-			}
-
-			expected, err := json.Marshal(sourceValue)
+			diff, changed, err := diffOrttoValue(k, contact.Fields[k], orttoValue)
 			if err != nil {
 				return result, err
 			}
-			actual, err := json.Marshal(orttoValue)
-			if err != nil {
-				return result, err
-			}
-			if !bytes.Equal(expected, actual) {
-				result.Fields[k] = OrttoContactDiffField{
-					Actual:   string(actual),
-					Expected: string(expected),
-				}
+			if changed {
+				result.Fields[k] = diff
 			}
 		}
 	}
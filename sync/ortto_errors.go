@@ -19,10 +19,13 @@ import (
 // type-asserts on the nested shape and returns this typed error so
 // callers can [IsRateLimited] and [RetryAfter] without re-parsing.
 //
-// fez itself does NOT retry, sleep, or apply the hint — it surfaces
+// By default fez does NOT retry, sleep, or apply the hint — it surfaces
 // the typed error and returns. The durable wait is the caller's
 // responsibility (record a hold in whatever state store the consumer
 // uses so subsequent gated operations defer until the window passes).
+// Consumers that opt in with a [RetryPolicy] get in-process retries of
+// idempotent calls that honour the hint; this error is still returned
+// once the policy gives up.
 //
 // ResponseHeaders preserves the outgoing edge / gateway attribution
 // headers (Server / Via / CF-* style) — load-bearing for shortening
@@ -48,13 +48,18 @@ func captureNonFezStack() string {
 	return strings.TrimRight(b.String(), "\n")
 }
 
+// ActivityFeedConcurrencyLimit is the number of EnrichCSVRows workers
+// fetching activity feeds at once. Their calls share the process-wide
+// Ortto rate limiter (see [SetOrttoRateLimit]), so raising this does not
+// raise the request rate.
 const ActivityFeedConcurrencyLimit = 5
 
 // ActivityFeedFirstMatchPageSize is the Ortto maximum page size for /v1/person/get/activities.
 const ActivityFeedFirstMatchPageSize = 40
 
 // ActivityFeedFirstMatchPageDelay is the pause between paginated requests for a single contact,
-// to stay within Ortto's documented 1 request/second rate limit.
+// to stay within Ortto's documented 1 request/second rate limit. It is skipped while the
+// Ortto rate limiter is enabled (the default, see SetOrttoRateLimit), which paces the requests instead.
 const ActivityFeedFirstMatchPageDelay = time.Second
 
 // ActivityFeedMode controls how GetActivityFeedForContact fetches activities.
@@ -82,9 +87,13 @@ type OrttoFetcherAndUpdater struct {
 // OrttoAPIBuilder returns a new requests.Builder configured for the Ortto API.
 // The recording path uses Config.Target to distinguish between targets.
 //
-// Three behaviours are layered into every Ortto call this builder
+// Four behaviours are layered into every Ortto call this builder
 // produces:
 //
+//   - **Client-side rate limiting** — each request (and each retry
+//     attempt under a [RetryPolicy]) first waits on the process-wide
+//     per-API-key token bucket (see [SetOrttoRateLimit]), so concurrent
+//     callers stay within the account limit instead of drawing 429s.
 //   - **Rate-limit detection** — a validator runs before ErrorJSON,
 //     intercepting 429 responses whose body carries the nested
 //     `{error:{code:"rate-limit",try-in-seconds:N}}` shape and
@@ -106,16 +115,18 @@ func (o OrttoFetcherAndUpdater) OrttoAPIBuilder() *requests.Builder {
 	result := requests.
 		URL(o.Config.API.Endpoints.Ortto).
 		UserAgent(o.userAgent()).
-		Client(&http.Client{Timeout: HTTPRequestTimeout}).
+		Client(&http.Client{}). // orttoRateLimitTransport applies HTTPRequestTimeout after waiting
 		AddValidator(orttoCallValidator(o.TriggerType))
+	var transport http.RoundTripper
 	if o.RecordRequests {
 		target := o.Config.Target
 		if target == "" {
 			target = "ortto-contacts"
 		}
-		result = result.Transport(requests.Record(nil, fmt.Sprintf("pkg/testdata/.requests/%s/%s", o.Campaign, target)))
+		transport = requests.Record(nil, fmt.Sprintf("pkg/testdata/.requests/%s/%s", o.Campaign, target))
 	}
-	return result
+	transport = &orttoRateLimitTransport{base: transport, limiter: orttoRateLimit}
+	return o.apiTransport(result, transport)
 }
 
 // orttoCallValidator is the validator added to every Ortto request by
@@ -361,8 +372,8 @@ func (o OrttoFetcherAndUpdater) CreateCustomPersonField(name, fieldType string,
 // When mode is ActivityFeedLatest, only the most recent activity is fetched (limit: 1).
 // When mode is ActivityFeedFirstMatch, the feed is paginated with the API's max page size
 // (ActivityFeedFirstMatchPageSize) until meta.has_more is false; results are returned in feed
-// order (most recent first). A short delay is applied between paginated requests to stay
-// within Ortto's 1 request/second rate limit.
+// order (most recent first). Paginated requests are paced by the Ortto rate limiter (or, with
+// it disabled, a short delay) to stay within Ortto's 1 request/second rate limit.
 // When mode is ActivityFeedLatestMatch, the feed is paginated the same way but pagination
 // short-circuits as soon as any entry on the current page satisfies match. Because the API
 // returns entries newest-first, the first page that contains a match has the most recent
@@ -443,7 +454,7 @@ func (o OrttoFetcherAndUpdater) GetActivityFeedForContact(
 	var activities []OrttoActivityFeedEntry
 	offset := 0
 	for page := 0; ; page++ {
-		if page > 0 {
+		if page > 0 && !orttoRateLimit.enabled() {
 			select {
 			case <-ctx.Done():
 				return activities, ctx.Err()
@@ -174,8 +174,11 @@ type OrttoMapper interface {
 // NewOrttoMapper creates an OrttoMapper based on the target specified in the SyncContext's config.
 // If target is empty or "ortto-contacts", it returns an OrttoContactsMapper.
 // If target is "ortto-activities", it returns an OrttoActivitiesMapper.
-func NewOrttoMapper(sc *SyncContext) OrttoMapper {
-	mustBeInitialised()
+// Returns ErrNotInitialised if Init has not been called.
+func NewOrttoMapper(sc *SyncContext) (OrttoMapper, error) {
+	if _, err := initialised(); err != nil {
+		return nil, err
+	}
 
 	orttoFetcherAndUpdater := OrttoFetcherAndUpdater{SyncContext: sc}
 	raiselyMapper := RaiselyMapper{SyncContext: sc}
@@ -186,13 +189,13 @@ func NewOrttoMapper(sc *SyncContext) OrttoMapper {
 			SyncContext:            sc,
 			RaiselyMapper:          raiselyMapper,
 			OrttoFetcherAndUpdater: orttoFetcherAndUpdater,
-		}
+		}, nil
 	default: // "", "ortto-contacts"
 		return &OrttoContactsMapper{
 			SyncContext:            sc,
 			RaiselyMapper:          raiselyMapper,
 			OrttoFetcherAndUpdater: orttoFetcherAndUpdater,
-		}
+		}, nil
 	}
 }
 
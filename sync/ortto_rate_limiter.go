@@ -0,0 +1,198 @@
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	gosync "sync"
+	"time"
+)
+
+// DefaultOrttoRequestsPerSecond and DefaultOrttoBurst are the default
+// process-wide Ortto rate limit: Ortto's documented 1 request/second
+// account limit, with a small burst so a single sync isn't held back.
+const (
+	DefaultOrttoRequestsPerSecond = 1.0
+	DefaultOrttoBurst             = 2
+)
+
+// orttoRateLimit is the process-wide limiter every
+// [OrttoFetcherAndUpdater] call waits on before it is sent.
+var orttoRateLimit = newOrttoRateLimiter(DefaultOrttoRequestsPerSecond, DefaultOrttoBurst)
+
+// SetOrttoRateLimit changes the process-wide Ortto rate limit from the
+// default ([DefaultOrttoRequestsPerSecond], [DefaultOrttoBurst]). Every
+// Ortto API key gets its own token bucket holding up to burst requests,
+// refilled at requestsPerSecond, so concurrent sync and enrichment
+// traffic for one account shares a budget while separate accounts don't
+// slow each other down. SetOrttoRateLimit(0, 0) disables limiting, e.g.
+// when the caller paces Ortto traffic itself.
+//
+// Existing buckets are reset to the new settings. Safe to call while
+// requests are in flight; waits already scheduled are not shortened.
+func SetOrttoRateLimit(requestsPerSecond float64, burst int) {
+	orttoRateLimit.configure(requestsPerSecond, burst)
+}
+
+// OrttoRateLimitStats reports the limiter activity for one Ortto API
+// key. Keys are identified by a fingerprint (a truncated SHA-256) so
+// metrics can be logged or exported without leaking the key.
+type OrttoRateLimitStats struct {
+	KeyFingerprint string
+	Requests       uint64        // requests that passed through the limiter
+	Delayed        uint64        // requests that had to wait for a token
+	Cancelled      uint64        // requests whose context ended while waiting
+	TotalWait      time.Duration // summed wait across delayed requests
+	MaxWait        time.Duration // longest single wait
+}
+
+// OrttoRateLimitMetrics returns a snapshot of the process-wide limiter's
+// per-key stats, ordered by KeyFingerprint.
+func OrttoRateLimitMetrics() []OrttoRateLimitStats {
+	return orttoRateLimit.metrics()
+}
+
+// orttoKeyFingerprint identifies an API key in metrics and logs.
+func orttoKeyFingerprint(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:6])
+}
+
+// orttoRateLimiter holds one token bucket per Ortto API key.
+type orttoRateLimiter struct {
+	mu      gosync.Mutex
+	rate    float64 // tokens per second; <= 0 disables limiting
+	burst   float64
+	buckets map[string]*orttoTokenBucket
+
+	// now and sleep are replaced in tests.
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// orttoTokenBucket is a single key's bucket and stats. Guarded by the
+// limiter's mutex.
+type orttoTokenBucket struct {
+	tokens float64
+	last   time.Time
+	stats  OrttoRateLimitStats
+}
+
+func newOrttoRateLimiter(requestsPerSecond float64, burst int) *orttoRateLimiter {
+	l := &orttoRateLimiter{
+		buckets: make(map[string]*orttoTokenBucket),
+		now:     time.Now,
+		sleep:   sleepContext,
+	}
+	l.configure(requestsPerSecond, burst)
+	return l
+}
+
+func (l *orttoRateLimiter) configure(requestsPerSecond float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = requestsPerSecond
+	l.burst = float64(max(burst, 1))
+	now := l.now()
+	for _, b := range l.buckets {
+		b.tokens = l.burst
+		b.last = now
+	}
+}
+
+// enabled reports whether requests are being paced.
+func (l *orttoRateLimiter) enabled() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate > 0
+}
+
+// wait blocks until apiKey's bucket has a token or ctx is done.
+// Tokens are reserved up front (the bucket may go negative), so waiters
+// are served in arrival order.
+func (l *orttoRateLimiter) wait(ctx context.Context, apiKey string) error {
+	l.mu.Lock()
+	b, ok := l.buckets[apiKey]
+	if !ok {
+		b = &orttoTokenBucket{tokens: l.burst, last: l.now()}
+		b.stats.KeyFingerprint = orttoKeyFingerprint(apiKey)
+		l.buckets[apiKey] = b
+	}
+	b.stats.Requests++
+	if l.rate <= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+
+	now := l.now()
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	delay := time.Duration(-b.tokens / l.rate * float64(time.Second))
+	b.stats.Delayed++
+	b.stats.TotalWait += delay
+	if delay > b.stats.MaxWait {
+		b.stats.MaxWait = delay
+	}
+	l.mu.Unlock()
+
+	if err := l.sleep(ctx, delay); err != nil {
+		l.mu.Lock()
+		b.tokens++ // hand the reservation back
+		b.stats.Cancelled++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (l *orttoRateLimiter) metrics() []OrttoRateLimitStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	result := make([]OrttoRateLimitStats, 0, len(l.buckets))
+	for _, b := range l.buckets {
+		result = append(result, b.stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].KeyFingerprint < result[j].KeyFingerprint
+	})
+	return result
+}
+
+// orttoRateLimitTransport waits on the limiter, keyed by the request's
+// X-Api-Key header, before each request (including each retry attempt)
+// is sent. The [HTTPRequestTimeout] starts once the wait is over, so
+// time queued behind other callers doesn't count against the request;
+// the client it is used with must not set its own Timeout.
+type orttoRateLimitTransport struct {
+	base    http.RoundTripper
+	limiter *orttoRateLimiter
+}
+
+// appliesAttemptTimeout implements attemptTimeoutTransport.
+func (t *orttoRateLimitTransport) appliesAttemptTimeout() {}
+
+// RoundTrip implements http.RoundTripper.
+func (t *orttoRateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.wait(req.Context(), req.Header.Get("X-Api-Key")); err != nil {
+		return nil, err
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), HTTPRequestTimeout)
+	resp, err := base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
@@ -0,0 +1,178 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+)
+
+// defaultOrttoRate and defaultOrttoBurst hold the process-wide limiter's
+// default settings, captured before init disables it: the package tests
+// make many Ortto calls to httptest servers and would otherwise be paced
+// at 1 request/second.
+var defaultOrttoRate, defaultOrttoBurst = orttoRateLimit.rate, orttoRateLimit.burst
+
+func init() {
+	SetOrttoRateLimit(0, 0)
+}
+
+// newTestOrttoRateLimiter returns a limiter on a fake clock that advances
+// by each requested wait instead of sleeping.
+func newTestOrttoRateLimiter(requestsPerSecond float64, burst int) (*orttoRateLimiter, *time.Time) {
+	l := newOrttoRateLimiter(requestsPerSecond, burst)
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	l.sleep = func(ctx context.Context, d time.Duration) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		now = now.Add(d)
+		return nil
+	}
+	return l, &now
+}
+
+func TestOrttoRateLimiter_PacesPerKey(t *testing.T) {
+	t.Parallel()
+	l, now := newTestOrttoRateLimiter(2, 2)
+	start := *now
+
+	for range 4 {
+		if err := l.wait(t.Context(), "key-a"); err != nil {
+			t.Fatalf("wait returned unexpected error: %v", err)
+		}
+	}
+	// Two from the burst, then one every 500ms.
+	if got := now.Sub(start); got != time.Second {
+		t.Errorf("4 requests took %s, want 1s", got)
+	}
+
+	// A different key has its own bucket.
+	before := *now
+	if err := l.wait(t.Context(), "key-b"); err != nil {
+		t.Fatalf("wait returned unexpected error: %v", err)
+	}
+	if *now != before {
+		t.Error("key-b should not wait on key-a's bucket")
+	}
+
+	metrics := l.metrics()
+	if len(metrics) != 2 {
+		t.Fatalf("expected stats for 2 keys, got %d", len(metrics))
+	}
+	for _, m := range metrics {
+		if m.KeyFingerprint != orttoKeyFingerprint("key-a") {
+			continue
+		}
+		if m.Requests != 4 || m.Delayed != 2 || m.TotalWait != time.Second || m.MaxWait != 500*time.Millisecond {
+			t.Errorf("unexpected key-a stats %+v", m)
+		}
+	}
+}
+
+func TestOrttoRateLimiter_CancelledWaitReturnsToken(t *testing.T) {
+	t.Parallel()
+	l, _ := newTestOrttoRateLimiter(1, 1)
+	_ = l.wait(t.Context(), "k")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if err := l.wait(ctx, "k"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if l.buckets["k"].tokens != 0 || l.metrics()[0].Cancelled != 1 {
+		t.Errorf("cancelled reservation should be handed back (tokens=%v)", l.buckets["k"].tokens)
+	}
+}
+
+func TestOrttoRateLimiter_Disabled(t *testing.T) {
+	t.Parallel()
+	l, now := newTestOrttoRateLimiter(0, 0)
+	start := *now
+	for range 10 {
+		_ = l.wait(t.Context(), "k")
+	}
+	if *now != start {
+		t.Error("a disabled limiter should never wait")
+	}
+	if got := l.metrics()[0].Requests; got != 10 {
+		t.Errorf("Requests = %d, want 10", got)
+	}
+}
+
+func TestOrttoRateLimitTransport_KeysByAPIKeyHeader(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(srv.Close)
+	l, _ := newTestOrttoRateLimiter(1, 1)
+
+	err := requests.URL(srv.URL).
+		Header("X-Api-Key", "secret-key").
+		Transport(&orttoRateLimitTransport{limiter: l}).
+		Fetch(t.Context())
+	if err != nil {
+		t.Fatalf("Fetch returned unexpected error: %v", err)
+	}
+	metrics := l.metrics()
+	if len(metrics) != 1 || metrics[0].KeyFingerprint != orttoKeyFingerprint("secret-key") || metrics[0].Requests != 1 {
+		t.Errorf("unexpected metrics %+v", metrics)
+	}
+}
+
+type deadlineRecordingTransport struct {
+	deadline time.Time
+}
+
+func (d *deadlineRecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	d.deadline, _ = req.Context().Deadline()
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+func TestOrttoRateLimitTransport_TimeoutStartsAfterWait(t *testing.T) {
+	t.Parallel()
+	const queued = 50 * time.Millisecond
+	l := newOrttoRateLimiter(1, 1)
+	l.sleep = func(ctx context.Context, d time.Duration) error {
+		time.Sleep(queued) // stands in for a long queue behind other callers
+		return nil
+	}
+	base := &deadlineRecordingTransport{}
+	transport := &orttoRateLimitTransport{base: base, limiter: l}
+
+	_ = l.wait(t.Context(), "k") // use the burst so the next request queues
+	start := time.Now()
+	req, _ := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://ortto.test", nil)
+	req.Header.Set("X-Api-Key", "k")
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip returned unexpected error: %v", err)
+	}
+	_ = resp.Body.Close()
+	if earliest := start.Add(queued + HTTPRequestTimeout); base.deadline.Before(earliest) {
+		t.Errorf("deadline %v includes the queue wait, want at least %v", base.deadline, earliest)
+	}
+}
+
+func TestOrttoRateLimit_PacesByDefault(t *testing.T) {
+	t.Parallel()
+	if defaultOrttoRate != DefaultOrttoRequestsPerSecond || defaultOrttoBurst != DefaultOrttoBurst {
+		t.Fatalf("process-wide limiter defaults to %v/s burst %v, want %v/s burst %v",
+			defaultOrttoRate, defaultOrttoBurst, DefaultOrttoRequestsPerSecond, DefaultOrttoBurst)
+	}
+
+	l, now := newTestOrttoRateLimiter(defaultOrttoRate, int(defaultOrttoBurst))
+	start := *now
+	for range DefaultOrttoBurst + 1 {
+		if err := l.wait(t.Context(), "key"); err != nil {
+			t.Fatalf("wait returned unexpected error: %v", err)
+		}
+	}
+	if waited := now.Sub(start); waited != time.Second {
+		t.Errorf("call after the burst waited %v, want 1s", waited)
+	}
+}
@@ -0,0 +1,222 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PreviewItem is the field-level diff for one contact or activity that a
+// sync would send. Expected values are what fez would send and Actual
+// values what Ortto currently holds, both as JSON.
+type PreviewItem struct {
+	// MergeFieldID and MergeFieldValue identify the Ortto person: the
+	// first of the request's MergeBy fields with a value.
+	MergeFieldID    string
+	MergeFieldValue string
+
+	// PersonID is the matching Ortto person, or "" if the sync would
+	// create a new one (in which case every field is reported).
+	PersonID string
+
+	// Fields holds the person fields that would change.
+	Fields map[string]OrttoContactDiffField
+
+	// ActivityFound reports whether the person already has an activity
+	// of this type (ortto-activities target only).
+	ActivityFound bool
+
+	// Attributes holds the activity attributes that differ from the
+	// person's latest activity of this type, keyed by attribute ID
+	// (ortto-activities target only). fez-managed metadata attributes
+	// (see IsMetaActivityAttribute) are not compared.
+	Attributes map[string]OrttoContactDiffField
+}
+
+// PreviewResult is returned by [Service.Preview].
+type PreviewResult struct {
+	Target  string
+	Request OrttoRequest  // the request a sync would send
+	Items   []PreviewItem // one per contact or activity in Request
+
+	// Referrals holds the referral messages a sync would send to Raisely
+	// (see ProcessReferrals), or nil if there are none.
+	Referrals *ReferralBatch
+}
+
+// Preview maps a profile exactly as MapFundraisingProfile does and diffs
+// the result against Ortto without sending anything: the current person
+// fields for both targets and, for ortto-activities, the attributes of
+// the person's latest activity of the configured type. A team member's
+// profile previews every member of the team (or, with incremental team
+// sync, just that member). Nothing is written back, including to the
+// [TeamPageCache].
+// FetchCampaign must be called first.
+func (s *Service) Preview(profileID string, ctx context.Context) (*PreviewResult, error) {
+	preview := *s
+	fetcher := *s.fetcher
+	if fetcher.TeamPageCache != nil {
+		fetcher.TeamPageCache = readOnlyTeamPageCache{fetcher.TeamPageCache}
+	}
+	preview.fetcher = &fetcher
+
+	req, referrals, err := preview.MapFundraisingProfile(profileID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.previewRequest(req, ctx)
+	if err != nil {
+		return nil, err
+	}
+	result.Referrals = referrals
+	return result, nil
+}
+
+// readOnlyTeamPageCache reads through to a TeamPageCache but drops
+// writes, so Preview maps against the cached team without changing it.
+type readOnlyTeamPageCache struct {
+	TeamPageCache
+}
+
+// Set implements [TeamPageCache] by discarding data.
+func (readOnlyTeamPageCache) Set(context.Context, string, TeamData) error {
+	return nil
+}
+
+// previewRequest diffs each item of a mapped request against Ortto.
+func (s *Service) previewRequest(req OrttoRequest, ctx context.Context) (*PreviewResult, error) {
+	_, ortto := s.buildMappers()
+	result := &PreviewResult{Target: s.sc.Config.Target, Request: req}
+
+	if contacts, ok := req.AsOrttoContactsRequest(); ok {
+		for _, contact := range contacts.Contacts {
+			item, err := previewPerson(ortto, contacts.MergeBy, contact.Fields, ctx)
+			if err != nil {
+				return nil, err
+			}
+			result.Items = append(result.Items, item)
+		}
+		return result, nil
+	}
+
+	activities, ok := req.AsOrttoActivitiesRequest()
+	if !ok {
+		return nil, fmt.Errorf("unsupported ortto request type %T", req)
+	}
+	for _, activity := range activities.Activities {
+		item, err := previewPerson(ortto, activities.MergeBy, activity.Fields, ctx)
+		if err != nil {
+			return nil, err
+		}
+		item.Attributes = make(map[string]OrttoContactDiffField)
+		expected := stripMetaAttributes(activity.Attributes)
+
+		var feed []OrttoActivityFeedEntry
+		if item.PersonID != "" {
+			feed, err = ortto.GetActivityFeedForContact(item.MergeFieldID, item.MergeFieldValue, activity.ActivityID, ActivityFeedLatest, nil, ctx)
+			if err != nil {
+				return nil, err
+			}
+		}
+		// Feed attribute keys can carry a different type prefix to the
+		// mapped ones, so they are matched by name.
+		actual := make(map[string]interface{})
+		if len(feed) > 0 {
+			item.ActivityFound = true
+			for k, v := range feed[0].Attributes {
+				actual[ExtractAttributeName(k)] = v
+			}
+		}
+		for k, v := range expected {
+			diff, changed, err := diffOrttoValue(k, v, actual[ExtractAttributeName(k)])
+			if err != nil {
+				return nil, err
+			}
+			if changed {
+				item.Attributes[k] = diff
+			}
+		}
+		result.Items = append(result.Items, item)
+	}
+	return result, nil
+}
+
+// previewPerson looks up the Ortto person for a mapped field set and
+// diffs the fields.
+func previewPerson(ortto OrttoFetcherAndUpdater, mergeBy []string, fields map[string]interface{}, ctx context.Context) (PreviewItem, error) {
+	item := PreviewItem{Fields: make(map[string]OrttoContactDiffField)}
+	item.MergeFieldID, item.MergeFieldValue = orttoMergeValue(mergeBy, fields)
+
+	var current map[string]interface{}
+	if item.MergeFieldID != "" {
+		fieldIDs := make([]string, 0, len(fields))
+		for k := range fields {
+			fieldIDs = append(fieldIDs, k)
+		}
+		fieldNames, err := json.Marshal(fieldIDs)
+		if err != nil {
+			return item, err
+		}
+		value, err := json.Marshal(item.MergeFieldValue)
+		if err != nil {
+			return item, err
+		}
+		filterJSON := fmt.Sprintf(`{"$str::is":{"field_id":%q,"value":%s}}`, item.MergeFieldID, value)
+		contacts, err := ortto.GetContact(fieldNames, filterJSON, ctx)
+		if err != nil {
+			return item, fmt.Errorf("failed to look up ortto person by %s: %w", item.MergeFieldID, err)
+		}
+		if len(contacts) > 0 {
+			item.PersonID = contacts[0].ID
+			current = contacts[0].Fields
+		}
+	}
+
+	for k, v := range fields {
+		diff, changed, err := diffOrttoValue(k, v, current[k])
+		if err != nil {
+			return item, err
+		}
+		if changed {
+			item.Fields[k] = diff
+		}
+	}
+	return item, nil
+}
+
+// diffOrttoValue compares the value fez would send for field key with
+// the value Ortto holds, allowing for the ways Ortto echoes values back
+// differently to how they were sent.
+func diffOrttoValue(key string, expected, actual interface{}) (OrttoContactDiffField, bool, error) {
+	if strings.HasPrefix(key, "geo:") { // Ortto adds an id field to geos (address fields)
+		if geoMap, ok := actual.(map[string]interface{}); ok {
+			delete(geoMap, "id")
+		}
+	}
+
+	if strings.HasPrefix(key, "tme:") { // Ortto returns timestamps in ISO 8601 format
+		if sourceStr, ok := expected.(string); ok {
+			t, err := time.Parse(time.RFC3339, sourceStr)
+			if err != nil {
+				return OrttoContactDiffField{}, false, err
+			}
+			expected = t.Format(time.RFC3339)
+		}
+	}
+
+	expectedJSON, err := json.Marshal(expected)
+	if err != nil {
+		return OrttoContactDiffField{}, false, err
+	}
+	actualJSON, err := json.Marshal(actual)
+	if err != nil {
+		return OrttoContactDiffField{}, false, err
+	}
+	if bytes.Equal(expectedJSON, actualJSON) {
+		return OrttoContactDiffField{}, false, nil
+	}
+	return OrttoContactDiffField{Actual: string(actualJSON), Expected: string(expectedJSON)}, true, nil
+}
@@ -0,0 +1,200 @@
+package sync
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newTestPreviewService returns a Service whose Ortto calls go to a test
+// server that knows one person, "person-1", with email a@example.com.
+func newTestPreviewService(t *testing.T, target string, feed []OrttoActivityFeedEntry) *Service {
+	t.Helper()
+	srv := newTestOrttoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/person/get":
+			var body struct {
+				Filter struct {
+					Is struct {
+						Value string `json:"value"`
+					} `json:"$str::is"`
+				} `json:"filter"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body.Filter.Is.Value != "a@example.com" {
+				_, _ = w.Write([]byte(`{"contacts":[]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"contacts":[{"id":"person-1","fields":{
+				"str::email":"a@example.com",
+				"str::first":"Ann",
+				"tme:cm:joined":"2026-01-02T03:04:05Z"
+			}}]}`))
+		case "/v1/person/get/activities":
+			_ = json.NewEncoder(w).Encode(OrttoActivityFeedResponse{Activities: feed})
+		default:
+			http.Error(w, "unexpected path: "+r.URL.Path, http.StatusInternalServerError)
+		}
+	})
+
+	sc := &SyncContext{}
+	sc.Config.Target = target
+	sc.Config.API.Endpoints.Ortto = srv.URL
+	sc.Config.API.Keys.Ortto = "test-key"
+	return &Service{sc: sc}
+}
+
+func TestPreview_Contacts(t *testing.T) {
+	t.Parallel()
+	svc := newTestPreviewService(t, "ortto-contacts", nil)
+	req := &OrttoContactsRequest{
+		MergeBy: []string{"str::email"},
+		Contacts: []OrttoContact{
+			{Fields: map[string]interface{}{
+				"str::email":    "a@example.com",
+				"str::first":    "Annie",
+				"tme:cm:joined": "2026-01-02T03:04:05.000Z",
+			}},
+			{Fields: map[string]interface{}{
+				"str::email": "new@example.com",
+				"str::first": "Ned",
+			}},
+		},
+	}
+
+	result, err := svc.previewRequest(req, t.Context())
+	if err != nil {
+		t.Fatalf("previewRequest returned unexpected error: %v", err)
+	}
+	if len(result.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(result.Items))
+	}
+
+	existing := result.Items[0]
+	if existing.PersonID != "person-1" || existing.MergeFieldValue != "a@example.com" {
+		t.Errorf("unexpected person match %+v", existing)
+	}
+	if len(existing.Fields) != 1 {
+		t.Errorf("expected only str::first to differ, got %v", existing.Fields)
+	}
+	if d := existing.Fields["str::first"]; d.Expected != `"Annie"` || d.Actual != `"Ann"` {
+		t.Errorf("unexpected str::first diff %+v", d)
+	}
+
+	created := result.Items[1]
+	if created.PersonID != "" {
+		t.Errorf("expected no matching person, got %q", created.PersonID)
+	}
+	if len(created.Fields) != 2 || created.Fields["str::first"].Actual != "null" {
+		t.Errorf("expected every field reported for a new person, got %v", created.Fields)
+	}
+}
+
+func TestPreview_Activities(t *testing.T) {
+	t.Parallel()
+	feed := []OrttoActivityFeedEntry{{
+		ActivityID: "act:cm:donation",
+		Attributes: map[string]interface{}{
+			"int:cm:amount": 10,
+			"str:cm:team":   "Red",
+		},
+	}}
+	svc := newTestPreviewService(t, "ortto-activities", feed)
+	req := &OrttoActivitiesRequest{
+		MergeBy: []string{"str::email"},
+		Activities: []OrttoActivity{{
+			ActivityID: "act:cm:donation",
+			Fields:     map[string]interface{}{"str::email": "a@example.com"},
+			Attributes: OrttoAttributes{
+				"int:cm:amount":       25,
+				"str:cm:team":         "Red",
+				"obj:cm:sync-context": map[string]interface{}{"trigger": "t-1"},
+			},
+		}},
+	}
+
+	result, err := svc.previewRequest(req, t.Context())
+	if err != nil {
+		t.Fatalf("previewRequest returned unexpected error: %v", err)
+	}
+	if len(result.Items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(result.Items))
+	}
+	item := result.Items[0]
+	if !item.ActivityFound {
+		t.Error("expected the latest activity to be found")
+	}
+	if len(item.Fields) != 0 {
+		t.Errorf("expected no person field changes, got %v", item.Fields)
+	}
+	if len(item.Attributes) != 1 {
+		t.Fatalf("expected only int:cm:amount to differ, got %v", item.Attributes)
+	}
+	if d := item.Attributes["int:cm:amount"]; d.Expected != "25" || d.Actual != "10" {
+		t.Errorf("unexpected int:cm:amount diff %+v", d)
+	}
+}
+
+func TestPreview_DoesNotWriteTeamPageCache(t *testing.T) {
+	t.Parallel()
+	raisely, _ := newTestRaiselyTeamServer(t)
+	ortto := newTestOrttoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"contacts":[]}`))
+	})
+	s := newTestSyncCampaignService(raisely.URL, &dataRecordingOrttoMapper{}, nil)
+	s.sc.Config.API.Endpoints.Ortto = ortto.URL
+	s.incrementalTeams = true
+	cache := NewMemoryTeamPageCache(time.Hour)
+	s.fetcher.TeamPageCache = cache
+
+	result, err := s.Preview("m-1", t.Context())
+	if err != nil {
+		t.Fatalf("Preview returned unexpected error: %v", err)
+	}
+	if len(result.Items) != 1 {
+		t.Errorf("expected 1 item, got %d", len(result.Items))
+	}
+	if _, ok, _ := cache.Get(t.Context(), "t-1"); ok {
+		t.Error("Preview wrote the team to the TeamPageCache")
+	}
+	if s.fetcher.TeamPageCache != cache {
+		t.Error("Preview replaced the service's TeamPageCache")
+	}
+}
+
+func TestPreview_IncludesReferrals(t *testing.T) {
+	t.Parallel()
+	raisely := newTestOrttoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"uuid":"p-1","type":"INDIVIDUAL","private":{"invitations":[
+			{"firstName":"Jane","email":"jane@example.com"}
+		]}}}`))
+	})
+	ortto := newTestOrttoServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"contacts":[]}`))
+	})
+	s := newTestSyncCampaignService(raisely.URL, &recordingOrttoMapper{}, nil)
+	s.sc.Config.API.Endpoints.Ortto = ortto.URL
+	s.sc.Config.API.Settings.RaiselyFundraiserReferralsField = "private.invitations"
+	s.sc.Config.FundraiserReferralFieldMappings = standardMessageMapping
+
+	result, err := s.Preview("p-1", t.Context())
+	if err != nil {
+		t.Fatalf("Preview returned unexpected error: %v", err)
+	}
+	if result.Referrals == nil || len(result.Referrals.Messages) != 1 {
+		t.Fatalf("expected 1 referral message, got %+v", result.Referrals)
+	}
+}
+
+// ReconcileFundraisingPage has always rejected a timestamp field mapped
+// to a value that is not RFC3339, including an empty one.
+func TestDiffOrttoValue_EmptyTimestampErrors(t *testing.T) {
+	t.Parallel()
+	if _, _, err := diffOrttoValue("tme:cm:joined", "", "2026-01-02T03:04:05Z"); err == nil {
+		t.Error("expected an error for an empty timestamp")
+	}
+	if _, changed, err := diffOrttoValue("tme:cm:joined", "2026-01-02T03:04:05Z", "2026-01-02T03:04:05Z"); err != nil || changed {
+		t.Errorf("expected an equal timestamp to match, got changed=%v err=%v", changed, err)
+	}
+}
@@ -13,7 +13,8 @@ import (
 // carry **no `Retry-After` header** and **empty bodies**, so there is
 // no hint to honour. Callers self-time the back-off (~30s + jitter)
 // at the [PlaceRateLimitHold] site rather than reading a `TryInSeconds`
-// from this error.
+// from this error, or opt in to a [RetryPolicy] whose RateLimitDelay
+// applies the same back-off to idempotent calls in-process.
 //
 // ResponseHeaders preserves the outgoing edge / gateway attribution
 // headers (Server / Via / CF-* style) so a future incident can be
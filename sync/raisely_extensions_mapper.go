@@ -2,6 +2,9 @@ package sync
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 )
 
 // RaiselyExtensionsMapper handles computing and writing extension data back to Raisely.
@@ -64,3 +67,49 @@ func (r *RaiselyExtensionsMapper) MapTeamFundraisingPageForExtensions(campaign *
 
 	return updateFundraisingPageRequests, nil
 }
+
+// liveProfileStatus is the Raisely status of a profile shown on the
+// campaign's public leaderboard.
+const liveProfileStatus = "ACTIVE"
+
+// MapCampaignForLeaderboard ranks every live individual and team in the
+// campaign for the configured leaderboard extensions, walking the campaign
+// as FetchProfilesSince does, and returns UpdateRaiselyDataRequests for the
+// profiles whose rank or percentile changed. Draft, archived and inactive
+// profiles are left out, as on the campaign's public leaderboard. Returns
+// nothing without fetching when no leaderboard is configured.
+//
+// Only supported for the Raisely2Ortto flavour.
+func (r *RaiselyExtensionsMapper) MapCampaignForLeaderboard(ctx context.Context) ([]UpdateRaiselyDataRequest, error) {
+	if isInitialisedAs(Funraisin2Ortto) {
+		return nil, errors.New("MapCampaignForLeaderboard requires the Raisely2Ortto flavour")
+	}
+	individuals := r.Config.FundraiserExtensions.Leaderboard
+	teams := r.Config.TeamExtensions.Leaderboard
+	if !individuals.IsConfigured() && !teams.IsConfigured() {
+		return nil, nil
+	}
+
+	var individualEntries, teamEntries []LeaderboardEntry
+	// Private fields are included so ranks mapped to private paths can
+	// be compared with the current values.
+	for profile, err := range r.RaiselyFetcherAndUpdater.fetchProfilesSince(r.Campaign, time.Time{}, true, ctx) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list profiles: %w", err)
+		}
+		switch {
+		case profile.Status != liveProfileStatus:
+		case profile.Type == "INDIVIDUAL" && individuals.IsConfigured():
+			individualEntries = append(individualEntries, NewLeaderboardEntry(individuals, profile))
+		case profile.Type == "GROUP" && teams.IsConfigured():
+			teamEntries = append(teamEntries, NewLeaderboardEntry(teams, profile))
+		}
+	}
+
+	result, err := ApplyRaiselyLeaderboardExtension(individuals, individualEntries)
+	if err != nil {
+		return result, err
+	}
+	teamResult, err := ApplyRaiselyLeaderboardExtension(teams, teamEntries)
+	return append(result, teamResult...), err
+}
@@ -2,11 +2,14 @@ package sync
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"iter"
 	"log"
 	"net/http"
 	"slices"
+	"strconv"
 	"strings"
 	gosync "sync"
 	"time"
@@ -38,14 +41,19 @@ type FundraisingPage struct {
 type Source struct {
 	data   gjson.Result
 	parent *Source // optional parent for ^. path traversal
+	team   *Source // optional team aggregates for @team. paths (see TeamData.MemberTeamPages)
 }
 
 // resolve returns the Source and path to query. Paths prefixed with "^."
-// are resolved against the parent Source (if set), stripping the prefix.
+// are resolved against the parent Source (if set), and paths prefixed
+// with "@team." against the team aggregates (if set), stripping the prefix.
 func (s Source) resolve(path string) (Source, string) {
 	if strings.HasPrefix(path, "^.") && s.parent != nil {
 		return *s.parent, strings.TrimPrefix(path, "^.")
 	}
+	if strings.HasPrefix(path, teamAggregatesPrefix) && s.team != nil {
+		return *s.team, strings.TrimPrefix(path, teamAggregatesPrefix)
+	}
 	return s, path
 }
 
@@ -97,16 +105,43 @@ type CampaignDefault struct {
 	Value string
 }
 
+// FundraisingProfilesSince is one page of campaign profiles updated
+// after Timestamp, sorted by updatedAt ascending. Offset skips that many
+// profiles within the window; see
+// [RaiselyFetcherAndUpdater.FetchProfilesSince] for the paging strategy.
 type FundraisingProfilesSince struct {
 	Timestamp time.Time
+	Offset    int
+	Private   bool                 // include each profile's private fields
 	Results   []FundraisingProfile `json:"data"`
 }
 
+// FundraisingProfileExerciseLogs is a profile's full exercise log
+// history, newest first. When From is set, paging stops once entries
+// predate it (older entries on the final page may still be present).
 type FundraisingProfileExerciseLogs struct {
+	From         time.Time          `json:"-"`
 	ExerciseLogs []ExerciseLogEntry `json:"data"`
 }
 
+// inWindow returns the entries dated on or after From, dropping the
+// older ones paging may have returned (every entry if From is zero).
+func (l FundraisingProfileExerciseLogs) inWindow() []ExerciseLogEntry {
+	if l.From.IsZero() {
+		return l.ExerciseLogs
+	}
+	var result []ExerciseLogEntry
+	for _, entry := range l.ExerciseLogs {
+		if t, err := time.Parse(time.RFC3339, entry.Date); err == nil && t.Before(l.From) {
+			continue
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
 type ExerciseLogEntry struct {
+	UUID     string  `json:"uuid"`
 	Activity string  `json:"activity"`
 	Date     string  `json:"date"`
 	Distance float64 `json:"distance"`
@@ -153,11 +188,16 @@ func (e ExerciseLogEntry) TimestampForStreak() string {
 	return e.Date
 }
 
+// FundraisingProfileDonations is a profile's full donation history,
+// newest first. When From is set, paging stops once donations were
+// created before it.
 type FundraisingProfileDonations struct {
+	From      time.Time  `json:"-"`
 	Donations []Donation `json:"data"`
 }
 
 type Donation struct {
+	UUID string `json:"uuid"`
 	User struct {
 		Uuid string `json:"uuid"`
 	} `json:"user"`
@@ -187,8 +227,25 @@ type FundraisingProfile struct {
 	Status    string `json:"status"`
 	Type      string `json:"type"`
 	UpdatedAt string `json:"updatedAt"`
+
+	// Source is the full profile, for values beyond the fields above
+	// (e.g. total for the leaderboard extension).
+	Source Source `json:"-"`
 }
 
+// UnmarshalJSON decodes a profile, keeping the full JSON in Source.
+func (p *FundraisingProfile) UnmarshalJSON(data []byte) error {
+	type profile FundraisingProfile
+	if err := json.Unmarshal(data, (*profile)(p)); err != nil {
+		return err
+	}
+	p.Source = Source{data: gjson.ParseBytes(data)}
+	return nil
+}
+
+// Webhook is the body Raisely POSTs to a configured webhook URL. Use
+// [VerifyWebhook] to check the Secret and reject stale or replayed
+// events before processing.
 type Webhook struct {
 	Secret string `json:"secret"`
 	Data   struct {
@@ -223,6 +280,26 @@ func (p *FundraisingPage) fetchRaiselyData(params fetchRaiselyDataParams) error
 	return err
 }
 
+// MarshalJSON encodes the page as its raw Raisely JSON.
+func (p FundraisingPage) MarshalJSON() ([]byte, error) {
+	if p.Source.data.Raw == "" {
+		return []byte("null"), nil
+	}
+	return []byte(p.Source.data.Raw), nil
+}
+
+// UnmarshalJSON decodes a page encoded by MarshalJSON.
+func (p *FundraisingPage) UnmarshalJSON(data []byte) error {
+	if !gjson.ValidBytes(data) {
+		return errors.New("invalid fundraising page json")
+	}
+	p.Source = Source{}
+	if string(data) != "null" {
+		p.Source.data = gjson.Parse(string(data))
+	}
+	return nil
+}
+
 func (p FundraisingPage) HasSameOwnerAs(other FundraisingPage) (bool, error) {
 	owner, exists := p.Source.StringForPath("user.uuid")
 	if !exists {
@@ -290,12 +367,19 @@ func (c *FundraisingCampaign) fetchRaiselyData(params fetchRaiselyDataParams) er
 
 func (p *FundraisingProfilesSince) fetchRaiselyData(params fetchRaiselyDataParams) error {
 	raiselyError := RaiselyError{}
-	err := params.RaiselyAPIBuilder.
+	builder := params.RaiselyAPIBuilder.
 		Pathf("/v3/campaigns/%s/profiles", params.P2PID).
 		Param("updatedAtAfter", p.Timestamp.Format(FundraisingProfilesSinceTimestampFormat)).
 		Param("sort", "updatedAt").
 		Param("order", "ASC").
-		Param("limit", FundraisingProfilesSinceLimit).
+		Param("limit", FundraisingProfilesSinceLimit)
+	if p.Private {
+		builder = builder.Param("private", "true")
+	}
+	if p.Offset > 0 {
+		builder = builder.Param("offset", strconv.Itoa(p.Offset))
+	}
+	err := builder.
 		Bearer(params.RaiselyAPIKey).
 		ToJSON(p).
 		ErrorJSON(&raiselyError).
@@ -320,39 +404,77 @@ func (p FundraisingProfile) TeamP2PID(fundraisingCampaign *FundraisingCampaign)
 }
 
 func (d *FundraisingProfileDonations) fetchRaiselyData(params fetchRaiselyDataParams) error {
-	raiselyError := RaiselyError{}
-	err := params.RaiselyAPIBuilder.
-		Pathf("/v3/profiles/%s/donations", params.P2PID).
-		Param("private", "true").
-		Param("limit", FundraisingProfileDonationsLimit).
-		Bearer(params.RaiselyAPIKey).
-		ToJSON(d).
-		ErrorJSON(&raiselyError).
-		Fetch(params.Context)
-	if err != nil {
-		log.Printf("Raisely Error: %+v", raiselyError)
-	}
-
+	donations, err := fetchRaiselyHistory(params, "/v3/profiles/%s/donations", FundraisingProfileDonationsLimit, "createdAt", d.From,
+		func(e Donation) (string, string) { return e.UUID, e.CreatedAt })
+	d.Donations = donations
 	return err
 }
 
 func (d *FundraisingProfileExerciseLogs) fetchRaiselyData(params fetchRaiselyDataParams) error {
-	raiselyError := RaiselyError{}
-	err := params.RaiselyAPIBuilder.
-		Pathf("/v3/profiles/%s/exercise-logs", params.P2PID).
-		Param("private", "true").
-		Param("limit", FundraisingProfileExerciseLogsLimit).
-		Bearer(params.RaiselyAPIKey).
-		ToJSON(d).
-		ErrorJSON(&raiselyError).
-		Fetch(params.Context)
-	if err != nil {
-		log.Printf("Raisely Error: %+v", raiselyError)
-	}
-
+	logs, err := fetchRaiselyHistory(params, "/v3/profiles/%s/exercise-logs", FundraisingProfileExerciseLogsLimit, "date", d.From,
+		func(e ExerciseLogEntry) (string, string) { return e.UUID, e.Date })
+	d.ExerciseLogs = logs
 	return err
 }
 
+// fetchRaiselyHistory pages through a profile's history endpoint (path
+// is formatted with the profile P2P ID) newest first using limit/offset,
+// until a short page is returned or, when from is set, the oldest entry
+// on a page predates it. key returns an entry's UUID and sort timestamp;
+// entries already collected are skipped by UUID, since entries created
+// mid-walk shift later pages.
+func fetchRaiselyHistory[T any](params fetchRaiselyDataParams, path string, limit string, sortField string, from time.Time, key func(T) (string, string)) ([]T, error) {
+	pageSize, _ := strconv.Atoi(limit)
+	var result []T
+	seen := map[string]bool{}
+
+	for offset := 0; ; {
+		raiselyError := RaiselyError{}
+		var page struct {
+			Data []T `json:"data"`
+		}
+		builder := params.RaiselyAPIBuilder.Clone().
+			Pathf(path, params.P2PID).
+			Param("private", "true").
+			Param("sort", sortField).
+			Param("order", "DESC").
+			Param("limit", limit)
+		if offset > 0 {
+			builder = builder.Param("offset", strconv.Itoa(offset))
+		}
+		err := builder.
+			Bearer(params.RaiselyAPIKey).
+			ToJSON(&page).
+			ErrorJSON(&raiselyError).
+			Fetch(params.Context)
+		if err != nil {
+			log.Printf("Raisely Error: %+v", raiselyError)
+			return result, err
+		}
+
+		for _, entry := range page.Data {
+			if id, _ := key(entry); id != "" {
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+			}
+			result = append(result, entry)
+		}
+
+		if len(page.Data) < pageSize {
+			return result, nil
+		}
+		if !from.IsZero() {
+			_, oldest := key(page.Data[len(page.Data)-1])
+			if t, err := time.Parse(time.RFC3339, oldest); err == nil && t.Before(from) {
+				return result, nil
+			}
+		}
+		offset += len(page.Data)
+	}
+}
+
 // RaiselyFetcherAndUpdater handles fetching data from the Raisely API.
 // It embeds *SyncContext for shared sync configuration.
 //
@@ -369,6 +491,16 @@ type RaiselyFetcherAndUpdater struct {
 	// [ServiceWithFundraisingCampaignCache] or directly on a struct
 	// literal); nil disables caching.
 	FundraisingCampaignCache FundraisingCampaignCache
+
+	// TeamPageCache supplies cross-call cache state for CachedTeamData.
+	// Set on construction (via [ServiceWithTeamPageCache] or directly on
+	// a struct literal); nil disables caching.
+	TeamPageCache TeamPageCache
+
+	// TeamMembersConcurrency caps the member pages FetchTeamMembers
+	// fetches at once. Set via [ServiceWithTeamMembersConcurrency]; zero
+	// means DefaultTeamMembersFetchConcurrency.
+	TeamMembersConcurrency int
 }
 
 // FetchFundraisingCampaign fetches the campaign data from Raisely.
@@ -392,29 +524,9 @@ func (r *RaiselyFetcherAndUpdater) FetchFundraisingCampaign(p2pID string, ctx co
 // and treated as a miss; Set errors are logged and the fresh value is
 // returned regardless.
 func (r *RaiselyFetcherAndUpdater) CachedFundraisingCampaign(p2pID string, refresh bool, ctx context.Context) (*FundraisingCampaign, error) {
-	cache := r.FundraisingCampaignCache
-	if cache == nil {
+	return cachedFundraisingCampaign(r.FundraisingCampaignCache, p2pID, r.Config.EnvVar.Org(), refresh, func() (*FundraisingCampaign, error) {
 		return r.FetchFundraisingCampaign(p2pID, ctx)
-	}
-
-	if !refresh {
-		hit, ok, err := cache.Get(ctx, p2pID)
-		if err != nil {
-			log.Printf("FundraisingCampaignCache.Get(%s): %v (treating as miss)", p2pID, err)
-		} else if ok {
-			return hit, nil
-		}
-	}
-
-	fresh, err := r.FetchFundraisingCampaign(p2pID, ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	if err := cache.Set(ctx, p2pID, r.Config.EnvVar.Org(), fresh); err != nil {
-		log.Printf("FundraisingCampaignCache.Set(%s): %v (continuing)", p2pID, err)
-	}
-	return fresh, nil
+	}, ctx)
 }
 
 // FundraiserData holds the fetched data for a single fundraiser.
@@ -428,6 +540,55 @@ type TeamData struct {
 	Team        FundraisingTeam
 	TeamPage    FundraisingPage
 	MemberPages []FundraisingPage
+
+	// FailedMembers lists members whose page could not be fetched; they
+	// are not in MemberPages, so mapping the team syncs everyone else.
+	FailedMembers []TeamMemberError `json:"-"`
+
+	// ChangedMembers limits mapping to these members (by P2PID), as for
+	// a member event with incremental team sync; team-level values still
+	// see every member. nil maps every member.
+	ChangedMembers []string `json:"-"`
+
+	// MemberExerciseLogs holds each member's exercise logs by P2PID, for
+	// @team.exerciseTotals, back to the activity window (see fetchNeeds).
+	// nil unless Config.MapTeamExerciseTotals.
+	MemberExerciseLogs map[string][]ExerciseLogEntry
+}
+
+// MapsMember reports whether page is one of the members to map (see
+// ChangedMembers).
+func (d TeamData) MapsMember(page FundraisingPage) bool {
+	if d.ChangedMembers == nil {
+		return true
+	}
+	p2pID, _ := page.Source.StringForPath("uuid")
+	return slices.Contains(d.ChangedMembers, p2pID)
+}
+
+// withMemberPage returns d with page replacing the page of the same
+// member, or added as a new member, leaving d's slices unchanged.
+func (d TeamData) withMemberPage(page FundraisingPage) TeamData {
+	p2pID, _ := page.Source.StringForPath("uuid")
+	d.MemberPages = slices.Clone(d.MemberPages)
+	for i, existing := range d.MemberPages {
+		if uuid, _ := existing.Source.StringForPath("uuid"); uuid == p2pID {
+			d.MemberPages[i] = page
+			return d
+		}
+	}
+	d.MemberPages = append(d.MemberPages, page)
+	if !slices.ContainsFunc(d.Team.TeamMembers, func(m TeamMember) bool { return m.P2PID == p2pID }) {
+		d.Team.TeamMembers = append(slices.Clone(d.Team.TeamMembers), TeamMember{P2PID: p2pID})
+	}
+	return d
+}
+
+// MembersErr returns a *TeamMembersError for FailedMembers, or nil if
+// every member was fetched.
+func (d TeamData) MembersErr() error {
+	team, _ := d.TeamPage.Source.StringForPath("uuid")
+	return TeamMembersResult{Pages: d.MemberPages, Failed: d.FailedMembers}.Err(team)
 }
 
 // RaiselyAPIKey returns the Raisely API key from the config.
@@ -460,10 +621,11 @@ func (r *RaiselyFetcherAndUpdater) RaiselyAPIBuilder() *requests.Builder {
 		UserAgent(r.userAgent()).
 		Client(&http.Client{Timeout: HTTPRequestTimeout}).
 		AddValidator(raiselyCallValidator(r.TriggerType))
+	var transport http.RoundTripper
 	if r.RecordRequests {
-		apiBuilder = apiBuilder.Transport(requests.Record(nil, fmt.Sprintf("pkg/testdata/.requests/%s/raisely", r.Campaign)))
+		transport = requests.Record(nil, fmt.Sprintf("pkg/testdata/.requests/%s/raisely", r.Campaign))
 	}
-	return apiBuilder
+	return r.apiTransport(apiBuilder, transport)
 }
 
 // raiselyCallValidator is the validator added to every Raisely request
@@ -534,7 +696,9 @@ func (r *RaiselyFetcherAndUpdater) FetchFundraiserData(p2pID string, ctx context
 		errPage = result.Page.fetchRaiselyData(r.fetchParams(p2pID, ctx))
 	}()
 
-	if r.Config.MapActivityLogs() {
+	needs := r.fetchNeeds()
+	if needs.activityLogs {
+		result.ExerciseLogs.From = needs.activityLogsFrom
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
@@ -542,7 +706,7 @@ func (r *RaiselyFetcherAndUpdater) FetchFundraiserData(p2pID string, ctx context
 		}()
 	}
 
-	if r.Config.MapDonations() {
+	if needs.donations {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
@@ -559,16 +723,18 @@ func (r *RaiselyFetcherAndUpdater) FetchFundraiserData(p2pID string, ctx context
 }
 
 // FetchTeamData fetches a team, its fundraising page, and all member pages.
+// Members whose page cannot be fetched are left out of MemberPages and
+// listed in FailedMembers; it is only an error if every member fails.
 func (r *RaiselyFetcherAndUpdater) FetchTeamData(p2pTeamID string, ctx context.Context) (TeamData, error) {
 	team, teamPage, err := r.FetchTeam(p2pTeamID, ctx)
 	if err != nil {
 		return TeamData{}, err
 	}
-	memberPages, err := r.FetchTeamMembers(team, ctx)
-	if err != nil {
-		return TeamData{}, err
+	members := r.FetchTeamMembers(team, ctx)
+	if len(members.Pages) == 0 && len(members.Failed) > 0 {
+		return TeamData{}, members.Err(p2pTeamID)
 	}
-	return TeamData{Team: team, TeamPage: teamPage, MemberPages: memberPages}, nil
+	return TeamData{Team: team, TeamPage: teamPage, MemberPages: members.Pages, FailedMembers: members.Failed, MemberExerciseLogs: members.ExerciseLogs}, nil
 }
 
 // FetchTeam fetches a team and its fundraising page.
@@ -595,38 +761,207 @@ func (r *RaiselyFetcherAndUpdater) FetchTeam(p2pTeamID string, ctx context.Conte
 	return team, teamPage, nil
 }
 
-// FetchTeamMembers fetches fundraising pages for all members of a team.
-func (r *RaiselyFetcherAndUpdater) FetchTeamMembers(team FundraisingTeam, ctx context.Context) ([]FundraisingPage, error) {
+// DefaultTeamMembersFetchConcurrency is the most member pages
+// FetchTeamMembers fetches from Raisely at once, unless
+// TeamMembersConcurrency is set.
+const DefaultTeamMembersFetchConcurrency = 10
+
+// TeamMemberError records a team member whose page could not be fetched.
+type TeamMemberError struct {
+	P2PID string
+	Err   error
+}
+
+func (e TeamMemberError) Error() string {
+	return fmt.Sprintf("team member %s: %v", e.P2PID, e.Err)
+}
+
+func (e TeamMemberError) Unwrap() error {
+	return e.Err
+}
+
+// TeamMembersResult is returned by FetchTeamMembers: the pages fetched,
+// in team order, and the members that failed. ExerciseLogs is set (by
+// member P2PID) when the team's exercise totals are mapped.
+type TeamMembersResult struct {
+	Pages        []FundraisingPage
+	Failed       []TeamMemberError
+	ExerciseLogs map[string][]ExerciseLogEntry
+}
+
+// Err returns a *TeamMembersError for the failed members of team, or nil
+// if none failed.
+func (r TeamMembersResult) Err(team string) error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+	return &TeamMembersError{Team: team, Members: len(r.Pages) + len(r.Failed), Failed: r.Failed}
+}
+
+// TeamMembersError reports team members that could not be fetched. A
+// team is still mapped for the rest (see [TeamData.FailedMembers]), and
+// FailedP2PIDs lists the members to retry.
+type TeamMembersError struct {
+	Team    string
+	Members int // team size, including the failed members
+	Failed  []TeamMemberError
+}
+
+func (e *TeamMembersError) Error() string {
+	return fmt.Sprintf("failed to fetch %d of %d members of team %s: %v", len(e.Failed), e.Members, e.Team, errors.Join(e.Unwrap()...))
+}
+
+func (e *TeamMembersError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, f := range e.Failed {
+		errs[i] = f
+	}
+	return errs
+}
+
+// FailedP2PIDs returns the IDs of the members that could not be fetched.
+func (e *TeamMembersError) FailedP2PIDs() []string {
+	ids := make([]string, len(e.Failed))
+	for i, f := range e.Failed {
+		ids[i] = f.P2PID
+	}
+	return ids
+}
+
+// FetchTeamMembers fetches fundraising pages for all members of a team,
+// at most TeamMembersConcurrency (or [DefaultTeamMembersFetchConcurrency])
+// at a time, along with their exercise logs within the activity window
+// if the config maps @team.exerciseTotals. A failed member does not stop
+// the others.
+func (r *RaiselyFetcherAndUpdater) FetchTeamMembers(team FundraisingTeam, ctx context.Context) TeamMembersResult {
+	var result TeamMembersResult
 	if len(team.TeamMembers) == 0 {
-		return nil, nil
+		return result
 	}
 
 	pages := make([]FundraisingPage, len(team.TeamMembers))
+	logs := make([]FundraisingProfileExerciseLogs, len(team.TeamMembers))
 	errs := make([]error, len(team.TeamMembers))
+	needs := r.fetchNeeds()
+	withLogs := needs.teamExerciseTotals
 
+	concurrency := r.TeamMembersConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultTeamMembersFetchConcurrency
+	}
 	var wg gosync.WaitGroup
+	sem := make(chan struct{}, concurrency)
 	for i, member := range team.TeamMembers {
 		wg.Add(1)
+		sem <- struct{}{}
 		go func(index int, memberP2PID string) {
 			defer wg.Done()
+			defer func() { <-sem }()
 			errs[index] = pages[index].fetchRaiselyData(r.fetchParams(memberP2PID, ctx))
+			if errs[index] == nil && withLogs {
+				logs[index].From = needs.activityLogsFrom
+				errs[index] = logs[index].fetchRaiselyData(r.fetchParams(memberP2PID, ctx))
+			}
 		}(i, member.P2PID)
 	}
 	wg.Wait()
 
-	if err := errors.Join(errs...); err != nil {
-		return pages, fmt.Errorf("raisely errors: %w", err)
+	if withLogs {
+		result.ExerciseLogs = make(map[string][]ExerciseLogEntry)
 	}
-	return pages, nil
+	for i, member := range team.TeamMembers {
+		if errs[i] != nil {
+			result.Failed = append(result.Failed, TeamMemberError{P2PID: member.P2PID, Err: fmt.Errorf("raisely errors: %w", errs[i])})
+			continue
+		}
+		result.Pages = append(result.Pages, pages[i])
+		if withLogs {
+			result.ExerciseLogs[member.P2PID] = logs[i].inWindow()
+		}
+	}
+	return result
+}
+
+// FetchProfilesSince streams every fundraising profile in the campaign
+// updated after the given timestamp, in updatedAt order, paging through
+// Raisely until the window is exhausted. Iteration stops at the first
+// error, which is yielded with a zero FundraisingProfile; breaking out of
+// the loop stops paging.
+//
+// Each page after the first restarts the window just before the last
+// profile's UpdatedAt rather than using a growing offset, so profiles
+// that are updated mid-walk (and move to the end of the sort order) are
+// neither skipped nor counted twice. Profiles sharing that boundary
+// timestamp are de-duplicated by UUID. If an entire page shares one
+// timestamp the window cannot advance past it, so paging switches to
+// offsets within that timestamp straight away.
+//
+//	for profile, err := range fetcher.FetchProfilesSince(campaignID, since, ctx) {
+//		if err != nil { return err }
+//		...
+//	}
+func (r *RaiselyFetcherAndUpdater) FetchProfilesSince(campaignP2PID string, since time.Time, ctx context.Context) iter.Seq2[FundraisingProfile, error] {
+	return r.fetchProfilesSince(campaignP2PID, since, false, ctx)
 }
 
-// FetchProfilesSince fetches fundraising profiles updated after the given timestamp.
-func (r *RaiselyFetcherAndUpdater) FetchProfilesSince(campaignP2PID string, since time.Time, ctx context.Context) (FundraisingProfilesSince, error) {
-	profiles := FundraisingProfilesSince{
-		Timestamp: since,
+// fetchProfilesSince implements FetchProfilesSince, optionally including
+// each profile's private fields.
+func (r *RaiselyFetcherAndUpdater) fetchProfilesSince(campaignP2PID string, since time.Time, private bool, ctx context.Context) iter.Seq2[FundraisingProfile, error] {
+	return func(yield func(FundraisingProfile, error) bool) {
+		pageSize, _ := strconv.Atoi(FundraisingProfilesSinceLimit)
+		after := since
+		offset := 0
+		boundary := ""                      // UpdatedAt of the most recent profile yielded
+		seenAtBoundary := map[string]bool{} // UUIDs already yielded with that UpdatedAt
+
+		for {
+			page := FundraisingProfilesSince{Timestamp: after, Offset: offset, Private: private}
+			if err := page.fetchRaiselyData(r.fetchParams(campaignP2PID, ctx)); err != nil {
+				yield(FundraisingProfile{}, err)
+				return
+			}
+
+			for _, profile := range page.Results {
+				if profile.UpdatedAt != boundary {
+					boundary = profile.UpdatedAt
+					clear(seenAtBoundary)
+				}
+				if seenAtBoundary[profile.P2PID] {
+					continue
+				}
+				seenAtBoundary[profile.P2PID] = true
+				if !yield(profile, nil) {
+					return
+				}
+			}
+
+			if len(page.Results) < pageSize {
+				return
+			}
+
+			last := page.Results[len(page.Results)-1]
+			lastUpdatedAt, err := time.Parse(time.RFC3339, last.UpdatedAt)
+			if err != nil {
+				yield(FundraisingProfile{}, fmt.Errorf("profile %s has invalid updatedAt %q: %w", last.P2PID, last.UpdatedAt, err))
+				return
+			}
+			// Step back 1ms (the timestamp format's precision) so the next
+			// window includes any remaining profiles at the boundary.
+			next := lastUpdatedAt.Add(-time.Millisecond).In(after.Location())
+			switch {
+			case next.Equal(after):
+				offset += len(page.Results)
+			case page.Results[0].UpdatedAt == last.UpdatedAt:
+				// The whole page shares one timestamp, so it leads the
+				// next window: skip it rather than fetching it again.
+				after = next
+				offset = len(page.Results)
+			default:
+				after = next
+				offset = 0
+			}
+		}
 	}
-	err := profiles.fetchRaiselyData(r.fetchParams(campaignP2PID, ctx))
-	return profiles, err
 }
 
 func (r *RaiselyFetcherAndUpdater) UpdateRaiselyData(request UpdateRaiselyDataRequest, ctx context.Context) (int, error) {
@@ -707,10 +1042,11 @@ func (r *RaiselyFetcherAndUpdater) RaiselyMessagesAPIBuilder() *requests.Builder
 		UserAgent(r.userAgent()).
 		Client(&http.Client{Timeout: HTTPRequestTimeout}).
 		AddValidator(raiselyCallValidator(r.TriggerType))
+	var transport http.RoundTripper
 	if r.RecordRequests {
-		apiBuilder = apiBuilder.Transport(requests.Record(nil, fmt.Sprintf("pkg/testdata/.requests/%s/raisely-messages", r.Campaign)))
+		transport = requests.Record(nil, fmt.Sprintf("pkg/testdata/.requests/%s/raisely-messages", r.Campaign))
 	}
-	return apiBuilder
+	return r.apiTransport(apiBuilder, transport)
 }
 
 // MapFundraiserReferrals reads the referrals array from the fundraiser
@@ -3,11 +3,15 @@ package sync
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -262,3 +266,388 @@ func TestRaiselyMessagesAPIBuilder_SetsUserAgentAndEmitsCallLog(t *testing.T) {
 		}
 	}
 }
+
+// newTestRaiselyProfilesServer serves /v3/campaigns/{id}/profiles from
+// profiles (sorted by updatedAt, then uuid), honouring updatedAtAfter
+// (exclusive), limit and offset the way Raisely does. Returns the
+// number of requests served via calls.
+func newTestRaiselyProfilesServer(t *testing.T, profiles []FundraisingProfile) (*httptest.Server, *int) {
+	t.Helper()
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		q := r.URL.Query()
+		after, err := time.Parse(FundraisingProfilesSinceTimestampFormat, q.Get("updatedAtAfter"))
+		if err != nil {
+			t.Errorf("invalid updatedAtAfter %q: %v", q.Get("updatedAtAfter"), err)
+		}
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		offset, _ := strconv.Atoi(q.Get("offset"))
+
+		var window []FundraisingProfile
+		for _, p := range profiles {
+			updatedAt, _ := time.Parse(time.RFC3339, p.UpdatedAt)
+			if updatedAt.After(after) {
+				window = append(window, p)
+			}
+		}
+		window = window[min(offset, len(window)):]
+		window = window[:min(limit, len(window))]
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": window})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+// TestFetchProfilesSince_PagesThroughWindow verifies every profile is
+// yielded exactly once across multiple pages, including a run of
+// profiles sharing one updatedAt that is larger than a page.
+func TestFetchProfilesSince_PagesThroughWindow(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	var profiles []FundraisingProfile
+	add := func(at time.Time, n int) {
+		for range n {
+			profiles = append(profiles, FundraisingProfile{
+				P2PID:     fmt.Sprintf("p-%05d", len(profiles)),
+				Type:      "INDIVIDUAL",
+				UpdatedAt: at.Format(time.RFC3339Nano),
+			})
+		}
+	}
+	for i := range 900 {
+		add(base.Add(time.Duration(i)*time.Second), 1)
+	}
+	add(base.Add(time.Hour), 1300) // more ties than fit in one page
+	for i := range 400 {
+		add(base.Add(2*time.Hour+time.Duration(i)*time.Millisecond), 1)
+	}
+
+	srv, calls := newTestRaiselyProfilesServer(t, profiles)
+	fetcher := newTestRaiselyAPIFetcher(srv.URL, "test", "")
+
+	seen := map[string]int{}
+	for profile, err := range fetcher.FetchProfilesSince("campaign", base.Add(-time.Second), t.Context()) {
+		if err != nil {
+			t.Fatalf("FetchProfilesSince yielded unexpected error: %v", err)
+		}
+		seen[profile.P2PID]++
+	}
+
+	if len(seen) != len(profiles) {
+		t.Fatalf("yielded %d distinct profiles, want %d", len(seen), len(profiles))
+	}
+	for id, n := range seen {
+		if n != 1 {
+			t.Errorf("profile %s yielded %d times", id, n)
+		}
+	}
+	if *calls < 3 {
+		t.Errorf("expected multiple pages, got %d calls", *calls)
+	}
+}
+
+// TestFetchProfilesSince_SharedTimestampPageFetchedOnce verifies a page
+// whose profiles all share one updatedAt is not fetched a second time
+// when paging moves on to offsets within that timestamp.
+func TestFetchProfilesSince_SharedTimestampPageFetchedOnce(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	pageSize, _ := strconv.Atoi(FundraisingProfilesSinceLimit)
+	var profiles []FundraisingProfile
+	for i := range pageSize + 10 {
+		at := base
+		if i >= pageSize {
+			at = base.Add(time.Second)
+		}
+		profiles = append(profiles, FundraisingProfile{
+			P2PID:     fmt.Sprintf("p-%05d", i),
+			UpdatedAt: at.Format(time.RFC3339),
+		})
+	}
+	srv, calls := newTestRaiselyProfilesServer(t, profiles)
+	fetcher := newTestRaiselyAPIFetcher(srv.URL, "test", "")
+
+	n := 0
+	for _, err := range fetcher.FetchProfilesSince("campaign", base.Add(-time.Second), t.Context()) {
+		if err != nil {
+			t.Fatalf("FetchProfilesSince yielded unexpected error: %v", err)
+		}
+		n++
+	}
+	if n != len(profiles) {
+		t.Errorf("yielded %d profiles, want %d", n, len(profiles))
+	}
+	if *calls != 2 {
+		t.Errorf("expected 2 calls, got %d", *calls)
+	}
+}
+
+// TestFetchProfilesSince_StopsOnBreak verifies breaking out of the loop
+// stops paging.
+func TestFetchProfilesSince_StopsOnBreak(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	var profiles []FundraisingProfile
+	for i := range 2500 {
+		profiles = append(profiles, FundraisingProfile{
+			P2PID:     fmt.Sprintf("p-%05d", i),
+			UpdatedAt: base.Add(time.Duration(i) * time.Second).Format(time.RFC3339),
+		})
+	}
+	srv, calls := newTestRaiselyProfilesServer(t, profiles)
+	fetcher := newTestRaiselyAPIFetcher(srv.URL, "test", "")
+
+	n := 0
+	for _, err := range fetcher.FetchProfilesSince("campaign", base.Add(-time.Second), t.Context()) {
+		if err != nil {
+			t.Fatalf("FetchProfilesSince yielded unexpected error: %v", err)
+		}
+		n++
+		if n == 10 {
+			break
+		}
+	}
+	if *calls != 1 {
+		t.Errorf("expected 1 call after breaking early, got %d", *calls)
+	}
+}
+
+// TestFetchProfilesSince_YieldsError verifies a Raisely error is yielded
+// and ends iteration.
+func TestFetchProfilesSince_YieldsError(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"errors":[{"message":"boom"}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	fetcher := newTestRaiselyAPIFetcher(srv.URL, "test", "")
+
+	var errs int
+	for _, err := range fetcher.FetchProfilesSince("campaign", time.Now(), t.Context()) {
+		if err == nil {
+			t.Fatal("expected only an error to be yielded")
+		}
+		errs++
+	}
+	if errs != 1 {
+		t.Fatalf("expected 1 error, got %d", errs)
+	}
+}
+
+// newTestRaiselyHistoryServer serves a profile history endpoint from
+// entries (already newest first), honouring limit and offset. Any other
+// path gets an empty profile. Returns the number of history requests
+// served via calls.
+func newTestRaiselyHistoryServer[T any](t *testing.T, path string, entries []T) (*httptest.Server, *int) {
+	t.Helper()
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			_, _ = w.Write([]byte(`{"data":{}}`))
+			return
+		}
+		calls++
+		q := r.URL.Query()
+		if q.Get("order") != "DESC" {
+			t.Errorf("order = %q, want DESC", q.Get("order"))
+		}
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		page := entries[min(offset, len(entries)):]
+		page = page[:min(limit, len(page))]
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": page})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+// TestFetchFundraiserData_PagesHistory verifies exercise logs and
+// donations beyond a single page are all fetched, and that exercise logs
+// stop paging once entries predate Streaks.Activity.From.
+func TestFetchFundraiserData_PagesHistory(t *testing.T) {
+	t.Parallel()
+	latest := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	logs := make([]ExerciseLogEntry, 2500)
+	for i := range logs {
+		logs[i] = ExerciseLogEntry{
+			UUID:     fmt.Sprintf("log-%d", i),
+			Activity: "RUN",
+			Date:     latest.Add(-time.Duration(i) * time.Hour).Format(time.RFC3339),
+			Distance: 1000,
+		}
+	}
+
+	t.Run("full history", func(t *testing.T) {
+		t.Parallel()
+		srv, calls := newTestRaiselyHistoryServer(t, "/v3/profiles/p-1/exercise-logs", logs)
+		fetcher := newTestRaiselyAPIFetcher(srv.URL, "test", "")
+
+		var got FundraisingProfileExerciseLogs
+		if err := got.fetchRaiselyData(fetcher.fetchParams("p-1", t.Context())); err != nil {
+			t.Fatalf("fetchRaiselyData returned unexpected error: %v", err)
+		}
+		if len(got.ExerciseLogs) != len(logs) {
+			t.Fatalf("fetched %d exercise logs, want %d", len(got.ExerciseLogs), len(logs))
+		}
+		if *calls != 3 {
+			t.Errorf("expected 3 pages, got %d calls", *calls)
+		}
+	})
+
+	t.Run("stops before streak window", func(t *testing.T) {
+		t.Parallel()
+		srv, calls := newTestRaiselyHistoryServer(t, "/v3/profiles/p-1/exercise-logs", logs)
+		fetcher := newTestRaiselyAPIFetcher(srv.URL, "test", "")
+		fetcher.Config.FundraiserExtensions.Streaks.Activity.Days = []int{3}
+		fetcher.Config.FundraiserExtensions.Streaks.Activity.From = latest.Add(-500 * time.Hour).Format(time.RFC3339)
+
+		data, err := fetcher.FetchFundraiserData("p-1", t.Context())
+		if err != nil {
+			t.Fatalf("FetchFundraiserData returned unexpected error: %v", err)
+		}
+		if len(data.ExerciseLogs.ExerciseLogs) != 1000 {
+			t.Errorf("fetched %d exercise logs, want the first page of 1000", len(data.ExerciseLogs.ExerciseLogs))
+		}
+		if *calls != 1 {
+			t.Errorf("expected paging to stop after 1 page, got %d calls", *calls)
+		}
+	})
+
+	t.Run("team members stop before window", func(t *testing.T) {
+		t.Parallel()
+		srv, calls := newTestRaiselyHistoryServer(t, "/v3/profiles/m-1/exercise-logs", logs)
+		fetcher := newTestRaiselyAPIFetcher(srv.URL, "test", "")
+		fetcher.Config.TeamFieldMappings.Custom.Integers = map[string]string{"int:cm:team-run": "@team.exerciseTotals.RUN"}
+		fetcher.Config.FundraiserExtensions.Streaks.Activity.From = latest.Add(-500 * time.Hour).Format(time.RFC3339)
+
+		result := fetcher.FetchTeamMembers(FundraisingTeam{TeamMembers: []TeamMember{{P2PID: "m-1"}}}, t.Context())
+		if len(result.Failed) > 0 {
+			t.Fatalf("FetchTeamMembers failed for %v", result.Failed)
+		}
+		if got := len(result.ExerciseLogs["m-1"]); got != 501 {
+			t.Errorf("kept %d exercise logs, want the 501 within the window", got)
+		}
+		if *calls != 1 {
+			t.Errorf("expected paging to stop after 1 page, got %d calls", *calls)
+		}
+	})
+
+	t.Run("donations", func(t *testing.T) {
+		t.Parallel()
+		donations := make([]Donation, 1001)
+		for i := range donations {
+			donations[i].UUID = fmt.Sprintf("don-%d", i)
+			donations[i].CreatedAt = latest.Add(-time.Duration(i) * time.Hour).Format(time.RFC3339)
+			donations[i].Amount = 100
+		}
+		srv, calls := newTestRaiselyHistoryServer(t, "/v3/profiles/p-1/donations", donations)
+		fetcher := newTestRaiselyAPIFetcher(srv.URL, "test", "")
+
+		var got FundraisingProfileDonations
+		if err := got.fetchRaiselyData(fetcher.fetchParams("p-1", t.Context())); err != nil {
+			t.Fatalf("fetchRaiselyData returned unexpected error: %v", err)
+		}
+		if len(got.Donations) != len(donations) {
+			t.Fatalf("fetched %d donations, want %d", len(got.Donations), len(donations))
+		}
+		if *calls != 2 {
+			t.Errorf("expected 2 pages, got %d calls", *calls)
+		}
+	})
+}
+
+// newTestRaiselyMembersServer serves team t-1 with the given members.
+// Members in failing return a 500. The peak number of member pages being
+// served at once is recorded.
+func newTestRaiselyMembersServer(t *testing.T, members []string, failing map[string]bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var inFlight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/profiles/t-1/members":
+			data := make([]TeamMember, len(members))
+			for i, m := range members {
+				data[i] = TeamMember{P2PID: m}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+		case "/v3/profiles/t-1":
+			_, _ = w.Write([]byte(`{"data":{"uuid":"t-1","type":"GROUP"}}`))
+		default:
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			uuid := strings.TrimPrefix(r.URL.Path, "/v3/profiles/")
+			if failing[uuid] {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`{"errors":[{"message":"boom"}]}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]string{"uuid": uuid}})
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &peak
+}
+
+func TestFetchTeamMembers_BoundedWithPartialFailures(t *testing.T) {
+	t.Parallel()
+	members := []string{"m-1", "m-2", "m-3", "m-4", "m-5", "m-6"}
+	srv, peak := newTestRaiselyMembersServer(t, members, map[string]bool{"m-2": true, "m-5": true})
+	fetcher := newTestRaiselyAPIFetcher(srv.URL, "test", "")
+	fetcher.TeamMembersConcurrency = 2
+
+	var team FundraisingTeam
+	for _, m := range members {
+		team.TeamMembers = append(team.TeamMembers, TeamMember{P2PID: m})
+	}
+	result := fetcher.FetchTeamMembers(team, t.Context())
+
+	var got []string
+	for _, page := range result.Pages {
+		uuid, _ := page.Source.StringForPath("uuid")
+		got = append(got, uuid)
+	}
+	if strings.Join(got, ",") != "m-1,m-3,m-4,m-6" {
+		t.Errorf("pages = %v, want the members that succeeded in team order", got)
+	}
+	var membersErr *TeamMembersError
+	if err := result.Err("t-1"); !errors.As(err, &membersErr) {
+		t.Fatalf("Err = %v, want a *TeamMembersError", err)
+	}
+	if ids := strings.Join(membersErr.FailedP2PIDs(), ","); ids != "m-2,m-5" || membersErr.Members != 6 {
+		t.Errorf("failed = %s of %d", ids, membersErr.Members)
+	}
+	if p := peak.Load(); p > 2 {
+		t.Errorf("fetched %d member pages at once, want at most 2", p)
+	}
+}
+
+func TestService_MapsPartiallyFetchedTeam(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+
+	srv, _ := newTestRaiselyMembersServer(t, []string{"m-1", "m-2"}, map[string]bool{"m-2": true})
+	s := newTestSyncCampaignService(srv.URL, &recordingOrttoMapper{}, nil)
+	req, _, err := s.MapByWebhookModel("GROUP", "t-1", "CAMPAIGN", "campaign-profile", false, "profile.updated", ctx)
+	if err != nil {
+		t.Fatalf("MapByWebhookModel returned unexpected error: %v", err)
+	}
+	if req == nil || req.ItemCount() != 1 {
+		t.Errorf("expected a request for the member that succeeded, got %v", req)
+	}
+
+	srv, _ = newTestRaiselyMembersServer(t, []string{"m-1", "m-2"}, map[string]bool{"m-1": true, "m-2": true})
+	s = newTestSyncCampaignService(srv.URL, &recordingOrttoMapper{}, nil)
+	req, _, err = s.MapByWebhookModel("GROUP", "t-1", "CAMPAIGN", "campaign-profile", false, "profile.updated", ctx)
+	if err == nil || req != nil {
+		t.Errorf("expected no request when every member fails, got %v, %v", req, err)
+	}
+}
@@ -28,15 +28,31 @@ func (m *RaiselyMapper) ClearTeamFields(destination Mappable) {
 }
 
 // ApplyFundraiserTransforms applies fundraiser field transforms and maps them to the provided destination.
+// Transforms that read the page see an empty one; use ApplyFundraiserPageTransforms to pass it.
 func (m *RaiselyMapper) ApplyFundraiserTransforms(destination Mappable, campaign *FundraisingCampaign) error {
+	return m.ApplyFundraiserPageTransforms(FundraisingPage{}, nil, destination, campaign)
+}
+
+// ApplyFundraiserPageTransforms applies fundraiser field transforms for page and maps them to the provided destination.
+// teampage is nil unless page is being mapped as a team member.
+func (m *RaiselyMapper) ApplyFundraiserPageTransforms(page FundraisingPage, teampage *FundraisingPage, destination Mappable, campaign *FundraisingCampaign) error {
 	return ApplyFundraiserFieldTransforms(ApplyFundraiserFieldTransformsParams{
 		Config:      m.Config,
 		Campaign:    campaign,
+		Page:        page,
+		TeamPage:    teampage,
 		Destination: destination,
 	})
 }
 
 // ApplyTeamTransforms applies team field transforms and maps them to the provided destination.
+// Campaign-dependent transforms (e.g. blankIfDefault) see no campaign; use ApplyTeamPageTransforms to pass it.
 func (m *RaiselyMapper) ApplyTeamTransforms(page, teampage FundraisingPage, destination Mappable) error {
 	return ApplyTeamFieldTransforms(m.Config, page, teampage, destination)
 }
+
+// ApplyTeamPageTransforms applies team field transforms for page, a member of teampage, and maps them to the
+// provided destination.
+func (m *RaiselyMapper) ApplyTeamPageTransforms(page, teampage FundraisingPage, destination Mappable, campaign *FundraisingCampaign) error {
+	return applyTeamFieldTransforms(m.Config, campaign, page, teampage, destination)
+}
@@ -0,0 +1,274 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+)
+
+// RetryPolicy configures the opt-in retry layer fez adds to the Ortto,
+// Raisely and Funraisin API builders (see [ServiceWithRetryPolicy]).
+// Without a policy fez never retries: rate limits surface as
+// [*OrttoRateLimitError] / [*RaiselyRateLimitError] and the caller owns
+// the wait.
+//
+// With a policy, a request is re-sent after:
+//
+//   - an Ortto rate-limit 429, waiting the body's `try-in-seconds` hint
+//     (RateLimitDelay with jitter when the hint is absent);
+//   - a Raisely edge 429 (no hint), waiting RateLimitDelay with jitter;
+//   - a 5xx response or a transport error, waiting BaseDelay doubled per
+//     attempt (capped at MaxDelay) with jitter, if the request is
+//     Retryable.
+//
+// A 429 means the request was not applied, so it is retried whatever
+// the method; Retryable only gates retries after a 5xx or transport
+// error, where a write may already have been applied.
+//
+// Retries stop after MaxAttempts, when the next wait would take the
+// cumulative wait past MaxTotalWait, or when the request context is
+// done. The last response is then returned as if no retry layer existed,
+// so the usual typed errors still reach the caller. Each attempt gets its
+// own [HTTPRequestTimeout].
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// Values below 2 disable retries.
+	MaxAttempts int
+
+	// MaxTotalWait caps the cumulative time spent waiting between
+	// attempts. Zero means no cap beyond MaxAttempts.
+	MaxTotalWait time.Duration
+
+	// BaseDelay is the first wait after a 5xx or transport error.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the exponential BaseDelay back-off. Zero means no cap.
+	MaxDelay time.Duration
+
+	// RateLimitDelay is the wait after a 429 that carries no hint.
+	RateLimitDelay time.Duration
+
+	// Retryable reports whether a request may be re-sent after a 5xx or
+	// transport error. nil means [IsIdempotentRequest]; override it to
+	// opt non-idempotent calls in (e.g. Ortto merges with an overwrite
+	// strategy).
+	Retryable func(req *http.Request) bool
+}
+
+// DefaultRetryPolicy returns the policy recommended for webhook and sync
+// traffic: up to 4 attempts within 2 minutes, ~30s (plus jitter) after a
+// Raisely edge 429 as advised by Raisely.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    4,
+		MaxTotalWait:   2 * time.Minute,
+		BaseDelay:      time.Second,
+		MaxDelay:       15 * time.Second,
+		RateLimitDelay: 30 * time.Second,
+	}
+}
+
+// orttoReadPaths are the Ortto endpoints that only read data. Ortto
+// uses POST for these, so they are not idempotent by method alone.
+var orttoReadPaths = []string{
+	"/v1/person/get",
+	"/v1/person/get/activities",
+	"/v1/person/custom-field/get",
+}
+
+// IsIdempotentRequest is the default [RetryPolicy] Retryable check: GET,
+// HEAD, OPTIONS, PUT and DELETE requests, plus POSTs to Ortto's read-only
+// endpoints (/v1/person/get and friends). Writes such as Ortto merges,
+// activity creation, Raisely profile PATCHes and custom messages are not
+// retried after a 5xx or transport error, since a request that timed out
+// may already have been applied. They are still retried after a 429.
+func IsIdempotentRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	case http.MethodPost:
+		for _, path := range orttoReadPaths {
+			if strings.HasSuffix(req.URL.Path, path) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// apiTransport wires rt (the builder's transport; nil for the default)
+// and the SyncContext's RetryPolicy into an API builder. With a policy
+// the client-wide [HTTPRequestTimeout] is replaced by a per-attempt one,
+// so waiting between attempts doesn't eat into the request timeout.
+func (sc *SyncContext) apiTransport(builder *requests.Builder, rt http.RoundTripper) *requests.Builder {
+	if sc.RetryPolicy == nil {
+		if rt != nil {
+			builder = builder.Transport(rt)
+		}
+		return builder
+	}
+	return builder.
+		Client(&http.Client{}).
+		Transport(newRetryTransport(rt, *sc.RetryPolicy))
+}
+
+// retryTransport is the http.RoundTripper implementing RetryPolicy.
+type retryTransport struct {
+	base   http.RoundTripper
+	policy RetryPolicy
+
+	// sleep and jitter are replaced in tests.
+	sleep  func(ctx context.Context, d time.Duration) error
+	jitter func(d time.Duration) time.Duration
+}
+
+func newRetryTransport(base http.RoundTripper, policy RetryPolicy) *retryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if policy.Retryable == nil {
+		policy.Retryable = IsIdempotentRequest
+	}
+	return &retryTransport{
+		base:   base,
+		policy: policy,
+		sleep:  sleepContext,
+		jitter: func(d time.Duration) time.Duration {
+			return d + rand.N(d/2+1) // up to +50%
+		},
+	}
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	resendable := t.policy.MaxAttempts > 1 &&
+		(req.Body == nil || req.Body == http.NoBody || req.GetBody != nil)
+	idempotent := t.policy.Retryable(req)
+	var waited time.Duration
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(ctx)
+			attemptReq.Body = body
+		}
+		attemptCtx, cancel := context.WithTimeout(ctx, HTTPRequestTimeout)
+		if _, ok := t.base.(attemptTimeoutTransport); ok {
+			attemptCtx, cancel = context.WithCancel(ctx)
+		}
+		resp, err := t.base.RoundTrip(attemptReq.WithContext(attemptCtx))
+
+		var delay time.Duration
+		var reason string
+		retryable := resendable && idempotent
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				cancel()
+				return nil, err
+			}
+			delay, reason = t.backoff(attempt), err.Error()
+		case resp.StatusCode == http.StatusTooManyRequests:
+			// Rate limited requests were not applied, so any may be re-sent.
+			retryable = resendable
+			delay, reason = t.rateLimitDelay(resp), "status 429"
+		case resp.StatusCode >= 500:
+			delay, reason = t.backoff(attempt), fmt.Sprintf("status %d", resp.StatusCode)
+		}
+
+		if reason == "" || !retryable || attempt >= t.policy.MaxAttempts ||
+			(t.policy.MaxTotalWait > 0 && waited+delay > t.policy.MaxTotalWait) {
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+		cancel()
+
+		log.Printf("Warning: retrying %s %s after %s (attempt %d of %d, waiting %s)",
+			req.Method, req.URL.Path, reason, attempt+1, t.policy.MaxAttempts, delay)
+		if err := t.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+		waited += delay
+	}
+}
+
+// backoff returns the jittered exponential wait before attempt+1.
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	delay := t.policy.BaseDelay << (attempt - 1)
+	if t.policy.MaxDelay > 0 && (delay > t.policy.MaxDelay || delay <= 0) {
+		delay = t.policy.MaxDelay
+	}
+	return t.jitter(delay)
+}
+
+// rateLimitDelay returns Ortto's try-in-seconds hint from a 429 body, or
+// the jittered RateLimitDelay when there is none. The body is restored
+// for the caller in case this is the last attempt.
+func (t *retryTransport) rateLimitDelay(resp *http.Response) time.Duration {
+	body, err := peekResponseBody(resp)
+	if err == nil {
+		var decoded struct {
+			Error struct {
+				TryInSeconds int `json:"try-in-seconds"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(body, &decoded) == nil && decoded.Error.TryInSeconds > 0 {
+			return time.Duration(decoded.Error.TryInSeconds) * time.Second
+		}
+	}
+	return t.jitter(t.policy.RateLimitDelay)
+}
+
+// attemptTimeoutTransport is implemented by base transports that apply
+// the per-attempt [HTTPRequestTimeout] themselves, after a client-side
+// wait (see orttoRateLimitTransport), so retryTransport doesn't.
+type attemptTimeoutTransport interface {
+	appliesAttemptTimeout()
+}
+
+// cancelOnClose releases an attempt's timeout context once the caller has
+// finished reading the response.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
@@ -0,0 +1,213 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+)
+
+// newTestRetryServer replies with statuses[i] (and bodies[i], if any) to
+// the i-th request, then 200 for every request after that. It records
+// each request body.
+func newTestRetryServer(t *testing.T, statuses []int, bodies []string) (*httptest.Server, *[]string) {
+	t.Helper()
+	var received []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, string(body))
+		i := len(received) - 1
+		if i >= len(statuses) {
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+		w.WriteHeader(statuses[i])
+		if i < len(bodies) {
+			_, _ = w.Write([]byte(bodies[i]))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &received
+}
+
+// newTestRetryTransport returns a retryTransport with no jitter that
+// records its waits instead of sleeping.
+func newTestRetryTransport(policy RetryPolicy) (*retryTransport, *[]time.Duration) {
+	var waits []time.Duration
+	rt := newRetryTransport(nil, policy)
+	rt.jitter = func(d time.Duration) time.Duration { return d }
+	rt.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	return rt, &waits
+}
+
+func TestRetryTransport(t *testing.T) {
+	t.Parallel()
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, RateLimitDelay: 30 * time.Second}
+	orttoRateLimit := `{"error":{"code":"rate-limit","message":"slow down","try-in-seconds":7}}`
+
+	cases := []struct {
+		name      string
+		policy    RetryPolicy
+		method    string
+		path      string
+		statuses  []int
+		bodies    []string
+		wantCalls int
+		wantWaits []time.Duration
+		wantErr   bool
+	}{
+		{
+			name:      "ortto hint on read endpoint",
+			policy:    policy,
+			method:    http.MethodPost,
+			path:      "/v1/person/get",
+			statuses:  []int{http.StatusTooManyRequests},
+			bodies:    []string{orttoRateLimit},
+			wantCalls: 2,
+			wantWaits: []time.Duration{7 * time.Second},
+		},
+		{
+			name:      "raisely 429 without hint",
+			policy:    policy,
+			method:    http.MethodGet,
+			path:      "/v3/profiles/p-1",
+			statuses:  []int{http.StatusTooManyRequests},
+			wantCalls: 2,
+			wantWaits: []time.Duration{30 * time.Second},
+		},
+		{
+			name:      "5xx backs off exponentially",
+			policy:    policy,
+			method:    http.MethodGet,
+			path:      "/v3/profiles/p-1",
+			statuses:  []int{http.StatusBadGateway, http.StatusServiceUnavailable},
+			wantCalls: 3,
+			wantWaits: []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:      "gives up after max attempts",
+			policy:    policy,
+			method:    http.MethodGet,
+			path:      "/v3/profiles/p-1",
+			statuses:  []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			wantCalls: 3,
+			wantWaits: []time.Duration{time.Second, 2 * time.Second},
+			wantErr:   true,
+		},
+		{
+			name:      "stops at max total wait",
+			policy:    RetryPolicy{MaxAttempts: 5, BaseDelay: 10 * time.Second, MaxTotalWait: 15 * time.Second},
+			method:    http.MethodGet,
+			path:      "/v3/profiles/p-1",
+			statuses:  []int{http.StatusBadGateway, http.StatusBadGateway},
+			wantCalls: 2,
+			wantWaits: []time.Duration{10 * time.Second},
+			wantErr:   true,
+		},
+		{
+			name:      "non-idempotent write is not retried",
+			policy:    policy,
+			method:    http.MethodPost,
+			path:      "/v1/activities/create",
+			statuses:  []int{http.StatusServiceUnavailable},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "non-idempotent write is retried after a 429",
+			policy:    policy,
+			method:    http.MethodPost,
+			path:      "/v1/activities/create",
+			statuses:  []int{http.StatusTooManyRequests},
+			bodies:    []string{orttoRateLimit},
+			wantCalls: 2,
+			wantWaits: []time.Duration{7 * time.Second},
+		},
+		{
+			name:      "success is not retried",
+			policy:    policy,
+			method:    http.MethodGet,
+			path:      "/v3/profiles/p-1",
+			wantCalls: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			srv, received := newTestRetryServer(t, tc.statuses, tc.bodies)
+			rt, waits := newTestRetryTransport(tc.policy)
+
+			builder := requests.URL(srv.URL).Path(tc.path).Method(tc.method).Transport(rt)
+			if tc.method == http.MethodPost {
+				builder = builder.BodyJSON(map[string]string{"q": "x"})
+			}
+			err := builder.Fetch(t.Context())
+
+			if (err != nil) != tc.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if len(*received) != tc.wantCalls {
+				t.Errorf("calls = %d, want %d", len(*received), tc.wantCalls)
+			}
+			for i, body := range *received {
+				if body != (*received)[0] {
+					t.Errorf("attempt %d body = %q, want %q", i+1, body, (*received)[0])
+				}
+			}
+			if len(*waits) != len(tc.wantWaits) {
+				t.Fatalf("waits = %v, want %v", *waits, tc.wantWaits)
+			}
+			for i := range tc.wantWaits {
+				if (*waits)[i] != tc.wantWaits[i] {
+					t.Errorf("waits = %v, want %v", *waits, tc.wantWaits)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryTransport_ContextCancelledWhileWaiting(t *testing.T) {
+	t.Parallel()
+	srv, received := newTestRetryServer(t, []int{http.StatusBadGateway}, nil)
+	rt, _ := newTestRetryTransport(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second})
+	rt.sleep = func(ctx context.Context, d time.Duration) error { return context.Canceled }
+
+	err := requests.URL(srv.URL).Transport(rt).Fetch(t.Context())
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if len(*received) != 1 {
+		t.Errorf("calls = %d, want 1", len(*received))
+	}
+}
+
+// TestRetryPolicy_RaiselyRateLimitSurfacesAfterRetries checks the policy
+// is wired into RaiselyAPIBuilder and that the typed rate-limit error
+// still reaches the caller once the policy gives up.
+func TestRetryPolicy_RaiselyRateLimitSurfacesAfterRetries(t *testing.T) {
+	t.Parallel()
+	tooMany := []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests}
+	srv, received := newTestRetryServer(t, tooMany, nil)
+
+	sc := &SyncContext{RetryPolicy: &RetryPolicy{MaxAttempts: 3, RateLimitDelay: time.Millisecond}}
+	sc.Config.API.Keys.Raisely = "k"
+	sc.Config.API.Endpoints.Raisely = srv.URL
+	fetcher := &RaiselyFetcherAndUpdater{SyncContext: sc}
+
+	_, err := fetcher.FetchFundraisingPage("p-1", t.Context())
+	if !IsRateLimited(err) {
+		t.Errorf("err = %v, want a rate-limit error", err)
+	}
+	if len(*received) != 3 {
+		t.Errorf("calls = %d, want 3", len(*received))
+	}
+}
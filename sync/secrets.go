@@ -0,0 +1,231 @@
+package sync
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const redactedSecret = "[REDACTED]"
+
+// redactedAPISettings has APISettings' fields without its methods, so
+// printing one doesn't recurse.
+type redactedAPISettings APISettings
+
+// Redacted returns a copy of a with every API key that is set replaced,
+// for logging. Marshalling APISettings keeps the keys, so marshal the
+// Redacted copy when writing settings to logs.
+func (a APISettings) Redacted() APISettings {
+	for _, key := range []*string{&a.Keys.Raisely, &a.Keys.Funraisin, &a.Keys.Ortto} {
+		if *key != "" {
+			*key = redactedSecret
+		}
+	}
+	return a
+}
+
+// Redacted returns a copy of c with its API keys redacted (see
+// [APISettings.Redacted]), for logging.
+func (c Config) Redacted() Config {
+	c.API = c.API.Redacted()
+	return c
+}
+
+// String implements fmt.Stringer, redacting the API keys. It is also
+// used when printing a Config.
+func (a APISettings) String() string {
+	return fmt.Sprintf("%+v", redactedAPISettings(a.Redacted()))
+}
+
+func (a APISettings) GoString() string {
+	return fmt.Sprintf("%#v", redactedAPISettings(a.Redacted()))
+}
+
+// SecretProvider resolves the ${secret:NAME} references in mapping
+// files, so API keys needn't sit in plaintext in the FEZ_ env vars:
+//
+//	api:
+//	  keys:
+//	    ortto: ${secret:ORTTO_KEY}
+//
+// Supply one with [ConfigWithSecretProvider]. Secret returns an error
+// wrapping [ErrSecretNotFound] for an unknown name.
+type SecretProvider interface {
+	Secret(name string) (string, error)
+}
+
+// ErrSecretNotFound is returned (wrapped) by a SecretProvider for an
+// unknown secret name.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// EnvSecretProvider reads secrets from environment variables named
+// Prefix + NAME.
+type EnvSecretProvider struct {
+	Prefix string
+}
+
+func (p EnvSecretProvider) Secret(name string) (string, error) {
+	v, ok := os.LookupEnv(p.Prefix + name)
+	if !ok {
+		return "", fmt.Errorf("env var %s%s: %w", p.Prefix, name, ErrSecretNotFound)
+	}
+	return v, nil
+}
+
+// FileSecretProvider reads secrets from files named NAME in Dir, as
+// mounted by Kubernetes or Docker secrets. Trailing newlines are
+// trimmed.
+type FileSecretProvider struct {
+	Dir string
+}
+
+func (p FileSecretProvider) Secret(name string) (string, error) {
+	if !fs.ValidPath(name) || strings.Contains(name, "/") {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+	data, err := os.ReadFile(filepath.Join(p.Dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("secret file %s in %s: %w", name, p.Dir, ErrSecretNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// EncryptedFileSecretProvider serves secrets from a local file written
+// by [EncryptSecrets]: a JSON object of secret names to values, sealed
+// with AES-256-GCM. The file is decrypted once, when the provider is
+// created.
+type EncryptedFileSecretProvider struct {
+	secrets map[string]string
+}
+
+// NewEncryptedFileSecretProvider decrypts the secrets file at path with
+// key, which must be 32 bytes.
+func NewEncryptedFileSecretProvider(path string, key []byte) (*EncryptedFileSecretProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets file %w", err)
+	}
+	gcm, err := secretsCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("secrets file %s is too short", path)
+	}
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secrets file %s %w", path, err)
+	}
+	var secrets map[string]string
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets file %s %w", path, err)
+	}
+	return &EncryptedFileSecretProvider{secrets: secrets}, nil
+}
+
+func (p *EncryptedFileSecretProvider) Secret(name string) (string, error) {
+	v, ok := p.secrets[name]
+	if !ok {
+		return "", fmt.Errorf("encrypted secret %s: %w", name, ErrSecretNotFound)
+	}
+	return v, nil
+}
+
+// EncryptSecrets returns the contents of a secrets file for
+// [NewEncryptedFileSecretProvider], sealing secrets with key (32 bytes).
+func EncryptSecrets(secrets map[string]string, key []byte) ([]byte, error) {
+	gcm, err := secretsCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plain, nil), nil
+}
+
+func secretsCipher(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("secrets key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// --- ${secret:NAME} references ---
+
+var secretRef = regexp.MustCompile(`\$\{secret:([^}]*)\}`)
+
+// secretRefs resolves ${secret:NAME} references for YAMLConfigUnmarshaler.
+// go.uber.org/config would read one as the variable "secret" with the
+// default "NAME", so each is rewritten to a placeholder variable before
+// the files are merged. Placeholders are resolved through the provider
+// when expanded, so only references that survive the merge are looked up.
+type secretRefs struct {
+	provider     SecretProvider
+	placeholders map[string]string // placeholder variable -> secret name
+	err          error             // first resolution error
+}
+
+const secretPlaceholderPrefix = "FEZ_SECRET_REF_"
+
+// rewrite replaces the secret references in data with placeholders.
+func (r *secretRefs) rewrite(data []byte) []byte {
+	return secretRef.ReplaceAllFunc(data, func(ref []byte) []byte {
+		name := string(secretRef.FindSubmatch(ref)[1])
+		if r.placeholders == nil {
+			r.placeholders = make(map[string]string)
+		}
+		placeholder := secretPlaceholderPrefix + strconv.Itoa(len(r.placeholders))
+		r.placeholders[placeholder] = name
+		return []byte("${" + placeholder + "}")
+	})
+}
+
+// lookup wraps an env lookup to also resolve placeholders, recording the
+// first error for Unmarshal to return.
+func (r *secretRefs) lookup(next func(string) (string, bool)) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		name, ok := r.placeholders[key]
+		if !ok {
+			return next(key)
+		}
+		var value string
+		var err error
+		if r.provider == nil {
+			err = fmt.Errorf("no SecretProvider configured")
+		} else {
+			value, err = r.provider.Secret(name)
+		}
+		if err != nil {
+			if r.err == nil {
+				r.err = fmt.Errorf("failed to resolve ${secret:%s} %w", name, err)
+			}
+			// Returning the empty string stops go.uber.org/config failing
+			// first with a less helpful error; r.err is returned instead.
+			return "", true
+		}
+		return value, true
+	}
+}
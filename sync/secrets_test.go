@@ -0,0 +1,171 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// mapSecretProvider serves secrets from a map, recording each lookup.
+type mapSecretProvider struct {
+	secrets map[string]string
+	lookups []string
+}
+
+func (p *mapSecretProvider) Secret(name string) (string, error) {
+	p.lookups = append(p.lookups, name)
+	v, ok := p.secrets[name]
+	if !ok {
+		return "", fmt.Errorf("map secret %s: %w", name, ErrSecretNotFound)
+	}
+	return v, nil
+}
+
+func TestYAMLConfigUnmarshaler_SecretReferences(t *testing.T) {
+	t.Parallel()
+	defaults := testMappingFile("defaults.yaml", `
+api:
+  keys:
+    funraisin: ${secret:UNUSED_KEY}
+`)
+	campaign := testMappingFile("campaign.yaml", `
+# keys come from the secret store: ${secret:COMMENTED_OUT}
+api:
+  keys:
+    ortto: ${secret:ORTTO_KEY}
+    raisely: ${RAISELY_KEY}
+    funraisin: "plain"
+`)
+	provider := &mapSecretProvider{secrets: map[string]string{"ORTTO_KEY": "ortto-secret"}}
+	compev := MapCompositeEnvVar{Values: map[string]string{"RAISELY_KEY": "raisely-key"}}
+
+	cfg, err := YAMLConfigUnmarshaler{SecretProvider: provider}.Unmarshal(compev, defaults, campaign)
+	if err != nil {
+		t.Fatalf("Unmarshal returned unexpected error: %v", err)
+	}
+	if cfg.API.Keys.Ortto != "ortto-secret" || cfg.API.Keys.Raisely != "raisely-key" || cfg.API.Keys.Funraisin != "plain" {
+		t.Errorf("unexpected keys %q %q %q", string(cfg.API.Keys.Ortto), string(cfg.API.Keys.Raisely), string(cfg.API.Keys.Funraisin))
+	}
+	if got := strings.Join(provider.lookups, ","); got != "ORTTO_KEY" {
+		t.Errorf("expected only the effective reference to be resolved, looked up %s", got)
+	}
+}
+
+func TestYAMLConfigUnmarshaler_SecretErrors(t *testing.T) {
+	t.Parallel()
+	body := "api:\n  keys:\n    ortto: ${secret:ORTTO_KEY}\n"
+
+	_, err := YAMLConfigUnmarshaler{}.Unmarshal(JSONCompositeEnvVar{}, testMappingFile("campaign.yaml", body))
+	if err == nil || !strings.Contains(err.Error(), "${secret:ORTTO_KEY}") || !strings.Contains(err.Error(), "no SecretProvider") {
+		t.Errorf("err = %v, want a missing provider error", err)
+	}
+
+	provider := &mapSecretProvider{}
+	_, err = YAMLConfigUnmarshaler{SecretProvider: provider}.Unmarshal(JSONCompositeEnvVar{}, testMappingFile("campaign.yaml", body))
+	if !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("err = %v, want ErrSecretNotFound", err)
+	}
+}
+
+func TestAPISettings_Redacted(t *testing.T) {
+	t.Parallel()
+	var cfg Config
+	cfg.API.Keys.Ortto = "ortto-secret"
+
+	out, err := json.Marshal(cfg.Redacted().API)
+	if err != nil {
+		t.Fatalf("json.Marshal returned unexpected error: %v", err)
+	}
+	for _, s := range []string{fmt.Sprintf("%v", cfg), fmt.Sprintf("%+v", cfg.API), fmt.Sprintf("%#v", cfg), string(out)} {
+		if strings.Contains(s, "ortto-secret") {
+			t.Errorf("secret leaked in %s", s)
+		}
+		if !strings.Contains(s, redactedSecret) {
+			t.Errorf("expected %s in %s", redactedSecret, s)
+		}
+	}
+	if cfg.API.Keys.Ortto != "ortto-secret" {
+		t.Errorf("Redacted changed the original key to %q", cfg.API.Keys.Ortto)
+	}
+
+	// Marshalling keeps the keys, so a Config survives a round trip.
+	out, err = json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("json.Marshal returned unexpected error: %v", err)
+	}
+	var decoded Config
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal returned unexpected error: %v", err)
+	}
+	if decoded.API.Keys.Ortto != "ortto-secret" {
+		t.Errorf("Ortto key = %q after a JSON round trip", decoded.API.Keys.Ortto)
+	}
+}
+
+func TestEnvSecretProvider(t *testing.T) {
+	t.Setenv("TEST_SECRET_ORTTO_KEY", "from-env")
+	p := EnvSecretProvider{Prefix: "TEST_SECRET_"}
+	if v, err := p.Secret("ORTTO_KEY"); err != nil || v != "from-env" {
+		t.Errorf("Secret = %q, %v", v, err)
+	}
+	if _, err := p.Secret("MISSING"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("err = %v, want ErrSecretNotFound", err)
+	}
+}
+
+func TestFileSecretProvider(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ORTTO_KEY"), []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p := FileSecretProvider{Dir: dir}
+	if v, err := p.Secret("ORTTO_KEY"); err != nil || v != "from-file" {
+		t.Errorf("Secret = %q, %v", v, err)
+	}
+	if _, err := p.Secret("MISSING"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("err = %v, want ErrSecretNotFound", err)
+	}
+	if _, err := p.Secret("../ORTTO_KEY"); err == nil || errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("err = %v, want an invalid name error", err)
+	}
+}
+
+func TestEncryptedFileSecretProvider(t *testing.T) {
+	t.Parallel()
+	key := bytes.Repeat([]byte{7}, 32)
+	data, err := EncryptSecrets(map[string]string{"ORTTO_KEY": "from-vault"}, key)
+	if err != nil {
+		t.Fatalf("EncryptSecrets returned unexpected error: %v", err)
+	}
+	if bytes.Contains(data, []byte("from-vault")) {
+		t.Fatal("secrets file contains plaintext")
+	}
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewEncryptedFileSecretProvider(path, key)
+	if err != nil {
+		t.Fatalf("NewEncryptedFileSecretProvider returned unexpected error: %v", err)
+	}
+	if v, err := p.Secret("ORTTO_KEY"); err != nil || v != "from-vault" {
+		t.Errorf("Secret = %q, %v", v, err)
+	}
+	if _, err := p.Secret("MISSING"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("err = %v, want ErrSecretNotFound", err)
+	}
+
+	if _, err := NewEncryptedFileSecretProvider(path, bytes.Repeat([]byte{8}, 32)); err == nil {
+		t.Error("expected an error decrypting with the wrong key")
+	}
+	if _, err := NewEncryptedFileSecretProvider(path, key[:16]); err == nil {
+		t.Error("expected an error for a short key")
+	}
+}
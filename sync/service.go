@@ -17,11 +17,13 @@ import (
 //
 // Usage:
 //
-//	svc := sync.NewService(config, campaignID, trigger)
+//	svc, err := sync.NewService(config, campaignID, trigger)
 //	svc.FetchCampaign(false, ctx)                              // required before Map/Send
 //	req, ref, _ := svc.MapFundraisingProfile(profileID, ctx)   // map without sending
 //	if req != nil { svc.SendRequest(req, ctx) }                // send Ortto request
+//	svc.SendRequests(reqs, ctx)                                // or batch many into fewer Ortto calls
 //	if ref != nil { svc.ProcessReferrals(ref, ctx) }           // send referral events + write-back
+//	svc.SyncCampaign(ctx)                                      // re-sync profiles updated since the last run
 //
 // Operations that do not require FetchCampaign:
 //
@@ -33,10 +35,26 @@ type Service struct {
 	sc      *SyncContext
 	fetcher *RaiselyFetcherAndUpdater
 
+	// data is the flavour's FundraisingDataFetcher used by the mapping
+	// paths. nil means the Raisely fetcher above (see dataFetcher).
+	data FundraisingDataFetcher
+
 	// Set after FetchCampaign — mapper creation is deferred because
 	// CampaignName must be set on SyncContext first.
 	campaign *FundraisingCampaign
 	mapper   OrttoMapper
+
+	// checkpoints persists the SyncCampaign high-water mark; nil means
+	// every SyncCampaign run walks the whole campaign.
+	checkpoints SyncCheckpointStore
+
+	// dedupe records the last activity hash ingested per person and
+	// activity type; nil means every activity is sent.
+	dedupe ActivityDedupeStore
+
+	// incrementalTeams maps a team member's event for that member alone
+	// (see ServiceWithIncrementalTeamSync).
+	incrementalTeams bool
 }
 
 // serviceOptions holds optional configuration for NewService.
@@ -44,6 +62,12 @@ type serviceOptions struct {
 	recordRequests           bool
 	debug                    bool
 	fundraisingCampaignCache FundraisingCampaignCache
+	syncCheckpointStore      SyncCheckpointStore
+	retryPolicy              *RetryPolicy
+	activityDedupeStore      ActivityDedupeStore
+	teamPageCache            TeamPageCache
+	incrementalTeams         bool
+	teamMembersConcurrency   int
 }
 
 // ServiceOption is a functional option for configuring NewService.
@@ -75,8 +99,77 @@ func ServiceWithFundraisingCampaignCache(c FundraisingCampaignCache) ServiceOpti
 	}
 }
 
+// ServiceWithSyncCheckpointStore supplies a [SyncCheckpointStore] so
+// [Service.SyncCampaign] resumes from the last high-water mark. Without
+// one, every SyncCampaign run walks the whole campaign.
+func ServiceWithSyncCheckpointStore(store SyncCheckpointStore) ServiceOption {
+	return func(o *serviceOptions) {
+		o.syncCheckpointStore = store
+	}
+}
+
+// ServiceWithRetryPolicy enables fez's retry layer for every API call
+// the service makes (see [RetryPolicy]; [DefaultRetryPolicy] is a good
+// starting point). Without it fez never retries.
+func ServiceWithRetryPolicy(policy RetryPolicy) ServiceOption {
+	return func(o *serviceOptions) {
+		o.retryPolicy = &policy
+	}
+}
+
+// ServiceWithActivityDedupeStore supplies an [ActivityDedupeStore] so
+// SendRequest and SendRequests skip activities identical (by
+// [OrttoActivity.ContentHash]) to the last one Ortto ingested for the
+// same person and activity type. Without one, every activity is sent.
+func ServiceWithActivityDedupeStore(store ActivityDedupeStore) ServiceOption {
+	return func(o *serviceOptions) {
+		o.activityDedupeStore = store
+	}
+}
+
+// ServiceWithTeamPageCache supplies a [TeamPageCache] for the service's
+// [RaiselyFetcherAndUpdater], used by incremental team sync. Without
+// one, each member event fetches the whole team from Raisely.
+func ServiceWithTeamPageCache(c TeamPageCache) ServiceOption {
+	return func(o *serviceOptions) {
+		o.teamPageCache = c
+	}
+}
+
+// ServiceWithIncrementalTeamSync makes team syncs incremental. An event
+// for a team member (MapFundraisingProfile or MapByWebhookModel) fetches
+// only that member and merges it into the team cached with
+// [ServiceWithTeamPageCache], then maps only that member, rather than
+// fetching and re-sending every member. A cache miss fetches the whole
+// team once. Events for the team itself still map every member, and
+// refresh the cached team. Configs mapping team aggregates (e.g.
+// @team.rank) always map every member.
+//
+// Raisely2Ortto only: Funraisin returns a team's members in one call, so
+// the Funraisin2Ortto flavour always maps the whole team.
+func ServiceWithIncrementalTeamSync() ServiceOption {
+	return func(o *serviceOptions) {
+		o.incrementalTeams = true
+	}
+}
+
+// ServiceWithTeamMembersConcurrency caps how many team member pages are
+// fetched from Raisely at once when mapping a team (default
+// [DefaultTeamMembersFetchConcurrency]).
+func ServiceWithTeamMembersConcurrency(n int) ServiceOption {
+	return func(o *serviceOptions) {
+		o.teamMembersConcurrency = n
+	}
+}
+
 // NewService creates a Service for the given campaign configuration.
-func NewService(config Config, campaignID string, trigger TriggerInfo, opts ...ServiceOption) *Service {
+// Returns ErrNotInitialised if Init has not been called.
+func NewService(config Config, campaignID string, trigger TriggerInfo, opts ...ServiceOption) (*Service, error) {
+	flavour, err := initialised()
+	if err != nil {
+		return nil, err
+	}
+
 	var o serviceOptions
 	for _, opt := range opts {
 		opt(&o)
@@ -87,14 +180,39 @@ func NewService(config Config, campaignID string, trigger TriggerInfo, opts ...S
 		RecordRequests: o.recordRequests,
 		Debug:          o.debug,
 		TriggerInfo:    trigger,
+		RetryPolicy:    o.retryPolicy,
 	}
-	return &Service{
+	s := &Service{
 		sc: sc,
 		fetcher: &RaiselyFetcherAndUpdater{
 			SyncContext:              sc,
 			FundraisingCampaignCache: o.fundraisingCampaignCache,
+			TeamPageCache:            o.teamPageCache,
+			TeamMembersConcurrency:   o.teamMembersConcurrency,
 		},
+		checkpoints:      o.syncCheckpointStore,
+		dedupe:           o.activityDedupeStore,
+		incrementalTeams: o.incrementalTeams,
+	}
+	if flavour == Funraisin2Ortto {
+		s.data = &FunraisinFetcher{
+			SyncContext:              sc,
+			FundraisingCampaignCache: o.fundraisingCampaignCache,
+		}
+		if o.incrementalTeams {
+			log.Printf("Warning: incremental team sync is not supported for the Funraisin2Ortto flavour, every team member will be mapped")
+		}
+	}
+	return s, nil
+}
+
+// dataFetcher returns the FundraisingDataFetcher for the initialised
+// flavour, defaulting to the Raisely fetcher.
+func (s *Service) dataFetcher() FundraisingDataFetcher {
+	if s.data != nil {
+		return s.data
 	}
+	return s.fetcher
 }
 
 // SyncContext returns the Service's underlying SyncContext. Useful for
@@ -105,18 +223,31 @@ func (s *Service) SyncContext() *SyncContext {
 	return s.sc
 }
 
-// FetchCampaign fetches (or cache-hits) the fundraising campaign from Raisely.
+// FetchCampaign fetches (or cache-hits) the fundraising campaign from
+// Raisely (or Funraisin, for the Funraisin2Ortto flavour).
 // Must be called before Map and Send operations.
 // Set refresh=true to force a fresh fetch (e.g. on new registrations).
 func (s *Service) FetchCampaign(refresh bool, ctx context.Context) (*FundraisingCampaign, error) {
-	fc, err := s.fetcher.CachedFundraisingCampaign(s.sc.Campaign, refresh, ctx)
+	fc, err := s.dataFetcher().CachedFundraisingCampaign(s.sc.Campaign, refresh, ctx)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.setCampaign(fc); err != nil {
+		return nil, err
+	}
+	return fc, nil
+}
+
+// setCampaign records the fetched campaign and creates the mapper.
+func (s *Service) setCampaign(fc *FundraisingCampaign) error {
+	mapper, err := NewOrttoMapper(s.sc)
+	if err != nil {
+		return err
+	}
 	s.campaign = fc
 	s.sc.CampaignName = fc.Name
-	s.mapper = NewOrttoMapper(s.sc)
-	return fc, nil
+	s.mapper = mapper
+	return nil
 }
 
 // requireMapper returns an error if FetchCampaign has not been called.
@@ -133,25 +264,47 @@ func (s *Service) requireMapper() error {
 // to a team or is an individual, and maps it. Returns the Ortto request
 // for the profile (or team) plus an optional ReferralBatch for any
 // unprocessed referral entries on an individual profile.
+//
+// If some members of a team could not be fetched, the request covers the
+// rest and the failed members are logged as a [*TeamMembersError], so
+// they can be retried ([MultiTargetService] also reports them in
+// [TargetResult.FailedMembers]).
 // FetchCampaign must be called first.
 func (s *Service) MapFundraisingProfile(profileID string, ctx context.Context) (OrttoRequest, *ReferralBatch, error) {
 	if err := s.requireMapper(); err != nil {
 		return nil, nil, err
 	}
+	data, err := s.fetchProfileData(profileID, ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.mapProfileData(data)
+}
+
+// profileData is the fetched data a profile maps from: its team's data
+// for a team member (or the team itself), otherwise the fundraiser's own.
+type profileData struct {
+	profileID        string
+	team             *TeamData
+	fundraiser       FundraiserData
+	includeReferrals bool
+}
 
-	fundraisingPage, err := s.fetcher.FetchFundraisingPage(profileID, ctx)
+// fetchProfileData does the fetching for MapFundraisingProfile.
+func (s *Service) fetchProfileData(profileID string, ctx context.Context) (profileData, error) {
+	fundraisingPage, err := s.dataFetcher().FetchFundraisingPage(profileID, ctx)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to fetch profile %s: %w", profileID, err)
+		return profileData{}, fmt.Errorf("failed to fetch profile %s: %w", profileID, err)
 	}
 
 	if s.sc.Debug {
-		profileData, _ := json.MarshalIndent(fundraisingPage.Source.Data(), "", "  ")
-		log.Printf("Debug: Fetched profile %s %s\n", profileID, string(profileData))
+		pageData, _ := json.MarshalIndent(fundraisingPage.Source.Data(), "", "  ")
+		log.Printf("Debug: Fetched profile %s %s\n", profileID, string(pageData))
 	}
 
 	fundraisingPageType, ok := fundraisingPage.Source.StringForPath("type")
 	if !ok {
-		return nil, nil, fmt.Errorf("profile %s is missing a type", profileID)
+		return profileData{}, fmt.Errorf("profile %s is missing a type", profileID)
 	}
 
 	profile := FundraisingProfile{
@@ -167,26 +320,82 @@ func (s *Service) MapFundraisingProfile(profileID string, ctx context.Context) (
 	}
 
 	team := profile.TeamP2PID(s.campaign)
-	if team != "" {
-		teamData, err := s.fetcher.FetchTeamData(team, ctx)
+	if team != "" && team != profileID && s.incrementalSync() {
+		teamData, err := s.fetchTeamMemberData(team, fundraisingPage, ctx)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to fetch team data for %s: %w", team, err)
+			return profileData{}, err
 		}
-		req, err := s.mapper.MapTeamFundraisingPage(s.campaign, teamData)
+		return profileData{profileID: profileID, team: &teamData}, nil
+	}
+	if team != "" {
+		teamData, err := s.fetchTeamData(team, ctx)
 		if err != nil {
-			return nil, nil, err
+			return profileData{}, fmt.Errorf("failed to fetch team data for %s: %w", team, err)
 		}
-		return req, nil, nil
+		return profileData{profileID: profileID, team: &teamData}, nil
 	}
 
-	data, err := s.fetcher.FetchFundraiserData(profileID, ctx)
+	data, err := s.dataFetcher().FetchFundraiserData(profileID, ctx)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to fetch fundraiser data for %s: %w", profileID, err)
+		return profileData{}, fmt.Errorf("failed to fetch fundraiser data for %s: %w", profileID, err)
 	}
 
 	// Manual sync: caller explicitly chose this profile, always include
 	// referrals. The event-type gate only applies to the webhook path.
-	return s.mapIndividual(profileID, data, true)
+	return profileData{profileID: profileID, fundraiser: data, includeReferrals: true}, nil
+}
+
+// incrementalSync reports whether team member events are mapped for the
+// member alone (see ServiceWithIncrementalTeamSync). Team aggregates
+// need every member, so configs mapping them always map the whole team.
+func (s *Service) incrementalSync() bool {
+	return s.incrementalTeams && s.data == nil && !s.sc.fetchNeeds().teamAggregates
+}
+
+// fetchTeamData fetches a team and every member page. With incremental
+// team sync the fresh team is written to the team page cache.
+func (s *Service) fetchTeamData(team string, ctx context.Context) (TeamData, error) {
+	teamData, err := s.dataFetcher().FetchTeamData(team, ctx)
+	if err != nil {
+		return teamData, err
+	}
+	if err := teamData.MembersErr(); err != nil {
+		log.Printf("Warning: mapping the rest of the team: %v", err)
+	}
+	if s.incrementalSync() {
+		s.fetcher.cacheTeamData(team, teamData, ctx)
+	}
+	return teamData, nil
+}
+
+// fetchTeamMemberData returns the team from the team page cache with
+// memberPage merged in, set to map that member alone. On a cache miss
+// the whole team is fetched (and cached) first, so team-level values
+// always see every member.
+func (s *Service) fetchTeamMemberData(team string, memberPage FundraisingPage, ctx context.Context) (TeamData, error) {
+	teamData, err := s.fetcher.CachedTeamData(team, false, ctx)
+	if err != nil {
+		return TeamData{}, fmt.Errorf("failed to fetch team data for %s: %w", team, err)
+	}
+	teamData = teamData.withMemberPage(memberPage)
+	s.fetcher.cacheTeamData(team, teamData, ctx)
+
+	memberP2PID, _ := memberPage.Source.StringForPath("uuid")
+	teamData.FailedMembers = nil // only memberPage is mapped
+	teamData.ChangedMembers = []string{memberP2PID}
+	return teamData, nil
+}
+
+// mapProfileData maps fetched profile data with this Service's mapper.
+func (s *Service) mapProfileData(data profileData) (OrttoRequest, *ReferralBatch, error) {
+	if data.team != nil {
+		req, err := s.mapper.MapTeamFundraisingPage(s.campaign, *data.team)
+		if err != nil {
+			return nil, nil, err
+		}
+		return req, nil, nil
+	}
+	return s.mapIndividual(data.profileID, data.fundraiser, data.includeReferrals)
 }
 
 // MapByWebhookModel maps using model type information already known from
@@ -197,39 +406,54 @@ func (s *Service) MapFundraisingProfile(profileID string, ctx context.Context) (
 // (profile.totalUpdated, profile.exerciseTotalUpdated) skip the
 // referrals path — invitations only need to fire when the fundraiser
 // explicitly creates or edits their profile.
+// Partially fetched teams are reported as for MapFundraisingProfile.
 // FetchCampaign must be called first.
 func (s *Service) MapByWebhookModel(modelType, modelID, parentType, parentID string, parentIsCampaignProfile bool, eventType string, ctx context.Context) (OrttoRequest, *ReferralBatch, error) {
 	if err := s.requireMapper(); err != nil {
 		return nil, nil, err
 	}
+	data, err := s.fetchWebhookModelData(modelType, modelID, parentType, parentID, parentIsCampaignProfile, eventType, ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.mapProfileData(data)
+}
 
+// fetchWebhookModelData does the fetching for MapByWebhookModel.
+func (s *Service) fetchWebhookModelData(modelType, modelID, parentType, parentID string, parentIsCampaignProfile bool, eventType string, ctx context.Context) (profileData, error) {
 	if modelType == "GROUP" ||
 		(modelType == "INDIVIDUAL" && !parentIsCampaignProfile && parentType == "GROUP") {
 		teamID := modelID
 		if modelType == "INDIVIDUAL" {
 			teamID = parentID
 		}
-		teamData, err := s.fetcher.FetchTeamData(teamID, ctx)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to fetch team data: %w", err)
+		if modelType == "INDIVIDUAL" && s.incrementalSync() {
+			memberPage, err := s.fetcher.FetchFundraisingPage(modelID, ctx)
+			if err != nil {
+				return profileData{}, fmt.Errorf("failed to fetch profile %s: %w", modelID, err)
+			}
+			teamData, err := s.fetchTeamMemberData(teamID, memberPage, ctx)
+			if err != nil {
+				return profileData{}, err
+			}
+			return profileData{profileID: modelID, team: &teamData}, nil
 		}
-		req, err := s.mapper.MapTeamFundraisingPage(s.campaign, teamData)
+		teamData, err := s.fetchTeamData(teamID, ctx)
 		if err != nil {
-			return nil, nil, err
+			return profileData{}, fmt.Errorf("failed to fetch team data: %w", err)
 		}
-		return req, nil, nil
+		return profileData{profileID: modelID, team: &teamData}, nil
 	}
 
 	if modelType == "INDIVIDUAL" {
-		data, err := s.fetcher.FetchFundraiserData(modelID, ctx)
+		data, err := s.dataFetcher().FetchFundraiserData(modelID, ctx)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to fetch fundraiser data: %w", err)
+			return profileData{}, fmt.Errorf("failed to fetch fundraiser data: %w", err)
 		}
-
-		return s.mapIndividual(modelID, data, referralsEligibleEvent(eventType))
+		return profileData{profileID: modelID, fundraiser: data, includeReferrals: referralsEligibleEvent(eventType)}, nil
 	}
 
-	return nil, nil, fmt.Errorf("unsupported model type: %s", modelType)
+	return profileData{}, fmt.Errorf("unsupported model type: %s", modelType)
 }
 
 // referralsEligibleEvent reports whether the given Raisely webhook event
@@ -255,6 +479,9 @@ func (s *Service) mapIndividual(profileID string, data FundraiserData, includeRe
 		return req, nil, nil
 	}
 
+	if s.data != nil {
+		return req, nil, errors.New("referrals require the Raisely2Ortto flavour")
+	}
 	batch, err := s.fetcher.MapFundraiserReferrals(profileID, data, s.sc.Config, s.sc.Campaign)
 	if err != nil {
 		return req, nil, err
@@ -275,11 +502,24 @@ func (s *Service) MapTrackingData(data map[string]string, ctx context.Context) (
 
 // SendRequest sends a mapped request to Ortto.
 // FetchCampaign must be called first.
+//
+// With an [ActivityDedupeStore] configured, unchanged activities are
+// dropped before sending, so the response only reports the activities
+// actually sent. If every activity is dropped nothing is sent and an
+// empty OrttoActivitiesResponse is returned.
 func (s *Service) SendRequest(req OrttoRequest, ctx context.Context) (OrttoResponse, error) {
 	if err := s.requireMapper(); err != nil {
 		return nil, err
 	}
-	return s.mapper.SendRequest(req, ctx)
+	req, kept, dropped := s.dedupeActivities(req, ctx)
+	if dropped > 0 && req.ItemCount() == 0 {
+		return OrttoActivitiesResponse{}, nil
+	}
+	resp, err := s.mapper.SendRequest(req, ctx)
+	if err == nil {
+		s.recordActivities(kept, resp, ctx)
+	}
+	return resp, err
 }
 
 // ProcessReferrals sends each Raisely Custom Message event in the batch
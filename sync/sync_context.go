@@ -39,6 +39,23 @@ type SyncContext struct {
 	// "<consumer-module-name>/<vcs-revision>". Set explicitly only when
 	// the per-SyncContext UA differs from the build-derived default.
 	UserAgent string
+
+	// RetryPolicy enables fez's retry layer on every Ortto / Raisely /
+	// Funraisin call made through this SyncContext. nil (the default)
+	// means no retries. See [RetryPolicy].
+	RetryPolicy *RetryPolicy
+
+	// sharedFetchNeeds replaces Config's fetch needs when this context
+	// fetches for several configs (see MultiTargetService).
+	sharedFetchNeeds *fetchNeeds
+}
+
+// fetchNeeds returns the optional data to fetch alongside a profile.
+func (sc *SyncContext) fetchNeeds() fetchNeeds {
+	if sc.sharedFetchNeeds != nil {
+		return *sc.sharedFetchNeeds
+	}
+	return sc.Config.fetchNeeds()
 }
 
 // fezVersion is fez's own resolved module version, read from buildinfo
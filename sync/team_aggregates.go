@@ -0,0 +1,125 @@
+package sync
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+
+	"github.com/tidwall/gjson"
+)
+
+// Team aggregates are values computed across a team's member pages,
+// exposed to TeamFieldMappings (and team transforms) as virtual paths on
+// the team page:
+//
+//	@team.members.count          number of team members
+//	@team.rank                   the member's rank in the team by total (1 = highest; ties share a rank)
+//	@team.captain.<path>         any path on the captain's page, e.g. @team.captain.user.fullName
+//	@team.exerciseTotals.<TYPE>  the team's exercise distance for an activity type, e.g. @team.exerciseTotals.RUN
+//
+// The @ keeps them apart from real team.* fields on the page, which
+// still map as usual. The captain is the member who owns the team page.
+// @team.exerciseTotals paths sum the exercise every member logged since
+// fundraiserExtensions.streaks.activity.from (all of it if unset), so
+// mapping one makes each team sync fetch the members' exercise logs for
+// that window (Raisely2Ortto only).
+//
+// Aggregates need every member page, so a config mapping @team.* paths
+// maps the whole team even with [ServiceWithIncrementalTeamSync].
+const teamAggregatesPrefix = "@team."
+
+// teamAggregatePath matches @team.<name> paths, skipping quoted or
+// static strings.
+var teamAggregatePath = regexp.MustCompile("(?:^|[^A-Za-z0-9_.^`\"'])@team\\.([A-Za-z]+)")
+
+// teamAggregatePaths returns the team aggregate names (members, rank,
+// captain, exerciseTotals) used by the team field mappings.
+func (c Config) teamAggregatePaths() map[string]bool {
+	result := make(map[string]bool)
+	add := func(path string) {
+		for _, m := range teamAggregatePath.FindAllStringSubmatch(path, -1) {
+			result[m[1]] = true
+		}
+	}
+	m := c.TeamFieldMappings.Custom
+	for _, paths := range []map[string]string{m.Strings, m.Texts, m.Decimals, m.Booleans, m.Timestamps, m.Integers} {
+		for _, path := range paths {
+			add(path)
+		}
+	}
+	for _, objects := range []map[string]map[string]string{m.Phones, m.Geos} {
+		for _, object := range objects {
+			for _, path := range object {
+				add(path)
+			}
+		}
+	}
+	return result
+}
+
+// MapTeamAggregates reports whether the team mappings use team aggregates.
+func (c Config) MapTeamAggregates() bool {
+	return len(c.teamAggregatePaths()) > 0
+}
+
+// MapTeamExerciseTotals reports whether the team mappings use
+// @team.exerciseTotals, which needs every member's exercise logs.
+func (c Config) MapTeamExerciseTotals() bool {
+	return c.teamAggregatePaths()["exerciseTotals"]
+}
+
+// MemberTeamPages returns the team page to map with each of MemberPages
+// (same order), carrying that member's team aggregates.
+func (d TeamData) MemberTeamPages() []FundraisingPage {
+	totals := make([]int64, len(d.MemberPages))
+	captain := -1
+	for i, page := range d.MemberPages {
+		totals[i], _ = page.Source.IntForPath("total")
+		if captain < 0 {
+			if same, err := page.HasSameOwnerAs(d.TeamPage); err == nil && same {
+				captain = i
+			}
+		}
+	}
+
+	count := len(d.Team.TeamMembers)
+	if count == 0 {
+		count = len(d.MemberPages)
+	}
+
+	shared := map[string]interface{}{
+		"members": map[string]int{"count": count},
+	}
+	if captain >= 0 && d.MemberPages[captain].Source.data.Raw != "" {
+		shared["captain"] = json.RawMessage(d.MemberPages[captain].Source.data.Raw)
+	}
+	if d.MemberExerciseLogs != nil {
+		byActivity := make(map[string]float64)
+		for _, logs := range d.MemberExerciseLogs {
+			for _, entry := range logs {
+				byActivity[entry.Activity] += entry.Distance
+			}
+		}
+		exerciseTotals := make(map[string]int64, len(byActivity))
+		for activity, distance := range byActivity {
+			exerciseTotals[activity] = int64(math.Round(distance))
+		}
+		shared["exerciseTotals"] = exerciseTotals
+	}
+
+	result := make([]FundraisingPage, len(d.MemberPages))
+	for i := range d.MemberPages {
+		rank := 1
+		for _, total := range totals {
+			if total > totals[i] {
+				rank++
+			}
+		}
+		shared["rank"] = rank
+		aggregates, _ := json.Marshal(shared)
+		team := Source{data: gjson.ParseBytes(aggregates)}
+		result[i] = d.TeamPage
+		result[i].Source.team = &team
+	}
+	return result
+}
@@ -0,0 +1,159 @@
+package sync
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func testTeamPage(t *testing.T, raw string) FundraisingPage {
+	t.Helper()
+	if !gjson.Valid(raw) {
+		t.Fatalf("invalid test page %s", raw)
+	}
+	return FundraisingPage{Source: Source{data: gjson.Parse(raw)}}
+}
+
+func TestTeamData_MemberTeamPages(t *testing.T) {
+	t.Parallel()
+	data := TeamData{
+		Team:     FundraisingTeam{TeamMembers: []TeamMember{{P2PID: "m-1"}, {P2PID: "m-2"}, {P2PID: "m-3"}, {P2PID: "m-4"}}},
+		TeamPage: testTeamPage(t, `{"uuid":"t-1","name":"Team","user":{"uuid":"u-2"},"team":{"rank":"real"}}`),
+		MemberPages: []FundraisingPage{
+			testTeamPage(t, `{"uuid":"m-1","total":500,"user":{"uuid":"u-1"}}`),
+			testTeamPage(t, `{"uuid":"m-2","total":2000,"user":{"uuid":"u-2","fullName":"Cap Tain"}}`),
+			testTeamPage(t, `{"uuid":"m-3","total":500,"user":{"uuid":"u-3"}}`),
+		},
+		MemberExerciseLogs: map[string][]ExerciseLogEntry{
+			"m-1": {{Activity: "RUN", Distance: 1200.4}, {Activity: "WALK", Distance: 300}},
+			"m-2": {{Activity: "RUN", Distance: 800.3}},
+		},
+	}
+
+	pages := data.MemberTeamPages()
+	if len(pages) != len(data.MemberPages) {
+		t.Fatalf("got %d team pages for %d members", len(pages), len(data.MemberPages))
+	}
+	var ranks []int64
+	for _, page := range pages {
+		rank, _ := page.Source.IntForPath("@team.rank")
+		ranks = append(ranks, rank)
+	}
+	if !slices.Equal(ranks, []int64{2, 1, 2}) {
+		t.Errorf("ranks = %v, want [2 1 2]", ranks)
+	}
+
+	page := pages[0]
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"@team.members.count", "4"},
+		{"@team.captain.user.fullName", "Cap Tain"},
+		{"@team.exerciseTotals.RUN", "2001"},
+		{"@team.exerciseTotals.WALK", "300"},
+		{"name", "Team"},
+		{"team.rank", "real"}, // a real field, not the aggregate
+	}
+	for _, tt := range tests {
+		if got, _ := page.Source.StringForPath(tt.path); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+	if _, exists := page.Source.StringForPath("@team.exerciseTotals.SWIM"); exists {
+		t.Error("expected no total for an activity nobody logged")
+	}
+	if _, exists := data.TeamPage.Source.StringForPath("@team.rank"); exists {
+		t.Error("expected MemberTeamPages not to modify TeamPage")
+	}
+}
+
+func TestTeamData_MemberTeamPages_NoCaptain(t *testing.T) {
+	t.Parallel()
+	data := TeamData{
+		TeamPage:    testTeamPage(t, `{"uuid":"t-1","user":{"uuid":"u-9"}}`),
+		MemberPages: []FundraisingPage{testTeamPage(t, `{"uuid":"m-1","user":{"uuid":"u-1"}}`)},
+	}
+	page := data.MemberTeamPages()[0]
+	if _, exists := page.Source.StringForPath("@team.captain.uuid"); exists {
+		t.Error("expected no captain when no member owns the team page")
+	}
+	if count, _ := page.Source.IntForPath("@team.members.count"); count != 1 {
+		t.Errorf("@team.members.count = %d, want 1", count)
+	}
+}
+
+func TestMapFields_TeamAggregates(t *testing.T) {
+	t.Parallel()
+	data := TeamData{
+		TeamPage: testTeamPage(t, `{"uuid":"t-1","user":{"uuid":"u-1"}}`),
+		MemberPages: []FundraisingPage{
+			testTeamPage(t, `{"uuid":"m-1","total":100,"user":{"uuid":"u-1","fullName":"Cap Tain"}}`),
+			testTeamPage(t, `{"uuid":"m-2","total":300,"user":{"uuid":"u-2"}}`),
+		},
+	}
+	mappings := FieldMappings{
+		Strings:  map[string]string{"str:cm:team-captain": "@team.captain.user.fullName"},
+		Integers: map[string]string{"int:cm:team-rank": "@team.rank", "int:cm:team-size": "= @team.members.count"},
+	}
+
+	contact := OrttoContact{Fields: make(map[string]interface{})}
+	MapFields(mappings, data.MemberTeamPages()[0].Source, &contact)
+	if contact.Fields["str:cm:team-captain"] != "Cap Tain" {
+		t.Errorf("captain = %v", contact.Fields["str:cm:team-captain"])
+	}
+	if rank, _ := contact.Fields["int:cm:team-rank"].(int64); rank != 2 {
+		t.Errorf("rank = %v (%T), want 2", contact.Fields["int:cm:team-rank"], contact.Fields["int:cm:team-rank"])
+	}
+	if contact.Fields["int:cm:team-size"] == nil {
+		t.Error("expected @team.members.count to be mapped by an expression")
+	}
+}
+
+func TestConfig_MapTeamAggregates(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		mappings     FieldMappings
+		wantAny      bool
+		wantExercise bool
+	}{
+		{name: "none", mappings: FieldMappings{Strings: map[string]string{"a": "name", "b": "team.rank", "c": "`@team.rank`", "d": "= team.exerciseTotals.RUN"}}},
+		{name: "rank", mappings: FieldMappings{Integers: map[string]string{"a": "@team.rank"}}, wantAny: true},
+		{name: "expression", mappings: FieldMappings{Decimals: map[string]string{"a": "= @team.exerciseTotals.RUN / 1000"}}, wantAny: true, wantExercise: true},
+		{name: "geo", mappings: FieldMappings{Geos: map[string]map[string]string{"a": {"city": "@team.captain.city"}}}, wantAny: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var c Config
+			c.TeamFieldMappings.Custom = tt.mappings
+			if got := c.MapTeamAggregates(); got != tt.wantAny {
+				t.Errorf("MapTeamAggregates() = %v, want %v", got, tt.wantAny)
+			}
+			if got := c.MapTeamExerciseTotals(); got != tt.wantExercise {
+				t.Errorf("MapTeamExerciseTotals() = %v, want %v", got, tt.wantExercise)
+			}
+		})
+	}
+}
+
+func TestService_IncrementalTeamSyncWithAggregates(t *testing.T) {
+	t.Parallel()
+	srv, requested := newTestRaiselyTeamServer(t)
+	s := newTestSyncCampaignService(srv.URL, &recordingOrttoMapper{}, nil)
+	s.incrementalTeams = true
+	s.sc.Config.TeamFieldMappings.Custom.Integers = map[string]string{"int:cm:team-rank": "@team.rank"}
+
+	req, _, err := s.MapFundraisingProfile("m-1", t.Context())
+	if err != nil {
+		t.Fatalf("MapFundraisingProfile returned unexpected error: %v", err)
+	}
+	if req.ItemCount() != 2 {
+		t.Errorf("expected every member to be mapped, got %d", req.ItemCount())
+	}
+	if got := requested(); !slices.Contains(got, "/v3/profiles/t-1/members") {
+		t.Errorf("expected the team's members to be fetched, requested %v", got)
+	}
+}
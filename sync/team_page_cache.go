@@ -0,0 +1,117 @@
+package sync
+
+import (
+	"context"
+	"log"
+	gosync "sync"
+	"time"
+)
+
+// TeamPageCache is an optional cross-call cache for teams fetched from
+// the Raisely API (the team page and its members' pages, as
+// [TeamData]), keyed by the team's p2pID. It backs incremental team sync
+// (see [ServiceWithIncrementalTeamSync]): a member-level event merges the
+// fresh member page into the cached team and maps just that member,
+// instead of re-fetching every member of the team.
+//
+// As with [FundraisingCampaignCache], implementations own TTL and the
+// fetcher fails open: Get errors are logged and treated as a miss, Set
+// errors are logged and swallowed. A TeamData round-trips through
+// encoding/json, for implementations backed by a shared store. Teams
+// with members that failed to fetch are not cached.
+//
+// Team-level events refresh the entry, so the TTL only bounds how stale
+// team fields can get when a team change arrives without one.
+type TeamPageCache interface {
+	// Get returns the cached team for p2pTeamID. ok is false on a miss
+	// or expired entry; a non-nil err reports a backing-store failure.
+	Get(ctx context.Context, p2pTeamID string) (data TeamData, ok bool, err error)
+
+	// Set writes data into the cache under p2pTeamID.
+	Set(ctx context.Context, p2pTeamID string, data TeamData) error
+
+	// Delete removes the entry for p2pTeamID. Used by operator-driven
+	// cache-bust paths; the fetcher itself does not call this.
+	Delete(ctx context.Context, p2pTeamID string) error
+}
+
+// CachedTeamData returns a team and its member pages, consulting the
+// configured [TeamPageCache] when present. With no cache every call
+// fetches from Raisely like FetchTeamData; refresh=true bypasses Get and
+// writes the fresh team through to the cache.
+func (r *RaiselyFetcherAndUpdater) CachedTeamData(p2pTeamID string, refresh bool, ctx context.Context) (TeamData, error) {
+	if r.TeamPageCache != nil && !refresh {
+		hit, ok, err := r.TeamPageCache.Get(ctx, p2pTeamID)
+		if err != nil {
+			log.Printf("TeamPageCache.Get(%s): %v (treating as miss)", p2pTeamID, err)
+		} else if ok {
+			return hit, nil
+		}
+	}
+
+	fresh, err := r.FetchTeamData(p2pTeamID, ctx)
+	if err != nil {
+		return fresh, err
+	}
+	r.cacheTeamData(p2pTeamID, fresh, ctx)
+	return fresh, nil
+}
+
+// cacheTeamData writes a team to the configured TeamPageCache, if any,
+// unless some of its members failed to fetch.
+func (r *RaiselyFetcherAndUpdater) cacheTeamData(p2pTeamID string, data TeamData, ctx context.Context) {
+	if r.TeamPageCache == nil || len(data.FailedMembers) > 0 {
+		return
+	}
+	data.ChangedMembers = nil
+	if err := r.TeamPageCache.Set(ctx, p2pTeamID, data); err != nil {
+		log.Printf("TeamPageCache.Set(%s): %v (continuing)", p2pTeamID, err)
+	}
+}
+
+// MemoryTeamPageCache is an in-process [TeamPageCache] whose entries
+// expire after a fixed TTL. Safe for concurrent use.
+type MemoryTeamPageCache struct {
+	ttl time.Duration
+
+	mu      gosync.Mutex
+	entries map[string]memoryTeamPageCacheEntry
+}
+
+type memoryTeamPageCacheEntry struct {
+	data    TeamData
+	expires time.Time
+}
+
+// NewMemoryTeamPageCache returns an empty MemoryTeamPageCache.
+func NewMemoryTeamPageCache(ttl time.Duration) *MemoryTeamPageCache {
+	return &MemoryTeamPageCache{ttl: ttl, entries: make(map[string]memoryTeamPageCacheEntry)}
+}
+
+// Get implements [TeamPageCache].
+func (c *MemoryTeamPageCache) Get(ctx context.Context, p2pTeamID string) (TeamData, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[p2pTeamID]
+	if !ok || time.Now().After(entry.expires) {
+		delete(c.entries, p2pTeamID)
+		return TeamData{}, false, nil
+	}
+	return entry.data, true, nil
+}
+
+// Set implements [TeamPageCache].
+func (c *MemoryTeamPageCache) Set(ctx context.Context, p2pTeamID string, data TeamData) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[p2pTeamID] = memoryTeamPageCacheEntry{data: data, expires: time.Now().Add(c.ttl)}
+	return nil
+}
+
+// Delete implements [TeamPageCache].
+func (c *MemoryTeamPageCache) Delete(ctx context.Context, p2pTeamID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, p2pTeamID)
+	return nil
+}
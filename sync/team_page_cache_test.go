@@ -0,0 +1,199 @@
+package sync
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	gosync "sync"
+	"testing"
+	"time"
+)
+
+func TestFundraisingPage_JSONRoundTrip(t *testing.T) {
+	t.Parallel()
+	var page FundraisingPage
+	if err := json.Unmarshal([]byte(`{"uuid":"t-1","name":"Team","total":1200}`), &page); err != nil {
+		t.Fatalf("json.Unmarshal returned unexpected error: %v", err)
+	}
+	out, err := json.Marshal(page)
+	if err != nil {
+		t.Fatalf("json.Marshal returned unexpected error: %v", err)
+	}
+	var decoded FundraisingPage
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal returned unexpected error: %v", err)
+	}
+	if name, _ := decoded.Source.StringForPath("name"); name != "Team" {
+		t.Errorf("name = %q after round trip of %s", name, out)
+	}
+	if out, _ := json.Marshal(FundraisingPage{}); string(out) != "null" {
+		t.Errorf("empty page marshalled as %s", out)
+	}
+	data := TeamData{TeamPage: page, MemberPages: []FundraisingPage{page}, ChangedMembers: []string{"t-1"}}
+	out, err = json.Marshal(data)
+	if err != nil {
+		t.Fatalf("json.Marshal returned unexpected error: %v", err)
+	}
+	var decodedData TeamData
+	if err := json.Unmarshal(out, &decodedData); err != nil {
+		t.Fatalf("json.Unmarshal returned unexpected error: %v", err)
+	}
+	if len(decodedData.MemberPages) != 1 || decodedData.ChangedMembers != nil {
+		t.Errorf("unexpected TeamData after round trip of %s", out)
+	}
+}
+
+func TestMemoryTeamPageCache(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	var page FundraisingPage
+	_ = json.Unmarshal([]byte(`{"uuid":"t-1"}`), &page)
+
+	data := TeamData{TeamPage: page}
+
+	c := NewMemoryTeamPageCache(time.Hour)
+	if _, ok, _ := c.Get(ctx, "t-1"); ok {
+		t.Fatal("expected a miss on an empty cache")
+	}
+	_ = c.Set(ctx, "t-1", data)
+	if got, ok, _ := c.Get(ctx, "t-1"); !ok {
+		t.Error("expected a hit after Set")
+	} else if uuid, _ := got.TeamPage.Source.StringForPath("uuid"); uuid != "t-1" {
+		t.Errorf("uuid = %q", uuid)
+	}
+	_ = c.Delete(ctx, "t-1")
+	if _, ok, _ := c.Get(ctx, "t-1"); ok {
+		t.Error("expected a miss after Delete")
+	}
+
+	expired := NewMemoryTeamPageCache(-time.Second)
+	_ = expired.Set(ctx, "t-1", data)
+	if _, ok, _ := expired.Get(ctx, "t-1"); ok {
+		t.Error("expected an expired entry to miss")
+	}
+}
+
+// newTestRaiselyTeamServer serves team t-1 with members m-1 and m-2,
+// recording the path of every request.
+func newTestRaiselyTeamServer(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu gosync.Mutex
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/v3/profiles/t-1/members":
+			_, _ = w.Write([]byte(`{"data":[{"uuid":"m-1"},{"uuid":"m-2"}]}`))
+		case "/v3/profiles/t-1":
+			_, _ = w.Write([]byte(`{"data":{"uuid":"t-1","type":"GROUP","name":"Team"}}`))
+		default:
+			uuid := strings.TrimPrefix(r.URL.Path, "/v3/profiles/")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+				"uuid": uuid, "type": "INDIVIDUAL", "parent": map[string]string{"uuid": "t-1", "type": "GROUP"},
+			}})
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		result := slices.Clone(paths)
+		paths = nil
+		slices.Sort(result)
+		return result
+	}
+}
+
+func TestService_IncrementalTeamSync(t *testing.T) {
+	t.Parallel()
+	srv, requested := newTestRaiselyTeamServer(t)
+	mapper := &dataRecordingOrttoMapper{}
+	s := newTestSyncCampaignService(srv.URL, mapper, nil)
+	s.incrementalTeams = true
+	s.fetcher.TeamPageCache = NewMemoryTeamPageCache(time.Hour)
+	ctx := t.Context()
+
+	contactIDs := func(req OrttoRequest) []string {
+		contacts, _ := req.AsOrttoContactsRequest()
+		var ids []string
+		for _, c := range contacts.Contacts {
+			ids = append(ids, c.Fields["str::p2p-id"].(string))
+		}
+		return ids
+	}
+
+	// A member event before the team is cached fetches the whole team once, but maps only the member.
+	req, _, err := s.MapByWebhookModel("INDIVIDUAL", "m-1", "GROUP", "t-1", false, "profile.updated", ctx)
+	if err != nil {
+		t.Fatalf("MapByWebhookModel returned unexpected error: %v", err)
+	}
+	if got := contactIDs(req); !slices.Equal(got, []string{"m-1"}) {
+		t.Errorf("contacts = %v, want only m-1", got)
+	}
+	if got := requested(); !slices.Equal(got, []string{"/v3/profiles/m-1", "/v3/profiles/m-1", "/v3/profiles/m-2", "/v3/profiles/t-1", "/v3/profiles/t-1/members"}) {
+		t.Errorf("requested %v", got)
+	}
+
+	// Once cached, a member event fetches the member alone, and is mapped with the rest of the team.
+	req, _, err = s.MapFundraisingProfile("m-2", ctx)
+	if err != nil {
+		t.Fatalf("MapFundraisingProfile returned unexpected error: %v", err)
+	}
+	if got := contactIDs(req); !slices.Equal(got, []string{"m-2"}) {
+		t.Errorf("contacts = %v, want only m-2", got)
+	}
+	if got := requested(); !slices.Equal(got, []string{"/v3/profiles/m-2"}) {
+		t.Errorf("requested %v", got)
+	}
+	if got := len(mapper.team.MemberPages); got != 2 {
+		t.Errorf("mapped with %d member pages, want the whole team", got)
+	}
+
+	// A new member is merged into the cached team.
+	req, _, err = s.MapByWebhookModel("INDIVIDUAL", "m-3", "GROUP", "t-1", false, "profile.created", ctx)
+	if err != nil {
+		t.Fatalf("MapByWebhookModel returned unexpected error: %v", err)
+	}
+	if got := contactIDs(req); !slices.Equal(got, []string{"m-3"}) {
+		t.Errorf("contacts = %v, want only m-3", got)
+	}
+	if got := requested(); !slices.Equal(got, []string{"/v3/profiles/m-3"}) {
+		t.Errorf("requested %v", got)
+	}
+	if cached, _, _ := s.fetcher.TeamPageCache.Get(ctx, "t-1"); len(cached.MemberPages) != 3 || len(cached.Team.TeamMembers) != 3 {
+		t.Errorf("cached team has %d member pages and %d members, want 3", len(cached.MemberPages), len(cached.Team.TeamMembers))
+	}
+
+	// A team event still maps every member.
+	req, _, err = s.MapByWebhookModel("GROUP", "t-1", "CAMPAIGN", "campaign-profile", false, "profile.updated", ctx)
+	if err != nil {
+		t.Fatalf("MapByWebhookModel returned unexpected error: %v", err)
+	}
+	if got := contactIDs(req); !slices.Equal(got, []string{"m-1", "m-2"}) {
+		t.Errorf("contacts = %v, want every member", got)
+	}
+	if got := requested(); !slices.Equal(got, []string{"/v3/profiles/m-1", "/v3/profiles/m-2", "/v3/profiles/t-1", "/v3/profiles/t-1/members"}) {
+		t.Errorf("requested %v", got)
+	}
+}
+
+func TestService_FullTeamSyncByDefault(t *testing.T) {
+	t.Parallel()
+	srv, requested := newTestRaiselyTeamServer(t)
+	s := newTestSyncCampaignService(srv.URL, &recordingOrttoMapper{}, nil)
+
+	req, _, err := s.MapByWebhookModel("INDIVIDUAL", "m-1", "GROUP", "t-1", false, "profile.updated", t.Context())
+	if err != nil {
+		t.Fatalf("MapByWebhookModel returned unexpected error: %v", err)
+	}
+	if req.ItemCount() != 2 {
+		t.Errorf("expected every member to be mapped, got %d", req.ItemCount())
+	}
+	if got := requested(); !slices.Contains(got, "/v3/profiles/t-1/members") {
+		t.Errorf("expected the team's members to be fetched, requested %v", got)
+	}
+}
@@ -3,78 +3,190 @@ package sync
 import (
 	"fmt"
 	"log"
+	"sort"
 	"strings"
+	gosync "sync"
 )
 
-// ApplyFundraiserFieldTransformsParams contains parameters for applying fundraiser field transforms.
-type ApplyFundraiserFieldTransformsParams struct {
-	Config      Config
-	Campaign    *FundraisingCampaign
+// TransformParams is passed to a [TransformFunc] for each configured
+// field transform.
+type TransformParams struct {
+	Field    string      // destination field ID the transform is configured on
+	Value    interface{} // the field's current value
+	Arg      string      // text after the first ':' in the config, e.g. "Bio" in "onlyIfNotDefault:Bio"
+	Campaign *FundraisingCampaign
+
+	// Page is the fundraising page being mapped (for tracking data, the
+	// submitted form). TeamPage is the page's team, or nil if the page is
+	// not being mapped as a team member.
+	Page     FundraisingPage
+	TeamPage *FundraisingPage
+
+	// Destination is the contact or activity being mapped. Transforms
+	// update the field through it.
 	Destination Mappable
 }
 
-// ApplyFundraiserFieldTransforms applies configured transforms to a field and maps it to the provided destination.
-// This is shared between OrttoContactsMapper and OrttoActivitiesMapper.
-func ApplyFundraiserFieldTransforms(params ApplyFundraiserFieldTransformsParams) error {
-	if len(params.Config.FundraiserFieldTransforms) == 0 {
+// TransformFunc implements a named field transform. A returned error
+// aborts mapping of the page.
+type TransformFunc func(params TransformParams) error
+
+var (
+	transformsMu gosync.RWMutex
+	transforms   = make(map[string]TransformFunc)
+)
+
+// RegisterTransform makes a field transform available to the
+// fundraiserFieldTransforms and teamFieldTransforms config sections
+// under name (see [TransformPipeline]). It is intended to be called
+// from init functions, before any config is loaded, and panics if name
+// is empty or contains ':', if fn is nil, or if name is already
+// registered.
+func RegisterTransform(name string, fn TransformFunc) {
+	if name == "" || strings.Contains(name, ":") {
+		panic(fmt.Sprintf("sync: invalid transform name %q", name))
+	}
+	if fn == nil {
+		panic("sync: RegisterTransform fn is nil for " + name)
+	}
+	transformsMu.Lock()
+	defer transformsMu.Unlock()
+	if _, dup := transforms[name]; dup {
+		panic("sync: RegisterTransform called twice for " + name)
+	}
+	transforms[name] = fn
+}
+
+// RegisteredTransforms returns the sorted names of all registered
+// transforms, including the built-ins.
+func RegisteredTransforms() []string {
+	transformsMu.RLock()
+	defer transformsMu.RUnlock()
+	names := make([]string, 0, len(transforms))
+	for name := range transforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupTransform returns the registered transform for a config value
+// ("name" or "name:arg") along with its argument.
+func lookupTransform(transform string) (TransformFunc, string, bool) {
+	parts := strings.Split(transform, ":")
+	arg := ""
+	if len(parts) > 1 {
+		arg = parts[1]
+	}
+	transformsMu.RLock()
+	defer transformsMu.RUnlock()
+	fn, ok := transforms[parts[0]]
+	return fn, arg, ok
+}
+
+// TransformPipeline is the ordered list of transforms configured for a
+// field, applied in sequence so each sees the value left by the one
+// before. In YAML it is either a single transform or a list:
+//
+//	fundraiserFieldTransforms:
+//	  "str:cm:team-type": "onlyIfOrgTypeSchool"
+//	  "str:cm:shirt-size": [trim, toLower, "onlyIfNotDefault:Shirt size"]
+type TransformPipeline []string
+
+// UnmarshalYAML accepts a single transform string or a list of them.
+func (p *TransformPipeline) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var single string
+	if err := unmarshal(&single); err == nil {
+		*p = TransformPipeline{single}
 		return nil
 	}
+	var list []string
+	if err := unmarshal(&list); err != nil {
+		return fmt.Errorf("transforms must be a string or a list of strings: %w", err)
+	}
+	*p = list
+	return nil
+}
 
-	fields := params.Destination.GetFields()
+// sortedTransformFields returns the configured field IDs in the order
+// their pipelines are applied.
+func sortedTransformFields(configured map[string]TransformPipeline) []string {
+	fields := make([]string, 0, len(configured))
+	for field := range configured {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
 
-	for field, transform := range params.Config.FundraiserFieldTransforms {
-		if _, exists := fields[field]; !exists {
-			return fmt.Errorf("invalid transform, field %s does not exist", field)
+// validateTransforms checks every transform in a config section names a
+// registered transform.
+func validateTransforms(key string, configured map[string]TransformPipeline) error {
+	var errs []string
+	for _, field := range sortedTransformFields(configured) {
+		for _, transform := range configured[field] {
+			if _, _, ok := lookupTransform(transform); !ok {
+				errs = append(errs, fmt.Sprintf("%s: unsupported transform: %s", field, transform))
+			}
 		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid '%s' in yaml config: %s", key, strings.Join(errs, "; "))
+	}
+	return nil
+}
 
-		parts := strings.Split(transform, ":")
-		function := parts[0]
-		arg := ""
-		if len(parts) > 1 {
-			arg = parts[1]
+// applyFieldTransforms runs each field's pipeline against the
+// destination. Fields are processed in sorted field ID order, so
+// transforms that read other fields see a deterministic state. A
+// pipeline stops early if a transform deletes its field.
+func applyFieldTransforms(configured map[string]TransformPipeline, params TransformParams) error {
+	for _, field := range sortedTransformFields(configured) {
+		if _, exists := params.Destination.GetFields()[field]; !exists {
+			return fmt.Errorf("invalid transform, field %s does not exist", field)
 		}
 
-		switch function {
-		case "blankIfDefault":
-			log.Println("Warning: transform 'blankIfDefault' is deprecated please switch to using 'onlyIfNotDefault' instead")
-			if fieldValue, ok := fields[field].(string); ok {
-				for _, defaultObject := range params.Campaign.FundraisingPageDefaults {
-					if arg == defaultObject.Label && fieldValue == defaultObject.Value {
-						params.Destination.SetField(field, "")
-					}
-				}
+		for _, transform := range configured[field] {
+			value, exists := params.Destination.GetFields()[field]
+			if !exists {
+				break
 			}
-
-		case "onlyIfNotDefault":
-			if fieldValue, ok := fields[field].(string); ok {
-				for _, defaultObject := range params.Campaign.FundraisingPageDefaults {
-					if arg == defaultObject.Label && fieldValue == defaultObject.Value {
-						params.Destination.DeleteField(field)
-					}
-				}
+			fn, arg, ok := lookupTransform(transform)
+			if !ok {
+				return fmt.Errorf("unsupported transform: %s", transform)
 			}
-
-		case "warnIfEqual":
-			if s := fmt.Sprintf("%v", fields[field]); arg == s {
-				log.Printf("Warning: %s has value of '%v'\n", field, s)
+			params.Field, params.Value, params.Arg = field, value, arg
+			if err := fn(params); err != nil {
+				return fmt.Errorf("transform %s on %s: %w", transform, field, err)
 			}
+		}
+	}
 
-		case "toUpper":
-			if fieldValue, ok := fields[field].(string); ok {
-				params.Destination.SetField(field, strings.ToUpper(fieldValue))
-			}
+	return nil
+}
 
-		case "toLower":
-			if fieldValue, ok := fields[field].(string); ok {
-				params.Destination.SetField(field, strings.ToLower(fieldValue))
-			}
+// ApplyFundraiserFieldTransformsParams contains parameters for applying fundraiser field transforms.
+type ApplyFundraiserFieldTransformsParams struct {
+	Config      Config
+	Campaign    *FundraisingCampaign
+	Page        FundraisingPage
+	TeamPage    *FundraisingPage // nil unless Page is being mapped as a team member
+	Destination Mappable
+}
 
-		default:
-			return fmt.Errorf("unsupported transform: %s", transform)
-		}
+// ApplyFundraiserFieldTransforms applies configured transforms to a field and maps it to the provided destination.
+// This is shared between OrttoContactsMapper and OrttoActivitiesMapper.
+func ApplyFundraiserFieldTransforms(params ApplyFundraiserFieldTransformsParams) error {
+	if len(params.Config.FundraiserFieldTransforms) == 0 {
+		return nil
 	}
 
-	return nil
+	return applyFieldTransforms(params.Config.FundraiserFieldTransforms, TransformParams{
+		Campaign:    params.Campaign,
+		Page:        params.Page,
+		TeamPage:    params.TeamPage,
+		Destination: params.Destination,
+	})
 }
 
 // ApplyTeamFieldTransforms applies team-specific transforms to a field and maps it to the provided destination.
@@ -84,44 +196,143 @@ func ApplyTeamFieldTransforms(
 	teammemberpage FundraisingPage,
 	teampage FundraisingPage,
 	destination Mappable,
+) error {
+	return applyTeamFieldTransforms(config, nil, teammemberpage, teampage, destination)
+}
+
+// applyTeamFieldTransforms is ApplyTeamFieldTransforms with the campaign
+// passed through to transforms that need it (e.g. blankIfDefault).
+func applyTeamFieldTransforms(
+	config Config,
+	campaign *FundraisingCampaign,
+	teammemberpage FundraisingPage,
+	teampage FundraisingPage,
+	destination Mappable,
 ) error {
 	if len(config.TeamFieldTransforms) == 0 {
 		return nil
 	}
 
-	captain, err := teammemberpage.HasSameOwnerAs(teampage)
-	if err != nil {
-		return err
+	return applyFieldTransforms(config.TeamFieldTransforms, TransformParams{
+		Campaign:    campaign,
+		Page:        teammemberpage,
+		TeamPage:    &teampage,
+		Destination: destination,
+	})
+}
+
+// --- Built-in transforms ---
+
+func init() {
+	RegisterTransform("trim", trimTransform)
+	RegisterTransform("blankIfDefault", blankIfDefaultTransform)
+	RegisterTransform("onlyIfNotDefault", onlyIfNotDefaultTransform)
+	RegisterTransform("warnIfEqual", warnIfEqualTransform)
+	RegisterTransform("toUpper", toUpperTransform)
+	RegisterTransform("toLower", toLowerTransform)
+	RegisterTransform("isCaptain", isCaptainTransform)
+	RegisterTransform("isMember", isMemberTransform)
+	RegisterTransform("onlyIfOrgTypeSchool", onlyIfOrgTypeSchoolTransform)
+}
+
+// isFundraisingPageDefault reports whether value is the campaign's
+// default for the page default labelled label.
+func isFundraisingPageDefault(campaign *FundraisingCampaign, label string, value string) bool {
+	if campaign == nil {
+		return false
 	}
+	for _, defaultObject := range campaign.FundraisingPageDefaults {
+		if label == defaultObject.Label && value == defaultObject.Value {
+			return true
+		}
+	}
+	return false
+}
 
-	orgType, _ := teampage.Source.StringForPath("public.organisationType")
-	if orgType != "" {
-		orgType = strings.ToLower(orgType)
+func blankIfDefaultTransform(p TransformParams) error {
+	log.Println("Warning: transform 'blankIfDefault' is deprecated please switch to using 'onlyIfNotDefault' instead")
+	if fieldValue, ok := p.Value.(string); ok && isFundraisingPageDefault(p.Campaign, p.Arg, fieldValue) {
+		p.Destination.SetField(p.Field, "")
 	}
+	return nil
+}
 
-	fields := destination.GetFields()
+func onlyIfNotDefaultTransform(p TransformParams) error {
+	if fieldValue, ok := p.Value.(string); ok && isFundraisingPageDefault(p.Campaign, p.Arg, fieldValue) {
+		p.Destination.DeleteField(p.Field)
+	}
+	return nil
+}
 
-	for field, transform := range config.TeamFieldTransforms {
-		if _, exists := fields[field]; !exists {
-			return fmt.Errorf("invalid transform, field %s does not exist", field)
-		}
+func warnIfEqualTransform(p TransformParams) error {
+	if s := fmt.Sprintf("%v", p.Value); p.Arg == s {
+		log.Printf("Warning: %s has value of '%v'\n", p.Field, s)
+	}
+	return nil
+}
 
-		parts := strings.Split(transform, ":")
-		function := parts[0]
-
-		switch function {
-		case "isCaptain":
-			destination.SetField(field, captain)
-		case "isMember":
-			destination.SetField(field, !captain)
-		case "onlyIfOrgTypeSchool":
-			if orgType != "school" {
-				destination.DeleteField(field)
-			}
-		default:
-			return fmt.Errorf("unsupported transform: %s", transform)
-		}
+func trimTransform(p TransformParams) error {
+	if fieldValue, ok := p.Value.(string); ok {
+		p.Destination.SetField(p.Field, strings.TrimSpace(fieldValue))
 	}
+	return nil
+}
+
+func toUpperTransform(p TransformParams) error {
+	if fieldValue, ok := p.Value.(string); ok {
+		p.Destination.SetField(p.Field, strings.ToUpper(fieldValue))
+	}
+	return nil
+}
 
+func toLowerTransform(p TransformParams) error {
+	if fieldValue, ok := p.Value.(string); ok {
+		p.Destination.SetField(p.Field, strings.ToLower(fieldValue))
+	}
+	return nil
+}
+
+// The team transforms below can also be configured under
+// fundraiserFieldTransforms, where pages not mapped as a team member
+// have no TeamPage: such a page is neither a captain nor a member, and
+// has no school team.
+
+// isTeamCaptain reports whether the page being mapped owns its team
+// page, and whether it has one at all.
+func isTeamCaptain(p TransformParams) (captain bool, onTeam bool, err error) {
+	if p.TeamPage == nil {
+		return false, false, nil
+	}
+	captain, err = p.Page.HasSameOwnerAs(*p.TeamPage)
+	return captain, true, err
+}
+
+func isCaptainTransform(p TransformParams) error {
+	captain, _, err := isTeamCaptain(p)
+	if err != nil {
+		return err
+	}
+	p.Destination.SetField(p.Field, captain)
+	return nil
+}
+
+func isMemberTransform(p TransformParams) error {
+	captain, onTeam, err := isTeamCaptain(p)
+	if err != nil {
+		return err
+	}
+	p.Destination.SetField(p.Field, onTeam && !captain)
+	return nil
+}
+
+func onlyIfOrgTypeSchoolTransform(p TransformParams) error {
+	if p.TeamPage == nil {
+		p.Destination.DeleteField(p.Field)
+		return nil
+	}
+	orgType, _ := p.TeamPage.Source.StringForPath("public.organisationType")
+	if strings.ToLower(orgType) != "school" {
+		p.Destination.DeleteField(p.Field)
+	}
 	return nil
 }
@@ -0,0 +1,201 @@
+package sync
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+// testTransformCalls records the params of each testSuffix call.
+var testTransformCalls []TransformParams
+
+func init() {
+	RegisterTransform("testSuffix", func(p TransformParams) error {
+		testTransformCalls = append(testTransformCalls, p)
+		if s, ok := p.Value.(string); ok {
+			p.Destination.SetField(p.Field, s+p.Arg)
+		}
+		return nil
+	})
+}
+
+func testPage(json string) FundraisingPage {
+	return FundraisingPage{Source: Source{data: gjson.Parse(json)}}
+}
+
+func TestRegisterTransform_CustomTransform(t *testing.T) {
+	page := testPage(`{"uuid":"page-1","user":{"uuid":"user-1"}}`)
+	campaign := &FundraisingCampaign{Name: "Test"}
+	config := Config{FundraiserFieldTransforms: map[string]TransformPipeline{"str:cm:name": {"testSuffix:-x"}}}
+	contact := OrttoContact{Fields: map[string]interface{}{"str:cm:name": "Ann"}}
+
+	err := ApplyFundraiserFieldTransforms(ApplyFundraiserFieldTransformsParams{
+		Config:      config,
+		Campaign:    campaign,
+		Page:        page,
+		Destination: &contact,
+	})
+	if err != nil {
+		t.Fatalf("ApplyFundraiserFieldTransforms returned unexpected error: %v", err)
+	}
+	if got := contact.Fields["str:cm:name"]; got != "Ann-x" {
+		t.Errorf("str:cm:name = %v, want Ann-x", got)
+	}
+
+	if len(testTransformCalls) == 0 {
+		t.Fatal("expected testSuffix to be called")
+	}
+	p := testTransformCalls[len(testTransformCalls)-1]
+	if p.Campaign != campaign || p.Arg != "-x" || p.Value != "Ann" || p.TeamPage != nil {
+		t.Errorf("unexpected params %+v", p)
+	}
+	if uuid, _ := p.Page.Source.StringForPath("uuid"); uuid != "page-1" {
+		t.Errorf("expected the source page to be passed, got uuid %q", uuid)
+	}
+}
+
+func TestRegisterTransform_Panics(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		name string
+		fn   TransformFunc
+	}{
+		"duplicate builtin": {"toUpper", toLowerTransform},
+		"empty name":        {"", toLowerTransform},
+		"name with colon":   {"a:b", toLowerTransform},
+		"nil func":          {"testNil", nil},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			defer func() {
+				if recover() == nil {
+					t.Error("expected RegisterTransform to panic")
+				}
+			}()
+			RegisterTransform(tc.name, tc.fn)
+		})
+	}
+}
+
+func TestApplyTeamFieldTransforms_Builtins(t *testing.T) {
+	t.Parallel()
+	captain := testPage(`{"user":{"uuid":"user-1"}}`)
+	member := testPage(`{"user":{"uuid":"user-2"}}`)
+	team := testPage(`{"user":{"uuid":"user-1"},"public":{"organisationType":"School"}}`)
+	config := Config{TeamFieldTransforms: map[string]TransformPipeline{
+		"bln:cm:captain": {"isCaptain"},
+		"bln:cm:member":  {"isMember"},
+		"str:cm:school":  {"onlyIfOrgTypeSchool"},
+	}}
+
+	for _, tc := range []struct {
+		page        FundraisingPage
+		wantCaptain bool
+	}{{captain, true}, {member, false}} {
+		contact := OrttoContact{Fields: map[string]interface{}{"bln:cm:captain": nil, "bln:cm:member": nil, "str:cm:school": "Springfield"}}
+		if err := ApplyTeamFieldTransforms(config, tc.page, team, &contact); err != nil {
+			t.Fatalf("ApplyTeamFieldTransforms returned unexpected error: %v", err)
+		}
+		if contact.Fields["bln:cm:captain"] != tc.wantCaptain || contact.Fields["bln:cm:member"] != !tc.wantCaptain {
+			t.Errorf("unexpected captain/member fields %v", contact.Fields)
+		}
+		if _, ok := contact.Fields["str:cm:school"]; !ok {
+			t.Error("expected str:cm:school to be kept for a school team")
+		}
+	}
+
+	// An individual without a team is neither captain nor member, and has no school team.
+	contact := OrttoContact{Fields: map[string]interface{}{"bln:cm:captain": nil, "bln:cm:member": nil, "str:cm:school": "Springfield"}}
+	err := ApplyFundraiserFieldTransforms(ApplyFundraiserFieldTransformsParams{
+		Config:      Config{FundraiserFieldTransforms: config.TeamFieldTransforms},
+		Page:        captain,
+		Destination: &contact,
+	})
+	if err != nil {
+		t.Fatalf("ApplyFundraiserFieldTransforms returned unexpected error: %v", err)
+	}
+	if contact.Fields["bln:cm:captain"] != false || contact.Fields["bln:cm:member"] != false {
+		t.Errorf("unexpected captain/member fields for an individual %v", contact.Fields)
+	}
+	if _, ok := contact.Fields["str:cm:school"]; ok {
+		t.Error("expected str:cm:school to be dropped for an individual")
+	}
+}
+
+func TestYAMLConfigUnmarshaler_RejectsUnknownTransforms(t *testing.T) {
+	t.Parallel()
+	yamlBody := `
+fundraiserFieldTransforms:
+  "str:cm:name": "toUpper"
+teamFieldTransforms:
+  "bln:cm:captain": "isCaptian"
+  "str:cm:name": "testSuffix:!"
+`
+	file := MappingFile{Name: "test.yaml", Reader: strings.NewReader(yamlBody), Length: len(yamlBody)}
+	_, err := YAMLConfigUnmarshaler{}.Unmarshal(JSONCompositeEnvVar{}, file)
+	if err == nil {
+		t.Fatal("expected an error for an unknown transform")
+	}
+	if !strings.Contains(err.Error(), "teamFieldTransforms") || !strings.Contains(err.Error(), "isCaptian") {
+		t.Errorf("error should name the section and transform, got %v", err)
+	}
+	if strings.Contains(err.Error(), "testSuffix") {
+		t.Errorf("registered transforms should be accepted, got %v", err)
+	}
+}
+
+func TestTransformPipeline_YAML(t *testing.T) {
+	t.Parallel()
+	yamlBody := `
+fundraiserFieldTransforms:
+  "str:cm:name": "toUpper"
+  "str:cm:shirt-size": [trim, toLower, "onlyIfNotDefault:Shirt size"]
+`
+	file := MappingFile{Name: "test.yaml", Reader: strings.NewReader(yamlBody), Length: len(yamlBody)}
+	cfg, err := YAMLConfigUnmarshaler{}.Unmarshal(JSONCompositeEnvVar{}, file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.FundraiserFieldTransforms["str:cm:name"]; len(got) != 1 || got[0] != "toUpper" {
+		t.Errorf("single-string form: got %q", got)
+	}
+	got := cfg.FundraiserFieldTransforms["str:cm:shirt-size"]
+	if strings.Join(got, ",") != "trim,toLower,onlyIfNotDefault:Shirt size" {
+		t.Errorf("list form: got %q", got)
+	}
+}
+
+func TestApplyFieldTransforms_Pipeline(t *testing.T) {
+	t.Parallel()
+	campaign := &FundraisingCampaign{FundraisingPageDefaults: []CampaignDefault{{Label: "Shirt size", Value: "m"}}}
+	config := Config{FundraiserFieldTransforms: map[string]TransformPipeline{
+		"str:cm:shirt-size": {"trim", "toLower", "onlyIfNotDefault:Shirt size", "toUpper"},
+		"str:cm:name":       {"trim", "toUpper"},
+	}}
+
+	for _, tc := range []struct {
+		size     string
+		wantSize interface{}
+	}{
+		{" M ", nil}, // trimmed and lowered to the default, then deleted
+		{" XL", "XL"},
+	} {
+		contact := OrttoContact{Fields: map[string]interface{}{"str:cm:shirt-size": tc.size, "str:cm:name": " ann "}}
+		err := ApplyFundraiserFieldTransforms(ApplyFundraiserFieldTransformsParams{
+			Config:      config,
+			Campaign:    campaign,
+			Destination: &contact,
+		})
+		if err != nil {
+			t.Fatalf("ApplyFundraiserFieldTransforms returned unexpected error: %v", err)
+		}
+		if got := contact.Fields["str:cm:shirt-size"]; got != tc.wantSize {
+			t.Errorf("size %q: got %v, want %v", tc.size, got, tc.wantSize)
+		}
+		if got := contact.Fields["str:cm:name"]; got != "ANN" {
+			t.Errorf("str:cm:name = %v, want ANN", got)
+		}
+	}
+}
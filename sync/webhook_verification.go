@@ -0,0 +1,232 @@
+package sync
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	gosync "sync"
+	"time"
+)
+
+// RaiselyWebhookSecretKey is the key in the campaign env-var JSON that
+// holds the shared secret Raisely sends in every webhook body (the
+// [Webhook] Secret field).
+const RaiselyWebhookSecretKey = "RAISELY_WEBHOOK_SECRET"
+
+// DefaultWebhookMaxAge is how far a webhook's CreatedAt may be from the
+// current time (in either direction) before [VerifyWebhook] rejects it
+// as stale. Raisely redelivers a failed webhook with the original
+// CreatedAt and keeps retrying for hours, so the window is wide enough
+// to accept those redeliveries; replays within it are caught by the
+// [WebhookEventStore]. It also bounds how long the store needs to
+// remember an event UUID: anything older is rejected before the store
+// is consulted.
+const DefaultWebhookMaxAge = 72 * time.Hour
+
+// Reasons carried by [WebhookRejectedError].
+const (
+	WebhookRejectedSecretMismatch = "secret-mismatch"
+	WebhookRejectedMalformed      = "malformed"
+	WebhookRejectedStale          = "stale"
+	WebhookRejectedReplayed       = "replayed"
+)
+
+// WebhookRejectedError is returned by [VerifyWebhook] when a webhook
+// fails verification. Reason is one of the WebhookRejected* constants;
+// callers typically respond 401 for a secret mismatch and 200 (ack
+// without processing) for a replay so Raisely stops redelivering.
+//
+// A missing secret in the campaign env-var JSON is a configuration
+// problem, not a rejection, and is reported as a plain error.
+type WebhookRejectedError struct {
+	Reason    string
+	EventUUID string
+	Detail    string
+}
+
+// Error implements the error interface.
+func (e *WebhookRejectedError) Error() string {
+	msg := fmt.Sprintf("webhook rejected: %s (event=%s)", e.Reason, e.EventUUID)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return msg
+}
+
+// IsWebhookRejected reports whether err is (or wraps) a
+// [*WebhookRejectedError] with the given reason. An empty reason
+// matches any rejection.
+func IsWebhookRejected(err error, reason string) bool {
+	var rejected *WebhookRejectedError
+	if !errors.As(err, &rejected) {
+		return false
+	}
+	return reason == "" || rejected.Reason == reason
+}
+
+// WebhookEventStore records the UUIDs of webhook events that have
+// already been accepted, so [VerifyWebhook] can reject redeliveries and
+// replays. Implementations typically live downstream in a shared
+// cross-process store; [MemoryWebhookEventStore] is provided for single
+// instance deployments and tests.
+//
+// MarkSeen must be atomic: when two callers race on the same eventUUID
+// exactly one of them sees seen=false, so concurrent redeliveries are
+// not processed twice. Entries only need to be retained until expiresAt
+// — after that the event is rejected as stale before the store is
+// consulted. A non-nil err is returned to the caller of VerifyWebhook
+// (fail closed), since silently accepting would defeat replay protection.
+//
+// Forget removes an entry recorded by MarkSeen, so a redelivery of an
+// event whose processing failed is accepted (see [WebhookRelease]).
+type WebhookEventStore interface {
+	MarkSeen(ctx context.Context, eventUUID string, expiresAt time.Time) (seen bool, err error)
+	Forget(ctx context.Context, eventUUID string) error
+}
+
+// WebhookRelease is returned by [VerifyWebhook] for an accepted webhook.
+// The event is claimed in the event store while it is processed; if
+// processing fails, call the release so Raisely's redelivery of the
+// event is accepted rather than rejected as a replay. Do not call it
+// once the event has been processed. It is a no-op without a store.
+type WebhookRelease func(ctx context.Context) error
+
+// MemoryWebhookEventStore is an in-process [WebhookEventStore]. Expired
+// entries are pruned as new events are recorded. Safe for concurrent use.
+type MemoryWebhookEventStore struct {
+	mu     gosync.Mutex
+	events map[string]time.Time
+	now    func() time.Time
+}
+
+// NewMemoryWebhookEventStore returns an empty MemoryWebhookEventStore.
+func NewMemoryWebhookEventStore() *MemoryWebhookEventStore {
+	return &MemoryWebhookEventStore{events: make(map[string]time.Time), now: time.Now}
+}
+
+// MarkSeen implements [WebhookEventStore].
+func (s *MemoryWebhookEventStore) MarkSeen(ctx context.Context, eventUUID string, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for id, expiry := range s.events {
+		if now.After(expiry) {
+			delete(s.events, id)
+		}
+	}
+
+	if _, seen := s.events[eventUUID]; seen {
+		return true, nil
+	}
+	s.events[eventUUID] = expiresAt
+	return false, nil
+}
+
+// Forget implements [WebhookEventStore].
+func (s *MemoryWebhookEventStore) Forget(ctx context.Context, eventUUID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.events, eventUUID)
+	return nil
+}
+
+// webhookVerifyOptions holds optional configuration for VerifyWebhook.
+type webhookVerifyOptions struct {
+	store  WebhookEventStore
+	maxAge time.Duration
+	now    func() time.Time
+}
+
+// WebhookVerifyOption is a functional option for configuring VerifyWebhook.
+type WebhookVerifyOption func(*webhookVerifyOptions)
+
+// WebhookVerifyWithEventStore enables replay rejection by event UUID.
+// Without a store, VerifyWebhook only checks the secret and CreatedAt.
+func WebhookVerifyWithEventStore(store WebhookEventStore) WebhookVerifyOption {
+	return func(o *webhookVerifyOptions) {
+		o.store = store
+	}
+}
+
+// WebhookVerifyWithMaxAge overrides [DefaultWebhookMaxAge].
+func WebhookVerifyWithMaxAge(maxAge time.Duration) WebhookVerifyOption {
+	return func(o *webhookVerifyOptions) {
+		o.maxAge = maxAge
+	}
+}
+
+// VerifyWebhook checks a decoded Raisely webhook before it is processed:
+//
+//  1. The Secret must equal the campaign's [RaiselyWebhookSecretKey],
+//     read through compositeEnvVar (typically
+//     JSONCompositeEnvVar{Parent: config.EnvVar.Name}). Compared in
+//     constant time.
+//  2. The event must carry a Uuid and an RFC3339 CreatedAt within maxAge
+//     of now.
+//  3. With an event store configured, the Uuid must not have been seen
+//     before. It is then claimed, so concurrent redeliveries are rejected
+//     while it is processed.
+//
+// The checks run in that order so unauthenticated requests never reach
+// the event store. Failures are reported as [*WebhookRejectedError].
+//
+// On success the returned [WebhookRelease] must be called if processing
+// the event fails, otherwise every redelivery is rejected as a replay
+// and the event is lost:
+//
+//	release, err := sync.VerifyWebhook(webhook, env, ctx, sync.WebhookVerifyWithEventStore(store))
+//	if err != nil { ... }
+//	if err := process(webhook); err != nil {
+//		_ = release(ctx)
+//		return err
+//	}
+func VerifyWebhook(webhook Webhook, compositeEnvVar CompositeEnvVar, ctx context.Context, opts ...WebhookVerifyOption) (WebhookRelease, error) {
+	noRelease := func(context.Context) error { return nil }
+
+	o := webhookVerifyOptions{maxAge: DefaultWebhookMaxAge, now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	expected, ok := compositeEnvVar.LookupEnv(RaiselyWebhookSecretKey)
+	if !ok || expected == "" {
+		return nil, fmt.Errorf("%s is not set for this campaign", RaiselyWebhookSecretKey)
+	}
+
+	eventUUID := webhook.Data.Uuid
+	if subtle.ConstantTimeCompare([]byte(webhook.Secret), []byte(expected)) != 1 {
+		return nil, &WebhookRejectedError{Reason: WebhookRejectedSecretMismatch, EventUUID: eventUUID}
+	}
+
+	if eventUUID == "" {
+		return nil, &WebhookRejectedError{Reason: WebhookRejectedMalformed, Detail: "missing event uuid"}
+	}
+	createdAt, err := time.Parse(time.RFC3339, webhook.Data.CreatedAt)
+	if err != nil {
+		return nil, &WebhookRejectedError{Reason: WebhookRejectedMalformed, EventUUID: eventUUID, Detail: fmt.Sprintf("invalid createdAt %q", webhook.Data.CreatedAt)}
+	}
+
+	age := o.now().Sub(createdAt)
+	if age > o.maxAge || age < -o.maxAge {
+		return nil, &WebhookRejectedError{Reason: WebhookRejectedStale, EventUUID: eventUUID, Detail: fmt.Sprintf("createdAt %s", webhook.Data.CreatedAt)}
+	}
+
+	if o.store == nil {
+		return noRelease, nil
+	}
+	seen, err := o.store.MarkSeen(ctx, eventUUID, createdAt.Add(o.maxAge))
+	if err != nil {
+		return nil, fmt.Errorf("failed to record webhook event %s: %w", eventUUID, err)
+	}
+	if seen {
+		return nil, &WebhookRejectedError{Reason: WebhookRejectedReplayed, EventUUID: eventUUID}
+	}
+	return func(ctx context.Context) error {
+		if err := o.store.Forget(ctx, eventUUID); err != nil {
+			return fmt.Errorf("failed to release webhook event %s: %w", eventUUID, err)
+		}
+		return nil
+	}, nil
+}
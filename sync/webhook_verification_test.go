@@ -0,0 +1,217 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	gosync "sync"
+	"testing"
+	"time"
+)
+
+func newTestWebhook(secret, uuid string, createdAt time.Time) Webhook {
+	var webhook Webhook
+	webhook.Secret = secret
+	webhook.Data.Uuid = uuid
+	webhook.Data.Type = "profile.updated"
+	webhook.Data.CreatedAt = createdAt.UTC().Format(time.RFC3339)
+	return webhook
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	env := MapCompositeEnvVar{Values: map[string]string{RaiselyWebhookSecretKey: "s3cret"}}
+	fixedNow := func(o *webhookVerifyOptions) { o.now = func() time.Time { return now } }
+
+	cases := []struct {
+		name    string
+		webhook Webhook
+		reason  string
+	}{
+		{"valid", newTestWebhook("s3cret", "evt-1", now.Add(-time.Minute)), ""},
+		{"wrong secret", newTestWebhook("guess", "evt-1", now), WebhookRejectedSecretMismatch},
+		{"empty secret", newTestWebhook("", "evt-1", now), WebhookRejectedSecretMismatch},
+		{"missing uuid", newTestWebhook("s3cret", "", now), WebhookRejectedMalformed},
+		{"too old", newTestWebhook("s3cret", "evt-1", now.Add(-DefaultWebhookMaxAge-time.Second)), WebhookRejectedStale},
+		{"too far in the future", newTestWebhook("s3cret", "evt-1", now.Add(DefaultWebhookMaxAge+time.Second)), WebhookRejectedStale},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := VerifyWebhook(tc.webhook, env, t.Context(), fixedNow)
+			if tc.reason == "" {
+				if err != nil {
+					t.Fatalf("VerifyWebhook returned unexpected error: %v", err)
+				}
+				return
+			}
+			if !IsWebhookRejected(err, tc.reason) {
+				t.Fatalf("VerifyWebhook error = %v, want rejection %q", err, tc.reason)
+			}
+		})
+	}
+
+	t.Run("invalid createdAt", func(t *testing.T) {
+		t.Parallel()
+		webhook := newTestWebhook("s3cret", "evt-1", now)
+		webhook.Data.CreatedAt = "yesterday"
+		if _, err := VerifyWebhook(webhook, env, t.Context(), fixedNow); !IsWebhookRejected(err, WebhookRejectedMalformed) {
+			t.Fatalf("VerifyWebhook error = %v, want malformed rejection", err)
+		}
+	})
+}
+
+func TestVerifyWebhook_MissingConfiguredSecret(t *testing.T) {
+	t.Parallel()
+	env := MapCompositeEnvVar{Values: map[string]string{}}
+	_, err := VerifyWebhook(newTestWebhook("", "evt-1", time.Now()), env, t.Context())
+	if err == nil {
+		t.Fatal("expected an error when the campaign has no webhook secret")
+	}
+	if IsWebhookRejected(err, "") {
+		t.Fatalf("missing configuration should not be reported as a rejection: %v", err)
+	}
+}
+
+func TestVerifyWebhook_RejectsReplays(t *testing.T) {
+	t.Parallel()
+	env := MapCompositeEnvVar{Values: map[string]string{RaiselyWebhookSecretKey: "s3cret"}}
+	store := NewMemoryWebhookEventStore()
+	webhook := newTestWebhook("s3cret", "evt-1", time.Now())
+
+	if _, err := VerifyWebhook(webhook, env, t.Context(), WebhookVerifyWithEventStore(store)); err != nil {
+		t.Fatalf("first delivery returned unexpected error: %v", err)
+	}
+	_, err := VerifyWebhook(webhook, env, t.Context(), WebhookVerifyWithEventStore(store))
+	if !IsWebhookRejected(err, WebhookRejectedReplayed) {
+		t.Fatalf("second delivery error = %v, want replay rejection", err)
+	}
+
+	// A bad secret must not consume the event UUID.
+	other := newTestWebhook("guess", "evt-2", time.Now())
+	if _, err := VerifyWebhook(other, env, t.Context(), WebhookVerifyWithEventStore(store)); !IsWebhookRejected(err, WebhookRejectedSecretMismatch) {
+		t.Fatalf("unexpected error for bad secret: %v", err)
+	}
+	other.Secret = "s3cret"
+	if _, err := VerifyWebhook(other, env, t.Context(), WebhookVerifyWithEventStore(store)); err != nil {
+		t.Fatalf("authentic delivery after a forged one returned unexpected error: %v", err)
+	}
+}
+
+type failingWebhookEventStore struct{}
+
+func (failingWebhookEventStore) MarkSeen(context.Context, string, time.Time) (bool, error) {
+	return false, errors.New("store unavailable")
+}
+
+func (failingWebhookEventStore) Forget(context.Context, string) error {
+	return errors.New("store unavailable")
+}
+
+func TestVerifyWebhook_ReleaseAcceptsRedelivery(t *testing.T) {
+	t.Parallel()
+	env := MapCompositeEnvVar{Values: map[string]string{RaiselyWebhookSecretKey: "s3cret"}}
+	store := NewMemoryWebhookEventStore()
+	webhook := newTestWebhook("s3cret", "evt-1", time.Now())
+
+	// First delivery is accepted but processing fails (e.g. Ortto 5xx).
+	release, err := VerifyWebhook(webhook, env, t.Context(), WebhookVerifyWithEventStore(store))
+	if err != nil {
+		t.Fatalf("first delivery returned unexpected error: %v", err)
+	}
+	if _, err := VerifyWebhook(webhook, env, t.Context(), WebhookVerifyWithEventStore(store)); !IsWebhookRejected(err, WebhookRejectedReplayed) {
+		t.Fatalf("delivery while processing error = %v, want replay rejection", err)
+	}
+	if err := release(t.Context()); err != nil {
+		t.Fatalf("release returned unexpected error: %v", err)
+	}
+
+	// Raisely's redelivery is accepted, and once processed further ones are replays.
+	if _, err := VerifyWebhook(webhook, env, t.Context(), WebhookVerifyWithEventStore(store)); err != nil {
+		t.Fatalf("redelivery after release returned unexpected error: %v", err)
+	}
+	if _, err := VerifyWebhook(webhook, env, t.Context(), WebhookVerifyWithEventStore(store)); !IsWebhookRejected(err, WebhookRejectedReplayed) {
+		t.Fatalf("redelivery after success error = %v, want replay rejection", err)
+	}
+
+	// Without a store the release is a no-op.
+	release, err = VerifyWebhook(webhook, env, t.Context())
+	if err != nil || release(t.Context()) != nil {
+		t.Fatalf("unexpected error without a store: %v", err)
+	}
+}
+
+func TestVerifyWebhook_AcceptsLateRedelivery(t *testing.T) {
+	t.Parallel()
+	env := MapCompositeEnvVar{Values: map[string]string{RaiselyWebhookSecretKey: "s3cret"}}
+	store := NewMemoryWebhookEventStore()
+	// Raisely redelivers with the original CreatedAt, hours after the
+	// first attempt failed.
+	webhook := newTestWebhook("s3cret", "evt-1", time.Now().Add(-6*time.Hour))
+
+	release, err := VerifyWebhook(webhook, env, t.Context(), WebhookVerifyWithEventStore(store))
+	if err != nil {
+		t.Fatalf("late redelivery returned unexpected error: %v", err)
+	}
+	if _, err := VerifyWebhook(webhook, env, t.Context(), WebhookVerifyWithEventStore(store)); !IsWebhookRejected(err, WebhookRejectedReplayed) {
+		t.Fatalf("replay of a late redelivery error = %v, want replay rejection", err)
+	}
+	if err := release(t.Context()); err != nil {
+		t.Fatalf("release returned unexpected error: %v", err)
+	}
+	if _, err := VerifyWebhook(webhook, env, t.Context(), WebhookVerifyWithEventStore(store)); err != nil {
+		t.Fatalf("redelivery after release returned unexpected error: %v", err)
+	}
+}
+
+func TestVerifyWebhook_StoreErrorFailsClosed(t *testing.T) {
+	t.Parallel()
+	env := MapCompositeEnvVar{Values: map[string]string{RaiselyWebhookSecretKey: "s3cret"}}
+	_, err := VerifyWebhook(newTestWebhook("s3cret", "evt-1", time.Now()), env, t.Context(), WebhookVerifyWithEventStore(failingWebhookEventStore{}))
+	if err == nil {
+		t.Fatal("expected store error to be returned")
+	}
+}
+
+func TestMemoryWebhookEventStore(t *testing.T) {
+	t.Parallel()
+
+	t.Run("expired entries are pruned", func(t *testing.T) {
+		t.Parallel()
+		now := time.Now()
+		store := NewMemoryWebhookEventStore()
+		store.now = func() time.Time { return now }
+
+		if seen, _ := store.MarkSeen(t.Context(), "evt-1", now.Add(time.Minute)); seen {
+			t.Fatal("first MarkSeen reported seen")
+		}
+		now = now.Add(2 * time.Minute)
+		if seen, _ := store.MarkSeen(t.Context(), "evt-1", now.Add(time.Minute)); seen {
+			t.Fatal("MarkSeen after expiry reported seen")
+		}
+	})
+
+	t.Run("concurrent callers see exactly one first delivery", func(t *testing.T) {
+		t.Parallel()
+		store := NewMemoryWebhookEventStore()
+		var wg gosync.WaitGroup
+		var mu gosync.Mutex
+		first := 0
+		for range 20 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				seen, _ := store.MarkSeen(context.Background(), "evt-1", time.Now().Add(time.Minute))
+				if !seen {
+					mu.Lock()
+					first++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		if first != 1 {
+			t.Fatalf("%d callers saw a first delivery, want 1", first)
+		}
+	})
+}